@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aku-web/internal/player"
+)
+
+// HandleCacheStats 处理获取音频缓存统计的请求
+func HandleCacheStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := player.GetCacheStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// HandleCacheList 处理列出音频缓存的请求
+func HandleCacheList(w http.ResponseWriter, r *http.Request) {
+	entries, err := player.ListCache()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// HandleCachePurge 处理清除音频缓存的请求，url 为地址或缓存列表中的 key，为空时清除全部（正在播放的歌曲除外）
+func HandleCachePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		URL string `json:"url"`
+	}
+	// 允许空请求体
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	removed, err := player.PurgeCache(request.URL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"removed": removed,
+	})
+}
@@ -48,6 +48,7 @@ func HandleShowText(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	acquireDisplayFocus()
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -84,6 +85,7 @@ func HandleShowImage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	acquireDisplayFocus()
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -159,6 +161,7 @@ func HandleShowGif(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	acquireDisplayFocus()
 
 	w.WriteHeader(http.StatusOK)
 }
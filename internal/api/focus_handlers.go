@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"aku-web/internal/config"
+	"aku-web/internal/focus"
+	"aku-web/internal/player"
+	"aku-web/internal/service"
+)
+
+// 音频焦点的来源
+const (
+	focusAlarm   = "alarm"
+	focusXiaozhi = "xiaozhi"
+	focusDisplay = "display"
+)
+
+var focusManager *focus.Manager
+
+// playerFocusTarget 焦点管理器控制默认播放器
+type playerFocusTarget struct{}
+
+func (playerFocusTarget) Duck(percent int) error { return player.SetDuck(percent) }
+func (playerFocusTarget) Pause() (bool, error)   { return player.PauseIfPlaying() }
+func (playerFocusTarget) Resume() error          { return player.ResumePlayback() }
+
+// InitFocus 按配置创建音频焦点管理器，并根据小智主服务的输出占用和释放焦点
+func InitFocus() {
+	rules := make(map[string]focus.Rule, len(config.FocusActions))
+	for source, action := range config.FocusActions {
+		rules[source] = focus.Rule{Action: focus.Action(action)}
+	}
+	for i, source := range config.FocusPriority {
+		rule := rules[source]
+		if rule.Action == "" {
+			rule.Action = focus.ActionNone
+		}
+		rule.Priority = len(config.FocusPriority) - i
+		rules[source] = rule
+	}
+	focusManager = focus.New(playerFocusTarget{}, rules, config.FocusDuckPercent)
+
+	service.SetActivityHandler(func(name string, active bool) {
+		if name != "xiaozhi" {
+			return
+		}
+		if active {
+			focusManager.Acquire(focusXiaozhi, config.FocusXiaozhiHold*time.Second)
+		} else {
+			focusManager.Release(focusXiaozhi)
+		}
+	})
+}
+
+// acquireDisplayFocus 屏幕显示内容后短暂占用焦点，并暂停推送歌词
+func acquireDisplayFocus() {
+	holdLyrics()
+	if focusManager != nil {
+		focusManager.Acquire(focusDisplay, config.FocusDisplayHold*time.Second)
+	}
+}
+
+// HandleFocusStatus 处理获取音频焦点状态的请求
+func HandleFocusStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(focusManager.Status())
+}
+
+// HandleFocusRules 处理修改焦点规则的请求，rules 为各来源的处理方式和优先级，修改只在本次运行期间有效
+func HandleFocusRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := focusManager.Status()
+	request := struct {
+		Rules       map[string]focus.Rule `json:"rules"`
+		DuckPercent *int                  `json:"duck_percent"`
+	}{Rules: status.Rules}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	duckPercent := status.DuckPercent
+	if request.DuckPercent != nil {
+		duckPercent = *request.DuckPercent
+	}
+	if err := focusManager.SetRules(request.Rules, duckPercent); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	HandleFocusStatus(w, r)
+}
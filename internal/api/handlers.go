@@ -88,19 +88,19 @@ func HandleStreamStop(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// HandleVolumeGet 处理获取音量的请求
+// HandleVolumeGet 处理获取音量的请求，返回原始值、百分比、分贝、静音状态和控制项的范围
 func HandleVolumeGet(w http.ResponseWriter, r *http.Request) {
-	volume, err := player.GetVolume()
+	info, err := player.GetVolumeInfo()
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get volume: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]int{"volume": volume})
+	json.NewEncoder(w).Encode(info)
 }
 
-// HandleVolumeSet 处理设置音量的请求
+// HandleVolumeSet 处理设置音量的请求，volume 为控制项的原始值，也可以传 percent（0-100）或 db
 func HandleVolumeSet(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -108,30 +108,40 @@ func HandleVolumeSet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var request struct {
-		Volume interface{} `json:"volume"`
+		Volume  interface{} `json:"volume"`
+		Percent *int        `json:"percent"`
+		DB      *float64    `json:"db"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	var volume int
-	switch v := request.Volume.(type) {
-	case float64:
-		volume = int(v)
-	case string:
-		var err error
-		volume, err = strconv.Atoi(v)
-		if err != nil {
-			http.Error(w, "Invalid volume value", http.StatusBadRequest)
+	var err error
+	switch {
+	case request.Percent != nil:
+		err = player.SetVolumePercent(*request.Percent)
+	case request.DB != nil:
+		err = player.SetVolumeDB(*request.DB)
+	default:
+		var volume int
+		switch v := request.Volume.(type) {
+		case float64:
+			volume = int(v)
+		case string:
+			var convErr error
+			volume, convErr = strconv.Atoi(v)
+			if convErr != nil {
+				http.Error(w, "Invalid volume value", http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "Invalid volume type", http.StatusBadRequest)
 			return
 		}
-	default:
-		http.Error(w, "Invalid volume type", http.StatusBadRequest)
-		return
+		err = player.SetVolume(volume)
 	}
-
-	if err := player.SetVolume(volume); err != nil {
+	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to set volume: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -139,6 +149,56 @@ func HandleVolumeSet(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// HandleVolumeMute 处理静音和取消静音的请求
+func HandleVolumeMute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Muted bool `json:"muted"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := player.SetMute(request.Muted); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	HandleVolumeGet(w, r)
+}
+
+// HandleVolumeControls 处理列出混音器控制项的请求
+func HandleVolumeControls(w http.ResponseWriter, r *http.Request) {
+	controls, err := player.MixerControls()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(controls)
+}
+
+// InitNetease 按配置创建网易云音乐客户端
+func InitNetease() {
+	client := netease.NewClient(config.NeteaseBaseURL, config.NeteaseTimeout*time.Second)
+	if config.NeteaseUserAgent != "" {
+		client.UserAgent = config.NeteaseUserAgent
+	}
+	client.SetCacheTTL(config.NeteasePlaylistTTL*time.Second, config.NeteaseSongTTL*time.Second)
+	if config.NeteaseCAFile != "" {
+		if err := client.LoadCABundle(config.NeteaseCAFile); err != nil {
+			log.Printf("加载网易云根证书失败: %v", err)
+		}
+	}
+	netease.SetDefaultClient(client)
+}
+
 // HandlePlaylistPlay 处理播放歌单歌曲的请求
 func HandlePlaylistPlay(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -164,6 +224,8 @@ func HandlePlaylistPlay(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "无法获取可播放的URL", http.StatusInternalServerError)
 		return
 	}
+	rememberSongURL(url, request.SongId)
+	player.RememberSongURL(url, request.SongId)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -284,6 +346,7 @@ func HandleServiceStart(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Failed to start service: %v", err), http.StatusInternalServerError)
 		return
 	}
+	updateServiceSource(request.Service, true)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -313,6 +376,7 @@ func HandleServiceStop(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Failed to stop service: %v", err), http.StatusInternalServerError)
 		return
 	}
+	updateServiceSource(request.Service, false)
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aku-web/internal/config"
+	"aku-web/internal/mixer"
+	"aku-web/internal/netease"
+	"aku-web/internal/netease/neteasetest"
+	"aku-web/internal/player"
+)
+
+// useFakeNetease 让网易云接口的处理函数使用模拟服务器，测试结束后恢复默认客户端
+func useFakeNetease(t *testing.T) *neteasetest.Server {
+	t.Helper()
+	server := neteasetest.NewServer()
+	t.Cleanup(server.Close)
+
+	for i, name := range []string{"晴天", "七里香", "稻香"} {
+		song := neteasetest.Song{Id: uint(i + 1), Name: name, Artists: []string{"周杰伦"}}
+		if i < 2 {
+			song.Audio = []byte("ID3 fake audio")
+		}
+		server.AddSong(song)
+	}
+	server.AddPlaylist(neteasetest.Playlist{Id: 100, Name: "测试歌单", TrackIds: []uint{1, 2, 3}})
+
+	previous := netease.DefaultClient()
+	netease.SetDefaultClient(server.NeteaseClient())
+	t.Cleanup(func() { netease.SetDefaultClient(previous) })
+	return server
+}
+
+func TestHandlePlaylistDetail(t *testing.T) {
+	useFakeNetease(t)
+
+	w := httptest.NewRecorder()
+	HandlePlaylistDetail(w, httptest.NewRequest(http.MethodGet, "/api/playlist/detail?id=100&page=2&pageSize=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 期望 200: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Status   string         `json:"status"`
+		Songs    []netease.Song `json:"songs"`
+		Page     int            `json:"page"`
+		PageSize int            `json:"pageSize"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.Status != "success" || resp.Page != 2 || resp.PageSize != 2 {
+		t.Errorf("响应 = %+v", resp)
+	}
+	if len(resp.Songs) != 1 || resp.Songs[0].Id != 3 || resp.Songs[0].Name != "稻香" {
+		t.Errorf("第 2 页的歌曲 = %+v, 期望只有 3 稻香", resp.Songs)
+	}
+
+	tests := []struct {
+		method string
+		target string
+		code   int
+	}{
+		{http.MethodPost, "/api/playlist/detail?id=100", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/playlist/detail", http.StatusBadRequest},
+		{http.MethodGet, "/api/playlist/detail?id=404", http.StatusInternalServerError},
+		{http.MethodGet, "/api/playlist/detail?id=100&page=3&pageSize=2", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		HandlePlaylistDetail(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s %s 状态码 = %d, 期望 %d", tt.method, tt.target, w.Code, tt.code)
+		}
+	}
+}
+
+func TestHandlePlaylistPlay(t *testing.T) {
+	server := useFakeNetease(t)
+
+	w := httptest.NewRecorder()
+	HandlePlaylistPlay(w, httptest.NewRequest(http.MethodPost, "/api/playlist/play", strings.NewReader(`{"song_id":2}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 期望 200: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Status string `json:"status"`
+		URL    string `json:"url"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if resp.Status != "success" || resp.URL != server.MediaURL(2) {
+		t.Errorf("响应 = %+v, 期望地址 %s", resp, server.MediaURL(2))
+	}
+
+	// 解析出的地址要记下来，通过地址播放时也能找到歌词
+	lyricsTracker.mutex.Lock()
+	songId := lyricsTracker.songURLs[resp.URL]
+	lyricsTracker.mutex.Unlock()
+	if songId != 2 {
+		t.Errorf("播放地址对应的歌曲ID = %d, 期望 2", songId)
+	}
+
+	tests := []struct {
+		method string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "not json", http.StatusBadRequest},
+		{http.MethodPost, `{"song_id":3}`, http.StatusInternalServerError}, // 没有音频，跳转到 /404
+		{http.MethodPost, `{"song_id":999}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		HandlePlaylistPlay(w, httptest.NewRequest(tt.method, "/api/playlist/play", strings.NewReader(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%s %q 状态码 = %d, 期望 %d", tt.method, tt.body, w.Code, tt.code)
+		}
+	}
+}
+
+// useFakeMixer 让音量接口使用假混音器，测试结束后恢复按配置创建的混音器
+func useFakeMixer(t *testing.T, m mixer.Mixer, control string) {
+	t.Helper()
+	player.SetMixer(m, control)
+	t.Cleanup(func() { player.SetMixer(nil, config.MixerControl) })
+}
+
+// volumeRequest 调用音量处理函数，返回状态码和响应
+func volumeRequest(t *testing.T, handler http.HandlerFunc, method, body string) (int, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(method, "/api/volume", strings.NewReader(body)))
+	return w.Code, w.Body.String()
+}
+
+// getVolume 通过 /api/volume/get 读取音量
+func getVolume(t *testing.T) player.VolumeInfo {
+	t.Helper()
+	code, body := volumeRequest(t, HandleVolumeGet, http.MethodGet, "")
+	if code != http.StatusOK {
+		t.Fatalf("读取音量状态码 = %d: %s", code, body)
+	}
+	var info player.VolumeInfo
+	if err := json.Unmarshal([]byte(body), &info); err != nil {
+		t.Fatalf("解析音量失败: %v", err)
+	}
+	return info
+}
+
+func TestHandleVolumeSet(t *testing.T) {
+	m, err := mixer.New("fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useFakeMixer(t, m, "Power Amplifier")
+
+	info := getVolume(t)
+	if info.Control.Name != "Power Amplifier" || info.Control.Max != 63 || info.Raw != 31 {
+		t.Errorf("初始音量 = %+v", info)
+	}
+
+	tests := []struct {
+		body    string
+		raw     int
+		percent int
+		db      float64
+	}{
+		{`{"volume":63}`, 63, 100, 0},
+		{`{"volume":"0"}`, 0, 0, -63},
+		{`{"percent":50}`, 32, 51, -31},
+		{`{"db":-20}`, 43, 68, -20},
+	}
+	for _, tt := range tests {
+		if code, body := volumeRequest(t, HandleVolumeSet, http.MethodPost, tt.body); code != http.StatusOK {
+			t.Fatalf("%s 状态码 = %d: %s", tt.body, code, body)
+		}
+		info := getVolume(t)
+		if info.Raw != tt.raw || info.Percent != tt.percent || info.DB == nil || *info.DB != tt.db {
+			t.Errorf("%s 后音量 = %d %d%% %v, 期望 %d %d%% %vdB", tt.body, info.Raw, info.Percent, info.DB, tt.raw, tt.percent, tt.db)
+		}
+	}
+}
+
+func TestHandleVolumeSetErrors(t *testing.T) {
+	useFakeMixer(t, mixer.NewFake(), "Power Amplifier")
+	if code, body := volumeRequest(t, HandleVolumeSet, http.MethodPost, `{"volume":40}`); code != http.StatusOK {
+		t.Fatalf("状态码 = %d: %s", code, body)
+	}
+
+	tests := []struct {
+		method  string
+		body    string
+		code    int
+		message string
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed, "Method not allowed"},
+		{http.MethodPost, "not json", http.StatusBadRequest, "Invalid request body"},
+		{http.MethodPost, `{"volume":"loud"}`, http.StatusBadRequest, "Invalid volume value"},
+		{http.MethodPost, `{"volume":64}`, http.StatusInternalServerError, "音量必须在 0 到 63 之间"},
+		{http.MethodPost, `{"volume":-1}`, http.StatusInternalServerError, "音量必须在 0 到 63 之间"},
+		{http.MethodPost, `{"percent":101}`, http.StatusInternalServerError, "百分比必须在 0 到 100 之间"},
+		{http.MethodPost, `{"db":1}`, http.StatusInternalServerError, "分贝必须在"},
+	}
+	for _, tt := range tests {
+		code, body := volumeRequest(t, HandleVolumeSet, tt.method, tt.body)
+		if code != tt.code || !strings.Contains(body, tt.message) {
+			t.Errorf("%s %q = %d %q, 期望 %d %q", tt.method, tt.body, code, strings.TrimSpace(body), tt.code, tt.message)
+		}
+	}
+
+	// 设置失败时音量不变
+	if info := getVolume(t); info.Raw != 40 {
+		t.Errorf("设置失败后音量 = %d, 期望 40", info.Raw)
+	}
+}
+
+func TestHandleVolumeMute(t *testing.T) {
+	fake := mixer.NewFake(
+		mixer.Control{Name: "Power Amplifier", Min: 0, Max: 63, HasDB: true, MinDB: -63, MaxDB: 0, HasMute: true},
+		mixer.Control{Name: "Speaker", Min: 0, Max: 31},
+	)
+
+	// 有硬件开关的控制项只切换开关，音量不变
+	useFakeMixer(t, fake, "Power Amplifier")
+	code, body := volumeRequest(t, HandleVolumeMute, http.MethodPost, `{"muted":true}`)
+	if code != http.StatusOK || !strings.Contains(body, `"muted":true`) {
+		t.Fatalf("静音 = %d %s", code, body)
+	}
+	if fake.Value("Power Amplifier") != 31 {
+		t.Errorf("硬件静音后音量 = %d, 期望不变", fake.Value("Power Amplifier"))
+	}
+
+	// 没有硬件开关的控制项把音量设为最小值模拟静音，取消静音时恢复
+	player.SetMixer(fake, "Speaker")
+	volumeRequest(t, HandleVolumeSet, http.MethodPost, `{"volume":20}`)
+	if code, body := volumeRequest(t, HandleVolumeMute, http.MethodPost, `{"muted":true}`); code != http.StatusOK {
+		t.Fatalf("静音状态码 = %d: %s", code, body)
+	}
+	if fake.Value("Speaker") != 0 {
+		t.Errorf("模拟静音后实际音量 = %d, 期望 0", fake.Value("Speaker"))
+	}
+	if info := getVolume(t); !info.Muted || info.Raw != 20 {
+		t.Errorf("模拟静音时读取的音量 = %d, 静音 = %v, 期望 20 和 true", info.Raw, info.Muted)
+	}
+
+	// 静音期间调整音量只记录，不发出声音
+	volumeRequest(t, HandleVolumeSet, http.MethodPost, `{"percent":50}`)
+	if fake.Value("Speaker") != 0 {
+		t.Errorf("静音期间设置音量后实际音量 = %d, 期望 0", fake.Value("Speaker"))
+	}
+
+	if code, body := volumeRequest(t, HandleVolumeMute, http.MethodPost, `{"muted":false}`); code != http.StatusOK {
+		t.Fatalf("取消静音状态码 = %d: %s", code, body)
+	}
+	if fake.Value("Speaker") != 16 {
+		t.Errorf("取消静音后实际音量 = %d, 期望 16", fake.Value("Speaker"))
+	}
+	if info := getVolume(t); info.Muted || info.Raw != 16 {
+		t.Errorf("取消静音后读取的音量 = %d, 静音 = %v", info.Raw, info.Muted)
+	}
+}
+
+func TestHandleVolumeControls(t *testing.T) {
+	m, err := mixer.New("fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useFakeMixer(t, m, "Power Amplifier")
+
+	code, body := volumeRequest(t, HandleVolumeControls, http.MethodGet, "")
+	if code != http.StatusOK {
+		t.Fatalf("状态码 = %d: %s", code, body)
+	}
+	var controls []mixer.Control
+	if err := json.Unmarshal([]byte(body), &controls); err != nil {
+		t.Fatalf("解析控制项失败: %v", err)
+	}
+	if len(controls) != 1 || controls[0].Name != "Power Amplifier" || !controls[0].HasDB || controls[0].MinDB != -63 {
+		t.Errorf("控制项 = %+v", controls)
+	}
+}
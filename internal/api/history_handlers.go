@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"aku-web/internal/history"
+	"aku-web/internal/player"
+)
+
+// 收听统计的默认范围
+const (
+	defaultStatsDays  = 30
+	defaultStatsLimit = 10
+)
+
+var historyStore *history.Store
+
+// InitHistory 打开播放历史并开始记录，打开失败时不记录
+func InitHistory(path string, limit int) {
+	store, err := history.Open(path, limit)
+	if err != nil {
+		log.Printf("打开播放历史失败: %v", err)
+		return
+	}
+	historyStore = store
+	player.SetHistory(store)
+}
+
+// HandleHistory 处理获取播放历史的请求，最新的在前，支持 page、page_size 分页
+func HandleHistory(w http.ResponseWriter, r *http.Request) {
+	if historyStore == nil {
+		http.Error(w, "播放历史不可用", http.StatusServiceUnavailable)
+		return
+	}
+
+	entries := historyStore.Entries()
+	page, pageSize, start, end := pageParams(r, len(entries))
+	writePage(w, entries[start:end], len(entries), page, pageSize)
+}
+
+// HandleStats 处理获取收听统计的请求：最近 days 天（0 为全部）的歌曲和艺术家排行，以及每天的收听时长
+func HandleStats(w http.ResponseWriter, r *http.Request) {
+	if historyStore == nil {
+		http.Error(w, "播放历史不可用", http.StatusServiceUnavailable)
+		return
+	}
+
+	days := defaultStatsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid days", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	limit := defaultStatsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	var since time.Time
+	if days > 0 {
+		// 从 days-1 天前的零点开始，包括今天
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, now.Location())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(historyStore.Stats(since, limit))
+}
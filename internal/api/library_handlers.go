@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"aku-web/internal/library"
+)
+
+var musicLibrary *library.Library
+
+// 分页参数
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// InitLibrary 加载音乐库索引，并在后台扫描一次
+func InitLibrary(indexPath string, dirs []string) {
+	musicLibrary = library.New(indexPath, dirs)
+	go rescanLibrary()
+}
+
+// rescanLibrary 扫描音乐库，出错时只记录日志
+func rescanLibrary() {
+	if musicLibrary == nil {
+		return
+	}
+	if _, err := musicLibrary.Scan(); err != nil {
+		log.Printf("扫描音乐库失败: %v", err)
+	}
+}
+
+// pageParams 解析 page 和 page_size 参数，返回切片范围
+func pageParams(r *http.Request, total int) (page, pageSize, start, end int) {
+	page, _ = strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ = strconv.Atoi(r.URL.Query().Get("page_size"))
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	start = (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end = start + pageSize
+	if end > total {
+		end = total
+	}
+	return page, pageSize, start, end
+}
+
+// writePage 输出一页数据
+func writePage(w http.ResponseWriter, items interface{}, total, page, pageSize int) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":     items,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// HandleLibraryTracks 处理获取歌曲列表的请求，可按 album、artist 过滤
+func HandleLibraryTracks(w http.ResponseWriter, r *http.Request) {
+	tracks := musicLibrary.Tracks(library.TrackFilter{
+		Album:  r.URL.Query().Get("album"),
+		Artist: r.URL.Query().Get("artist"),
+	})
+	page, pageSize, start, end := pageParams(r, len(tracks))
+	writePage(w, tracks[start:end], len(tracks), page, pageSize)
+}
+
+// HandleLibraryAlbums 处理获取专辑列表的请求
+func HandleLibraryAlbums(w http.ResponseWriter, r *http.Request) {
+	albums := musicLibrary.Albums()
+	page, pageSize, start, end := pageParams(r, len(albums))
+	writePage(w, albums[start:end], len(albums), page, pageSize)
+}
+
+// HandleLibraryArtists 处理获取艺术家列表的请求
+func HandleLibraryArtists(w http.ResponseWriter, r *http.Request) {
+	artists := musicLibrary.Artists()
+	page, pageSize, start, end := pageParams(r, len(artists))
+	writePage(w, artists[start:end], len(artists), page, pageSize)
+}
+
+// HandleLibrarySearch 处理搜索本地歌曲的请求
+func HandleLibrarySearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+
+	tracks := musicLibrary.Search(query)
+	page, pageSize, start, end := pageParams(r, len(tracks))
+	writePage(w, tracks[start:end], len(tracks), page, pageSize)
+}
+
+// HandleLibraryCover 处理获取歌曲嵌入封面的请求
+func HandleLibraryCover(w http.ResponseWriter, r *http.Request) {
+	cover, err := musicLibrary.Cover(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", cover.MIME)
+	w.Header().Set("Cache-Control", "max-age=86400")
+	w.Write(cover.Data)
+}
+
+// HandleLibraryRescan 处理重新扫描音乐库的请求
+func HandleLibraryRescan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, err := musicLibrary.Scan()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"aku-web/internal/config"
+	"aku-web/internal/lyrics"
+	"aku-web/internal/netease"
+	"aku-web/internal/player"
+)
+
+// songURLLimit 记住的网易云播放地址数量，超出时清空重新记录
+const songURLLimit = 200
+
+// lyricTracker 跟踪当前歌曲的歌词，播放进度变化时把当前行推送到屏幕
+type lyricTracker struct {
+	mutex      sync.Mutex
+	key        string // 当前歌曲，网易云歌曲为 netease:<ID>，本地文件为路径，没有歌词来源时为空
+	source     string // netease 或 file
+	lyrics     *lyrics.Lyrics
+	loading    bool
+	err        error
+	generation int // 切歌时增加，丢弃旧歌曲的加载结果
+	index      int
+	position   float64
+	playing    bool
+	display    bool
+	holdUntil  time.Time       // 在这之前不推送歌词，避免覆盖其他显示内容
+	shown      string          // 最近一次推送到屏幕的文字
+	songURLs   map[string]uint // 通过 /api/playlist/play 解析出的播放地址对应的歌曲ID
+}
+
+var lyricsTracker = &lyricTracker{
+	index:    -1,
+	display:  config.LyricsDisplay,
+	songURLs: make(map[string]uint),
+}
+
+// InitLyrics 订阅播放器事件，切歌时加载歌词，播放进度变化时更新当前歌词行
+func InitLyrics() {
+	go func() {
+		events, cancel, err := player.SubscribeEvents()
+		if err != nil {
+			log.Printf("[Lyrics] 订阅播放器事件失败: %v", err)
+			return
+		}
+		defer cancel()
+
+		for event := range events {
+			switch event.Type {
+			case player.EventStatus, player.EventState:
+				lyricsTracker.refresh()
+			case player.EventFrame:
+				if event.Frame != nil {
+					lyricsTracker.seek(event.Frame.Seconds)
+				}
+			}
+		}
+	}()
+}
+
+// rememberSongURL 记录网易云歌曲的播放地址，通过地址直接播放时也能找到歌词
+func rememberSongURL(url string, songId uint) {
+	lyricsTracker.mutex.Lock()
+	defer lyricsTracker.mutex.Unlock()
+	if len(lyricsTracker.songURLs) >= songURLLimit {
+		lyricsTracker.songURLs = make(map[string]uint)
+	}
+	lyricsTracker.songURLs[url] = songId
+}
+
+// holdLyrics 其他内容显示到屏幕后暂停推送歌词
+func holdLyrics() {
+	lyricsTracker.mutex.Lock()
+	lyricsTracker.holdUntil = time.Now().Add(config.LyricsHold * time.Second)
+	lyricsTracker.shown = ""
+	lyricsTracker.mutex.Unlock()
+}
+
+// refresh 根据播放器状态检查是否切歌，切歌时在后台加载新歌曲的歌词
+func (t *lyricTracker) refresh() {
+	status, err := player.GetStatus()
+	if err != nil {
+		return
+	}
+
+	t.mutex.Lock()
+	var songId uint
+	key := ""
+	if !status.Live && status.URL != "" {
+		if status.Track != nil && status.Track.SongId != 0 {
+			songId = status.Track.SongId
+		} else if id, ok := t.songURLs[status.URL]; ok {
+			songId = id
+		}
+		if songId != 0 {
+			key = fmt.Sprintf("netease:%d", songId)
+		} else if !strings.Contains(status.URL, "://") {
+			key = status.URL
+		}
+	}
+	t.playing = status.State == "playing"
+
+	if key == t.key {
+		text := t.updateLocked(status.Position)
+		t.mutex.Unlock()
+		t.show(text)
+		return
+	}
+
+	t.generation++
+	t.key, t.lyrics, t.err, t.index, t.position, t.shown = key, nil, nil, -1, status.Position, ""
+	t.source, t.loading = "", key != ""
+	if songId != 0 {
+		t.source = "netease"
+	} else if key != "" {
+		t.source = "file"
+	}
+	generation := t.generation
+	t.mutex.Unlock()
+
+	if key != "" {
+		go t.load(generation, songId, key)
+	}
+}
+
+// load 获取网易云歌词或读取本地文件同名的 .lrc 文件
+func (t *lyricTracker) load(generation int, songId uint, path string) {
+	var l *lyrics.Lyrics
+	var err error
+	if songId != 0 {
+		var lyric *netease.Lyric
+		if lyric, err = netease.GetLyric(songId); err == nil && lyric.Lrc != "" {
+			l = lyrics.Parse(lyric.Lrc)
+			l.Translate(lyrics.Parse(lyric.Translation))
+		}
+	} else {
+		l, err = lyrics.LoadSidecar(path)
+	}
+	if err != nil {
+		log.Printf("[Lyrics] 加载 %s 的歌词失败: %v", path, err)
+	}
+
+	t.mutex.Lock()
+	if generation != t.generation {
+		t.mutex.Unlock()
+		return
+	}
+	t.loading, t.lyrics, t.err = false, l, err
+	text := t.updateLocked(t.position)
+	t.mutex.Unlock()
+	t.show(text)
+}
+
+// seek 播放进度变化时更新当前行
+func (t *lyricTracker) seek(position float64) {
+	t.mutex.Lock()
+	text := t.updateLocked(position)
+	t.mutex.Unlock()
+	t.show(text)
+}
+
+// updateLocked 更新当前行，返回需要推送到屏幕的文字，不需要推送时返回空字符串
+func (t *lyricTracker) updateLocked(position float64) string {
+	t.position = position
+	if t.lyrics.Empty() {
+		return ""
+	}
+	t.index = t.lyrics.Index(position)
+	if t.index < 0 || !t.display || !t.playing || time.Now().Before(t.holdUntil) {
+		return ""
+	}
+
+	line := t.lyrics.Lines[t.index]
+	text := line.Text
+	if config.LyricsTranslation && line.Translation != "" {
+		text += "\n" + line.Translation
+	}
+	// 间奏的空行保留上一句
+	if text == "" || text == t.shown {
+		return ""
+	}
+	t.shown = text
+	return text
+}
+
+// show 把歌词推送到屏幕，不占用音频焦点
+func (t *lyricTracker) show(text string) {
+	if text == "" || displayManager == nil {
+		return
+	}
+	if err := displayManager.ShowText(text, config.LyricsFontSize, config.LyricsColor, 1, 1); err != nil {
+		log.Printf("[Lyrics] 显示歌词失败: %v", err)
+	}
+}
+
+// lyricsStatus 当前歌词的状态
+type lyricsStatus struct {
+	Key       string         `json:"key,omitempty"`
+	Source    string         `json:"source,omitempty"`
+	Available bool           `json:"available"`
+	Loading   bool           `json:"loading,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Display   bool           `json:"display"`
+	Position  float64        `json:"position"`
+	Index     int            `json:"index"`
+	Line      *lyrics.Line   `json:"line,omitempty"`
+	Next      *lyrics.Line   `json:"next,omitempty"`
+	Lyrics    *lyrics.Lyrics `json:"lyrics,omitempty"`
+}
+
+// status 返回当前歌词的状态，full 为 true 时包含全部歌词
+func (t *lyricTracker) status(full bool) lyricsStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	s := lyricsStatus{
+		Key:       t.key,
+		Source:    t.source,
+		Available: !t.lyrics.Empty(),
+		Loading:   t.loading,
+		Display:   t.display,
+		Position:  t.position,
+		Index:     -1,
+	}
+	if t.err != nil {
+		s.Error = t.err.Error()
+	}
+	if !s.Available {
+		return s
+	}
+	s.Index = t.index
+	if t.index >= 0 {
+		line := t.lyrics.Lines[t.index]
+		s.Line = &line
+	}
+	if t.index+1 < len(t.lyrics.Lines) {
+		next := t.lyrics.Lines[t.index+1]
+		s.Next = &next
+	}
+	if full {
+		s.Lyrics = t.lyrics
+	}
+	return s
+}
+
+// HandleLyricsCurrent 处理获取当前歌词行的请求，full=1 时同时返回全部歌词
+func HandleLyricsCurrent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lyricsTracker.status(r.URL.Query().Get("full") == "1"))
+}
+
+// HandleLyricsDisplay 处理开关屏幕歌词的请求，修改只在本次运行期间有效
+func HandleLyricsDisplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	lyricsTracker.mutex.Lock()
+	lyricsTracker.display = request.Enabled
+	lyricsTracker.shown = ""
+	lyricsTracker.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lyricsTracker.status(false))
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"aku-web/internal/config"
+	"aku-web/internal/library"
+)
+
+// UploadedFile 上传成功的文件
+type UploadedFile struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"` // 相对于音乐目录
+	Size   int64  `json:"size"`
+	Format string `json:"format"`
+}
+
+// UploadError 上传失败的文件
+type UploadError struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// HandleMusicUpload 处理上传音乐文件的请求，表单字段 files 可以包含多个文件，dir 为目标子目录
+func HandleMusicUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, config.MaxUploadSize)
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "文件太大或表单格式错误", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	dir, err := library.SafeJoin(config.MusicDir, r.FormValue("dir"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+		http.Error(w, "目标目录不存在", http.StatusBadRequest)
+		return
+	}
+
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		http.Error(w, "没有上传文件", http.StatusBadRequest)
+		return
+	}
+
+	// 检查磁盘空间
+	var total int64
+	for _, header := range files {
+		total += header.Size
+	}
+	if free, err := library.FreeSpace(dir); err == nil && free < uint64(total)+config.MinFreeSpace {
+		http.Error(w, fmt.Sprintf("磁盘空间不足: 需要 %d 字节，可用 %d 字节", total, free), http.StatusInsufficientStorage)
+		return
+	}
+
+	uploaded := []UploadedFile{}
+	failed := []UploadError{}
+	for _, header := range files {
+		file, err := saveUpload(dir, header)
+		if err != nil {
+			log.Printf("上传 %s 失败: %v", header.Filename, err)
+			failed = append(failed, UploadError{Name: header.Filename, Error: err.Error()})
+			continue
+		}
+		uploaded = append(uploaded, *file)
+	}
+
+	if len(uploaded) > 0 {
+		go rescanLibrary()
+	}
+
+	status := http.StatusOK
+	if len(uploaded) == 0 {
+		status = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"uploaded": uploaded,
+		"failed":   failed,
+	})
+}
+
+// saveUpload 校验并保存单个上传文件，先写入临时文件，成功后再重命名
+func saveUpload(dir string, header *multipart.FileHeader) (*UploadedFile, error) {
+	name := filepath.Base(filepath.Clean("/" + filepath.ToSlash(header.Filename)))
+	if err := library.ValidName(name); err != nil {
+		return nil, err
+	}
+	if !library.IsAudioExt(name) {
+		return nil, fmt.Errorf("不支持的文件格式")
+	}
+	if header.Size > config.MaxUploadFileSize {
+		return nil, fmt.Errorf("文件超过 %d MB", config.MaxUploadFileSize>>20)
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer src.Close()
+
+	// 根据文件内容识别格式，防止把其他文件改名后上传
+	head := make([]byte, 12)
+	n, _ := io.ReadFull(src, head)
+	format := library.SniffFormat(head[:n])
+	if format == "" || !library.MatchesExt(format, name) {
+		return nil, fmt.Errorf("文件内容不是有效的音频")
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	target := library.UniquePath(filepath.Join(dir, name))
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建文件失败: %v", err)
+	}
+	written, err := io.Copy(tmp, io.LimitReader(src, config.MaxUploadFileSize+1))
+	tmp.Close()
+	if err == nil && written > config.MaxUploadFileSize {
+		err = fmt.Errorf("文件超过 %d MB", config.MaxUploadFileSize>>20)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("保存文件失败: %v", err)
+	}
+
+	return &UploadedFile{
+		Name:   filepath.Base(target),
+		Path:   relMusicPath(target),
+		Size:   written,
+		Format: format,
+	}, nil
+}
+
+// relMusicPath 返回相对于音乐目录的路径
+func relMusicPath(path string) string {
+	root, _ := filepath.Abs(config.MusicDir)
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// HandleMusicRename 处理重命名音乐文件或目录的请求
+func HandleMusicRename(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Path string `json:"path"`
+		Name string `json:"name"` // 新名称，不含目录
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	src, err := musicPath(request.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := library.ValidName(request.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	stat, err := os.Stat(src)
+	if err != nil {
+		http.Error(w, "文件不存在", http.StatusNotFound)
+		return
+	}
+	if !stat.IsDir() && !library.IsAudioExt(request.Name) {
+		http.Error(w, "不支持的文件格式", http.StatusBadRequest)
+		return
+	}
+
+	dst := filepath.Join(filepath.Dir(src), request.Name)
+	if _, err := os.Lstat(dst); err == nil {
+		http.Error(w, "目标文件已存在", http.StatusConflict)
+		return
+	}
+	if err := os.Rename(src, dst); err != nil {
+		http.Error(w, fmt.Sprintf("重命名失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	go rescanLibrary()
+	writeFileResult(w, dst)
+}
+
+// HandleMusicDelete 处理删除音乐文件或目录的请求，非空目录需要 recursive 为 true
+func HandleMusicDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Path      string `json:"path"`
+		Recursive bool   `json:"recursive"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	path, err := musicPath(request.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	stat, err := os.Lstat(path)
+	if err != nil {
+		http.Error(w, "文件不存在", http.StatusNotFound)
+		return
+	}
+
+	if stat.IsDir() && !request.Recursive {
+		if entries, err := os.ReadDir(path); err == nil && len(entries) > 0 {
+			http.Error(w, "目录不为空", http.StatusConflict)
+			return
+		}
+	}
+
+	if stat.IsDir() && request.Recursive {
+		err = os.RemoveAll(path)
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("删除失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	go rescanLibrary()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// HandleMusicMkdir 处理在音乐目录下创建文件夹的请求
+func HandleMusicMkdir(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	path, err := musicPath(request.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := library.ValidName(filepath.Base(path)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		http.Error(w, fmt.Sprintf("创建目录失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeFileResult(w, path)
+}
+
+// musicPath 把请求中的相对路径转换为音乐目录下的路径，不允许操作音乐目录本身
+func musicPath(rel string) (string, error) {
+	path, err := library.SafeJoin(config.MusicDir, rel)
+	if err != nil {
+		return "", err
+	}
+	if relMusicPath(path) == "." {
+		return "", fmt.Errorf("不能操作音乐根目录")
+	}
+	return path, nil
+}
+
+// writeFileResult 返回操作后的相对路径
+func writeFileResult(w http.ResponseWriter, path string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "success",
+		"path":   relMusicPath(path),
+	})
+}
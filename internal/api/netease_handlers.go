@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"aku-web/internal/lyrics"
+	"aku-web/internal/netease"
+)
+
+// 网易云搜索的分页参数
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+// HandleNeteaseSearch 处理网易云搜索的请求，q 为关键字，type 为 song、album、artist 或 playlist（默认 song），
+// 支持 page、page_size 分页。歌曲不包含播放地址，通过 song_id 播放或加入播放队列
+func HandleNeteaseSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+	kind, err := netease.ParseSearchType(r.URL.Query().Get("type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if pageSize < 1 {
+		pageSize = defaultSearchPageSize
+	}
+	if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+
+	result, err := netease.Search(query, kind, (page-1)*pageSize, pageSize)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("搜索失败: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	var items interface{}
+	switch kind {
+	case netease.SearchAlbum:
+		items = result.Albums
+	case netease.SearchArtist:
+		items = result.Artists
+	case netease.SearchPlaylist:
+		items = result.Playlists
+	default:
+		items = result.Songs
+	}
+	writePage(w, items, result.Total, page, pageSize)
+}
+
+// HandleNeteaseAlbum 处理获取网易云专辑详情和歌曲的请求
+func HandleNeteaseAlbum(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	album, err := netease.GetAlbum(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("获取专辑失败: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(album)
+}
+
+// HandleNeteaseArtistSongs 处理获取网易云艺术家热门歌曲的请求
+func HandleNeteaseArtistSongs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	songs, err := netease.GetArtistTopSongs(id)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("获取热门歌曲失败: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":    id,
+		"songs": songs,
+	})
+}
+
+// HandleNeteaseLyric 处理获取网易云歌曲歌词的请求，返回按时间解析并合并翻译后的歌词行
+func HandleNeteaseLyric(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 0)
+	if err != nil || id == 0 {
+		http.Error(w, "Invalid id", http.StatusBadRequest)
+		return
+	}
+
+	lyric, err := netease.GetLyric(uint(id))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("获取歌词失败: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	l := lyrics.Parse(lyric.Lrc)
+	l.Translate(lyrics.Parse(lyric.Translation))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":           id,
+		"instrumental": lyric.Instrumental,
+		"lyrics":       l,
+	})
+}
+
+// HandleNeteaseCacheStats 处理获取网易云歌单和歌曲详情缓存统计的请求
+func HandleNeteaseCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(netease.GetCacheStats())
+}
+
+// HandleNeteaseCacheClear 处理清空网易云歌单和歌曲详情缓存的请求，歌单修改后可以立即看到新的歌曲
+func HandleNeteaseCacheClear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	netease.ClearCache()
+	HandleNeteaseCacheStats(w, r)
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"aku-web/internal/config"
+	"aku-web/internal/player"
+)
+
+// HandlePlayerStatus 处理获取播放器状态的请求
+func HandlePlayerStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := player.GetStatus()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandlePlayerEvents 通过 SSE 推送播放器状态（进度、状态、歌曲信息、缓存进度和音量）
+func HandlePlayerEvents(w http.ResponseWriter, r *http.Request) {
+	events, cancel, err := player.SubscribeEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cancel()
+
+	// 设置 SSE 头
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// 监控客户端断开连接
+	notify := r.Context().Done()
+	// 定时推送，保证缓存进度等没有事件的数据也能更新
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	send := func() bool {
+		status, err := player.GetStatus()
+		if err != nil {
+			return false
+		}
+		data, err := json.Marshal(status)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return true
+	}
+
+	if !send() {
+		return
+	}
+
+	for {
+		select {
+		case <-notify:
+			log.Printf("Client disconnected from player events")
+			return
+		case event := <-events:
+			// 只有影响状态的事件才需要推送
+			switch event.Type {
+			case player.EventFrame, player.EventState, player.EventTag,
+				player.EventTrackEnd, player.EventStatus:
+			default:
+				continue
+			}
+			if !send() {
+				return
+			}
+		case <-ticker.C:
+			if !send() {
+				return
+			}
+		}
+	}
+}
+
+// HandlePlayerSleep 处理设置睡眠定时器的请求。minutes 为定时分钟数，end_of_track 为 true 时在当前歌曲结束时停止，
+// fade 为停止前音量渐弱的秒数，不传时使用默认值
+func HandlePlayerSleep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Minutes    float64 `json:"minutes"`
+		EndOfTrack bool    `json:"end_of_track"`
+		Fade       *int    `json:"fade"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	fade := config.SleepFadeSeconds
+	if request.Fade != nil {
+		fade = *request.Fade
+	}
+	duration := time.Duration(request.Minutes * float64(time.Minute))
+	status, err := player.SetSleepTimer(duration, request.EndOfTrack, time.Duration(fade)*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// HandlePlayerSleepCancel 处理取消睡眠定时器的请求
+func HandlePlayerSleepCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := player.CancelSleepTimer(); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// HandlePlayerCrossfade 处理设置淡出淡入的请求，seconds 为自动切换到队列下一首时淡出淡入的秒数，0 表示关闭
+func HandlePlayerCrossfade(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Seconds int `json:"seconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := player.SetCrossfade(request.Seconds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"crossfade": request.Seconds})
+}
+
+// HandlePlayerReplayGain 处理设置响度均衡的请求，mode 为 off、track 或 album，preamp 为额外调整的分贝
+func HandlePlayerReplayGain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Mode   string  `json:"mode"`
+		Preamp float64 `json:"preamp"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := player.SetReplayGain(request.Mode, request.Preamp); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"mode": request.Mode, "preamp": request.Preamp})
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"aku-web/internal/config"
+	"aku-web/internal/player"
+	"aku-web/internal/playlist"
+)
+
+var playlistStore *playlist.Store
+
+// InitPlaylists 加载保存的歌单
+func InitPlaylists(path string) {
+	playlistStore = playlist.NewStore(path)
+}
+
+// writePlaylist 返回歌单详情
+func writePlaylist(w http.ResponseWriter, p *playlist.Playlist) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// HandlePlaylistList 处理获取歌单列表的请求
+func HandlePlaylistList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(playlistStore.List())
+}
+
+// HandlePlaylistGet 处理获取歌单详情的请求
+func HandlePlaylistGet(w http.ResponseWriter, r *http.Request) {
+	p, err := playlistStore.Get(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writePlaylist(w, p)
+}
+
+// HandlePlaylistCreate 处理创建歌单的请求
+func HandlePlaylistCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Name    string           `json:"name"`
+		Entries []playlist.Entry `json:"entries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	p, err := playlistStore.Create(request.Name, request.Entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writePlaylist(w, p)
+}
+
+// HandlePlaylistUpdate 处理修改歌单的请求，entries 不传时只修改名称
+func HandlePlaylistUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID      string           `json:"id"`
+		Name    string           `json:"name"`
+		Entries []playlist.Entry `json:"entries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	p, err := playlistStore.Update(request.ID, request.Name, request.Entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writePlaylist(w, p)
+}
+
+// HandlePlaylistDelete 处理删除歌单的请求
+func HandlePlaylistDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := playlistStore.Delete(request.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// HandlePlaylistImport 处理导入歌单文件的请求，表单字段 file 为 M3U/M3U8/PLS/XSPF 文件，name 为歌单名称
+func HandlePlaylistImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(5 << 20); err != nil { // 限制 5MB
+		http.Error(w, "文件太大", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "无法获取上传的文件", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "读取文件失败", http.StatusBadRequest)
+		return
+	}
+
+	format, err := playlist.DetectFormat(header.Filename, data)
+	if value := r.FormValue("format"); value != "" {
+		format, err = playlist.ParseFormat(value)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := playlist.Parse(format, bytes.NewReader(data))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(entries) == 0 {
+		http.Error(w, "歌单中没有歌曲", http.StatusBadRequest)
+		return
+	}
+
+	name := r.FormValue("name")
+	if name == "" {
+		name = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	}
+	p, err := playlistStore.Create(name, entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writePlaylist(w, p)
+}
+
+// HandlePlaylistExport 处理导出歌单的请求，format 为 m3u、m3u8、pls 或 xspf，默认 m3u8
+func HandlePlaylistExport(w http.ResponseWriter, r *http.Request) {
+	p, err := playlistStore.Get(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	format := playlist.FormatM3U8
+	if value := r.URL.Query().Get("format"); value != "" {
+		if format, err = playlist.ParseFormat(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := playlist.Write(format, &buf, p); err != nil {
+		http.Error(w, fmt.Sprintf("导出歌单失败: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	filename := p.Name + "." + string(format)
+	w.Header().Set("Content-Type", format.ContentType()+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Write(buf.Bytes())
+}
+
+// HandlePlaylistLoad 处理把歌单加载到播放队列的请求。append 为 true 时追加到队列末尾，play 为 true 时从 index 开始播放
+func HandlePlaylistLoad(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID     string `json:"id"`
+		Append bool   `json:"append"`
+		Play   bool   `json:"play"`
+		Index  int    `json:"index"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	p, err := playlistStore.Get(request.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	start, err := loadPlaylist(p, request.Append)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !request.Play {
+		queue, _ := player.GetQueue()
+		writeQueueState(w, queue)
+		return
+	}
+	duration, err := player.QueuePlay(start + request.Index)
+	writePlayResult(w, duration, err)
+}
+
+// loadPlaylist 把歌单加载到播放队列，appendItems 为 false 时先清空队列。返回歌单第一首在队列中的下标
+func loadPlaylist(p *playlist.Playlist, appendItems bool) (int, error) {
+	queue, err := player.GetQueue()
+	if err != nil {
+		return 0, err
+	}
+
+	items := make([]player.QueueItem, 0, len(p.Entries))
+	for _, e := range p.Entries {
+		items = append(items, entryToQueueItem(e))
+	}
+
+	start := 0
+	if appendItems {
+		start = len(queue.Snapshot().Items)
+	} else {
+		queue.Clear()
+	}
+	queue.Append(items...)
+	return start, nil
+}
+
+// entryToQueueItem 把歌单项转换为队列项，本地相对路径基于音乐目录
+func entryToQueueItem(e playlist.Entry) player.QueueItem {
+	item := player.QueueItem{
+		Title:   e.Title,
+		Artists: e.Artists,
+		URL:     e.URL,
+		SongId:  e.SongId,
+	}
+	if e.Path != "" {
+		item.URL = e.Path
+		if !filepath.IsAbs(e.Path) {
+			item.URL = filepath.Join(config.MusicDir, filepath.FromSlash(e.Path))
+		}
+	}
+	return item
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"aku-web/internal/netease"
+	"aku-web/internal/player"
+)
+
+func init() {
+	// 网易云歌曲在播放时才解析URL，避免队列中的地址过期
+	player.SetURLResolver(func(item player.QueueItem) (string, error) {
+		if item.SongId == 0 {
+			return "", fmt.Errorf("队列项缺少URL")
+		}
+		return netease.GetSongUrl(item.SongId)
+	})
+}
+
+// writeQueueState 返回当前队列状态
+func writeQueueState(w http.ResponseWriter, queue *player.Queue) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(queue.Snapshot())
+}
+
+// writePlayResult 返回队列播放结果
+func writePlayResult(w http.ResponseWriter, duration *player.AudioDuration, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	queue, _ := player.GetQueue()
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"duration": duration,
+		"queue":    queue.Snapshot(),
+		"message":  "开始播放",
+	})
+}
+
+// HandleQueueList 处理获取播放队列的请求
+func HandleQueueList(w http.ResponseWriter, r *http.Request) {
+	queue, err := player.GetQueue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeQueueState(w, queue)
+}
+
+// HandleQueueAdd 处理向队列添加歌曲的请求，position 为空时追加到末尾
+func HandleQueueAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Items    []player.QueueItem `json:"items"`
+		Position *int               `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(request.Items) == 0 {
+		http.Error(w, "没有要添加的歌曲", http.StatusBadRequest)
+		return
+	}
+	for _, item := range request.Items {
+		if item.URL == "" && item.SongId == 0 {
+			http.Error(w, "歌曲缺少 url 或 song_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	queue, err := player.GetQueue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if request.Position == nil {
+		queue.Append(request.Items...)
+	} else if _, err := queue.Insert(*request.Position, request.Items...); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeQueueState(w, queue)
+}
+
+// HandleQueueRemove 处理从队列移除歌曲的请求
+func HandleQueueRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	queue, err := player.GetQueue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := queue.Remove(request.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeQueueState(w, queue)
+}
+
+// HandleQueueMove 处理调整歌曲顺序的请求
+func HandleQueueMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		From int `json:"from"`
+		To   int `json:"to"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	queue, err := player.GetQueue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := queue.Move(request.From, request.To); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeQueueState(w, queue)
+}
+
+// HandleQueueClear 处理清空队列的请求
+func HandleQueueClear(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	queue, err := player.GetQueue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	queue.Clear()
+
+	writeQueueState(w, queue)
+}
+
+// HandleQueuePlay 处理播放队列中指定歌曲的请求
+func HandleQueuePlay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Index int `json:"index"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	duration, err := player.QueuePlay(request.Index)
+	writePlayResult(w, duration, err)
+}
+
+// HandleQueueNext 处理下一首的请求
+func HandleQueueNext(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	duration, err := player.QueueNext()
+	writePlayResult(w, duration, err)
+}
+
+// HandleQueuePrevious 处理上一首的请求
+func HandleQueuePrevious(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	duration, err := player.QueuePrevious()
+	writePlayResult(w, duration, err)
+}
+
+// HandleQueueShuffle 处理开关随机播放的请求
+func HandleQueueShuffle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	queue, err := player.GetQueue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	queue.SetShuffle(request.Enabled)
+
+	writeQueueState(w, queue)
+}
+
+// HandleQueueRepeat 处理设置循环模式的请求（off/one/all）
+func HandleQueueRepeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Mode player.RepeatMode `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	queue, err := player.GetQueue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := queue.SetRepeat(request.Mode); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeQueueState(w, queue)
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"aku-web/internal/player"
+	"aku-web/internal/radio"
+)
+
+var radioStore *radio.Store
+
+// InitRadio 加载电台列表
+func InitRadio(path string) {
+	radioStore = radio.NewStore(path)
+}
+
+// HandleRadioList 处理获取电台列表的请求
+func HandleRadioList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(radioStore.List())
+}
+
+// HandleRadioAdd 处理添加电台的请求
+func HandleRadioAdd(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var station radio.Station
+	if err := json.NewDecoder(r.Body).Decode(&station); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	station, err := radioStore.Add(station)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(station)
+}
+
+// HandleRadioUpdate 处理修改电台的请求
+func HandleRadioUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var station radio.Station
+	if err := json.NewDecoder(r.Body).Decode(&station); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	station, err := radioStore.Update(station)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(station)
+}
+
+// HandleRadioDelete 处理删除电台的请求
+func HandleRadioDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := radioStore.Delete(request.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// HandleRadioPlay 处理播放电台的请求，id 为已保存的电台，也可以直接传 url
+func HandleRadioPlay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	station := radio.Station{Name: request.URL, URL: request.URL}
+	if request.ID != "" {
+		var err error
+		if station, err = radioStore.Get(request.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+	if station.URL == "" {
+		http.Error(w, "缺少电台地址", http.StatusBadRequest)
+		return
+	}
+
+	streamURL, err := radio.ResolveStreamURL(station.URL)
+	if err == nil {
+		_, err = player.PlayLive(streamURL, station.Name)
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"station": station,
+		"message": "开始播放",
+	})
+}
+
+// HandleRadioNow 处理获取当前电台节目的请求
+func HandleRadioNow(w http.ResponseWriter, r *http.Request) {
+	status, err := player.GetStatus()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := status.Name
+	if name == "" && status.Track != nil {
+		name = status.Track.Title
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"live":        status.Live,
+		"name":        name,
+		"station":     status.Station,
+		"now_playing": status.NowPlaying,
+		"url":         status.URL,
+		"state":       status.State,
+	})
+}
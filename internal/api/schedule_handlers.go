@@ -0,0 +1,375 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"aku-web/internal/mixer"
+	"aku-web/internal/netease"
+	"aku-web/internal/player"
+	"aku-web/internal/playlist"
+	"aku-web/internal/radio"
+	"aku-web/internal/scheduler"
+	"aku-web/internal/service"
+)
+
+var scheduleManager *scheduler.Scheduler
+
+// InitSchedule 加载定时任务并启动调度
+func InitSchedule(path string) {
+	scheduleManager = scheduler.New(path, &scheduleRunner{})
+	scheduleManager.Start()
+}
+
+// scheduleRunner 执行定时任务的动作
+type scheduleRunner struct {
+	mutex   sync.Mutex
+	cancel  chan struct{} // 关闭时停止正在进行的音量渐强
+	fading  chan struct{} // 音量渐强结束时关闭
+	restore int           // 闹钟响起前的音量
+}
+
+// Run 执行任务动作
+func (r *scheduleRunner) Run(job scheduler.Job) error {
+	a := job.Action
+	switch a.Type {
+	case scheduler.ActionText:
+		fontSize, color := a.FontSize, a.Color
+		if fontSize <= 0 {
+			fontSize = 24
+		}
+		if color == "" {
+			color = "#ffffff"
+		}
+		if err := displayManager.ShowText(a.Target, fontSize, color, 1, 1); err != nil {
+			return err
+		}
+		acquireDisplayFocus()
+		return nil
+	case scheduler.ActionImage:
+		if err := displayManager.ShowImage(a.Target); err != nil {
+			return err
+		}
+		acquireDisplayFocus()
+		return nil
+	case scheduler.ActionServiceStart, scheduler.ActionServiceStop:
+		svc, err := service.GetService(a.Target)
+		if err != nil {
+			return err
+		}
+		running := a.Type == scheduler.ActionServiceStart
+		if running {
+			err = svc.Start()
+		} else {
+			err = svc.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		updateServiceSource(a.Target, running)
+		return nil
+	default:
+		return r.play(a)
+	}
+}
+
+// play 开始播放，设置了渐强时从静音逐渐调到目标音量。没有指定音量时使用闹钟记住的音量，
+// 闹钟还没有记住音量时使用当前音量
+func (r *scheduleRunner) play(a scheduler.Action) error {
+	r.stopFade()
+
+	restore, err := player.GetVolume()
+	if err != nil {
+		log.Printf("[Scheduler] 读取音量失败，不做渐强: %v", err)
+		a.FadeIn = 0
+	}
+	volume := a.Volume
+	if volume == 0 {
+		if remembered, ok := player.SourceVolume(mixer.SourceAlarm); ok {
+			volume = remembered
+		} else {
+			volume = restore
+		}
+	}
+	player.ActivateSource(mixer.SourceAlarm)
+	focusManager.Acquire(focusAlarm, 0)
+
+	start := volume
+	if a.FadeIn > 0 {
+		start = 0
+	}
+	if start != volume || a.Volume > 0 {
+		if err := player.ApplyVolume(start); err != nil {
+			log.Printf("[Scheduler] 设置音量失败: %v", err)
+		}
+	}
+
+	if err := startPlayback(a); err != nil {
+		r.release(restore)
+		return err
+	}
+
+	r.mutex.Lock()
+	r.restore = restore
+	if a.FadeIn > 0 {
+		cancel, done := make(chan struct{}), make(chan struct{})
+		r.cancel, r.fading = cancel, done
+		go func() {
+			defer close(done)
+			err := player.FadeVolume(start, volume, time.Duration(a.FadeIn)*time.Second, cancel)
+			if err != nil && err != player.ErrFadeCanceled {
+				log.Printf("[Scheduler] 音量渐强失败: %v", err)
+			}
+		}()
+	}
+	r.mutex.Unlock()
+	return nil
+}
+
+// Silence 停止闹钟的播放和音量渐强，恢复闹钟响起前的音量
+func (r *scheduleRunner) Silence() {
+	player.StopPlayback()
+	r.stopFade()
+
+	r.mutex.Lock()
+	restore := r.restore
+	r.mutex.Unlock()
+	r.release(restore)
+}
+
+// stopFade 停止正在进行的音量渐强并等待其结束
+func (r *scheduleRunner) stopFade() {
+	r.mutex.Lock()
+	cancel, done := r.cancel, r.fading
+	r.cancel, r.fading = nil, nil
+	r.mutex.Unlock()
+
+	if cancel != nil {
+		close(cancel)
+		<-done
+	}
+}
+
+// release 结束闹钟来源并释放焦点。之前的来源没有记住的音量时恢复为 restore
+func (r *scheduleRunner) release(restore int) {
+	focusManager.Release(focusAlarm)
+	player.ReleaseSource(mixer.SourceAlarm)
+	if _, ok := player.SourceVolume(player.ActiveSource()); !ok {
+		player.ApplyVolume(restore)
+	}
+}
+
+// startPlayback 按动作类型播放歌曲、歌单或电台
+func startPlayback(a scheduler.Action) error {
+	switch a.Type {
+	case scheduler.ActionPlay:
+		item := entryToQueueItem(playlist.EntryFromLocation(a.Target))
+		url := item.URL
+		if url == "" {
+			var err error
+			if url, err = netease.GetSongUrl(item.SongId); err != nil {
+				return fmt.Errorf("获取歌曲地址失败: %v", err)
+			}
+		}
+		_, err := player.PlayStream(url)
+		return err
+	case scheduler.ActionPlaylist:
+		p, err := playlistStore.Get(a.Target)
+		if err != nil {
+			return err
+		}
+		if len(p.Entries) == 0 {
+			return fmt.Errorf("歌单中没有歌曲")
+		}
+		start, err := loadPlaylist(p, false)
+		if err != nil {
+			return err
+		}
+		_, err = player.QueuePlay(start)
+		return err
+	case scheduler.ActionRadio:
+		station, err := radioStore.Get(a.Target)
+		if err != nil {
+			return err
+		}
+		streamURL, err := radio.ResolveStreamURL(station.URL)
+		if err != nil {
+			return err
+		}
+		_, err = player.PlayLive(streamURL, station.Name)
+		return err
+	}
+	return fmt.Errorf("不支持的动作类型: %q", a.Type)
+}
+
+// writeJob 返回任务详情
+func writeJob(w http.ResponseWriter, job scheduler.Job) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleScheduleList 处理获取定时任务列表的请求
+func HandleScheduleList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scheduleManager.List())
+}
+
+// HandleScheduleGet 处理获取定时任务详情的请求
+func HandleScheduleGet(w http.ResponseWriter, r *http.Request) {
+	job, err := scheduleManager.Get(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJob(w, job)
+}
+
+// HandleScheduleCreate 处理创建定时任务的请求
+func HandleScheduleCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var job scheduler.Job
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := scheduleManager.Create(job)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJob(w, job)
+}
+
+// HandleScheduleUpdate 处理修改定时任务的请求
+func HandleScheduleUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var job scheduler.Job
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := scheduleManager.Update(job)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJob(w, job)
+}
+
+// HandleScheduleDelete 处理删除定时任务的请求
+func HandleScheduleDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := scheduleManager.Delete(request.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// HandleScheduleRun 处理立即执行定时任务的请求
+func HandleScheduleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := scheduleManager.RunNow(request.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// HandleScheduleActive 处理获取正在响铃的闹钟的请求
+func HandleScheduleActive(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scheduleManager.Active())
+}
+
+// HandleScheduleSnooze 处理闹钟稍后提醒的请求，id 为空时作用于所有正在响铃的闹钟，minutes 默认为配置值
+func HandleScheduleSnooze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID      string `json:"id"`
+		Minutes int    `json:"minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	alarms, err := scheduleManager.Snooze(request.ID, request.Minutes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alarms)
+}
+
+// HandleScheduleDismiss 处理关闭闹钟的请求，id 为空时关闭所有闹钟
+func HandleScheduleDismiss(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := scheduleManager.Dismiss(request.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
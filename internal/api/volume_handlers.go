@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"aku-web/internal/mixer"
+	"aku-web/internal/player"
+)
+
+// volumePollInterval 检查其他程序（amixer、小智等）修改音量的间隔
+const volumePollInterval = 5 * time.Second
+
+// InitVolumeProfiles 加载各声音来源记住的音量
+func InitVolumeProfiles(path string) {
+	player.SetVolumeProfiles(mixer.NewProfiles(path))
+}
+
+// updateServiceSource 小智服务启动时切换到小智语音的音量，停止时恢复之前来源的音量
+func updateServiceSource(name string, running bool) {
+	if name != "xiaozhi" {
+		return
+	}
+	if running {
+		player.ActivateSource(mixer.SourceXiaozhi)
+	} else {
+		player.ReleaseSource(mixer.SourceXiaozhi)
+	}
+}
+
+// HandleVolumeProfiles 处理获取当前声音来源和各来源记住的音量的请求
+func HandleVolumeProfiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"source":   player.ActiveSource(),
+		"sources":  mixer.Sources,
+		"profiles": player.VolumeProfiles(),
+	})
+}
+
+// HandleVolumeProfileSet 处理修改声音来源记住的音量的请求，该来源正在使用时同时调整音量
+func HandleVolumeProfileSet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Source string `json:"source"`
+		Volume int    `json:"volume"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	source, err := mixer.ParseSource(request.Source)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := player.SetSourceVolume(source, request.Volume); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	HandleVolumeProfiles(w, r)
+}
+
+// HandleVolumeEvents 通过 SSE 推送音量变化，任何页面调整音量后其他页面的滑块都能同步
+func HandleVolumeEvents(w http.ResponseWriter, r *http.Request) {
+	events, cancel, err := player.SubscribeEvents()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cancel()
+
+	// 设置 SSE 头
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	notify := r.Context().Done()
+	// 定时读取混音器，其他程序修改音量时会产生变化事件
+	ticker := time.NewTicker(volumePollInterval)
+	defer ticker.Stop()
+
+	send := func() bool {
+		info, err := player.GetVolumeInfo()
+		if err != nil {
+			return false
+		}
+		data, err := json.Marshal(info)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return true
+	}
+
+	if !send() {
+		return
+	}
+
+	for {
+		select {
+		case <-notify:
+			log.Printf("Client disconnected from volume events")
+			return
+		case event := <-events:
+			if event.Type != player.EventVolumeChange {
+				continue
+			}
+			if !send() {
+				return
+			}
+		case <-ticker.C:
+			player.GetVolumeInfo()
+		}
+	}
+}
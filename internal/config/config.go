@@ -8,7 +8,88 @@ const (
 
 // 音频相关配置
 const (
-	MaxVolume = 63
+	AudioBackend  = "mpg123"            // 播放后端: mpg123、mpv 或 fake（不发声，用于测试）
+	MpvSocketPath = "/tmp/aku_mpv.sock" // mpv JSON IPC 套接字路径
+
+	SleepFadeSeconds = 30 // 睡眠定时器默认的音量渐弱秒数
+	CrossfadeSeconds = 0  // 自动切换到队列下一首时淡出淡入的秒数，0 表示关闭
+
+	ReplayGainMode   = "track" // 响度均衡: off 关闭、track 按单曲、album 按专辑（没有专辑增益时按单曲）
+	ReplayGainPreamp = 0.0     // 在 ReplayGain 增益之外额外调整的分贝
+)
+
+// 音量控制配置
+const (
+	Mixer        = "alsa"            // 混音器: alsa（通过 amixer）或 fake（不操作硬件，用于测试）
+	MixerCard    = ""                // amixer -c 使用的声卡，为空时使用默认声卡
+	MixerControl = "Power Amplifier" // 调节音量的控制项
+
+	VolumeProfilePath = "aku-volume.json" // 各声音来源（歌曲、电台、闹钟、小智语音）记住的音量，相对于工作目录
+)
+
+// 音频焦点配置：小智语音、闹钟、屏幕显示出现时如何处理正在播放的音乐
+const (
+	FocusDuckPercent = 25 // 降低音量时音乐保留的百分比
+	FocusXiaozhiHold = 8  // 小智最后一次输出活动后保持焦点的秒数
+	FocusDisplayHold = 5  // 显示文字、图片或动画后保持焦点的秒数
+)
+
+// FocusActions 各来源对音乐的处理：none 不处理、duck 降低音量、pause 暂停
+var FocusActions = map[string]string{
+	"alarm":   "none", // 闹钟本身通过播放器播放，占用焦点使小智说话时不降低闹钟音量
+	"xiaozhi": "duck",
+	"display": "duck",
+}
+
+// FocusPriority 来源的优先级，靠前的优先，多个来源同时出现时只按优先级最高的处理
+var FocusPriority = []string{"alarm", "xiaozhi", "display"}
+
+// 小智主服务输出中表示正在说话或聆听、以及回到空闲的关键字（不区分大小写）
+var (
+	XiaozhiActivePatterns = []string{"speaking", "listening", "tts start"}
+	XiaozhiIdlePatterns   = []string{"idle", "tts stop"}
+)
+
+// 本地音乐库配置
+const (
+	MusicDir          = DefaultDir + "/music" // 默认音乐目录
+	LibraryIndexPath  = "aku-library.json"    // 音乐库索引文件，相对于工作目录
+	MaxUploadFileSize = 100 << 20             // 单个上传文件的大小上限
+	MaxUploadSize     = 512 << 20             // 一次上传请求的大小上限
+	MinFreeSpace      = 50 << 20              // 上传后至少保留的磁盘空间
+	PlaylistStorePath = "aku-playlists.json"  // 保存的歌单，相对于工作目录
+	RadioStorePath    = "aku-radio.json"      // 网络电台列表，相对于工作目录
+	HistoryPath       = "aku-history.jsonl"   // 播放历史，相对于工作目录
+	HistoryLimit      = 10000                 // 最多保存的播放记录数，超出时删除最旧的
+)
+
+// LibraryDirs 音乐库扫描的目录，可以添加U盘、SD卡等目录
+var LibraryDirs = []string{MusicDir}
+
+// 定时任务配置
+const (
+	ScheduleStorePath    = "aku-schedule.json" // 定时任务列表，相对于工作目录
+	DefaultSnoozeMinutes = 9                   // 闹钟稍后提醒的默认分钟数
+)
+
+// 网易云音乐配置
+const (
+	NeteaseBaseURL   = "https://music.163.com" // 接口地址，可以改为代理或本地的模拟服务器
+	NeteaseTimeout   = 15                      // 请求超时秒数
+	NeteaseUserAgent = ""                      // 请求使用的 User-Agent，为空时使用默认的浏览器 User-Agent
+	NeteaseCAFile    = ""                      // 额外信任的根证书（PEM 格式），系统证书库过旧时使用
+
+	NeteasePlaylistTTL = 600   // 歌单歌曲列表的缓存秒数，0 表示不缓存
+	NeteaseSongTTL     = 86400 // 歌曲详情的缓存秒数，0 表示不缓存
+)
+
+// 歌词配置：播放网易云歌曲或带同名 .lrc 文件的本地歌曲时在屏幕上同步显示歌词
+const (
+	LyricsDisplay     = true      // 是否在屏幕上显示歌词，可以通过 /api/lyrics/display 临时开关
+	LyricsTranslation = true      // 有翻译时在原文下方显示翻译
+	LyricsFontSize    = 24        // 歌词字号
+	LyricsColor       = "#ffffff" // 歌词颜色
+	LyricsHold        = 30        // 通过接口或定时任务显示其他内容后暂停显示歌词的秒数
 )
 
 // 小智AI服务配置
@@ -20,7 +101,7 @@ const (
 
 // 底包程序配置
 const (
-	ShowImgPath   = "/opt/aku/web/show_image"        // 显示图片程序路径
-	ShowGifPath   = "/opt/aku/web/play_bmp_sequence" // 播放GIF动画程序路径
-	ShowTextPath  = "/opt/aku/web/show_text"         // 显示文字程序路径
+	ShowImgPath  = "/opt/aku/web/show_image"        // 显示图片程序路径
+	ShowGifPath  = "/opt/aku/web/play_bmp_sequence" // 播放GIF动画程序路径
+	ShowTextPath = "/opt/aku/web/show_text"         // 显示文字程序路径
 )
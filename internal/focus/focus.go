@@ -0,0 +1,262 @@
+// Package focus 音频焦点：小智语音、闹钟、屏幕显示等来源出现时，按规则降低音乐的音量或暂停音乐，来源结束后恢复
+package focus
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Action 来源出现时对音乐的处理
+type Action string
+
+const (
+	ActionNone  Action = "none"  // 不处理音乐，但占用焦点，优先级更低的来源不再生效
+	ActionDuck  Action = "duck"  // 降低音乐的音量
+	ActionPause Action = "pause" // 暂停音乐
+)
+
+// ParseAction 检查处理方式名称
+func ParseAction(name string) (Action, error) {
+	switch a := Action(name); a {
+	case ActionNone, ActionDuck, ActionPause:
+		return a, nil
+	}
+	return "", fmt.Errorf("未知的处理方式: %s", name)
+}
+
+// Rule 来源的处理方式，多个来源同时出现时只按 Priority 最大的来源处理
+type Rule struct {
+	Action   Action `json:"action"`
+	Priority int    `json:"priority"`
+}
+
+// Target 被控制的音乐播放器
+type Target interface {
+	// Duck 把音乐的音量降低到 percent，100 为恢复
+	Duck(percent int) error
+	// Pause 正在播放时暂停，返回是否暂停了
+	Pause() (bool, error)
+	// Resume 继续播放
+	Resume() error
+}
+
+// Holder 正在占用焦点的来源
+type Holder struct {
+	Source  string     `json:"source"`
+	Since   time.Time  `json:"since"`
+	Expires *time.Time `json:"expires,omitempty"` // 为空时一直占用，直到 Release
+}
+
+// Status 焦点状态
+type Status struct {
+	Holders     []Holder        `json:"holders"`
+	Owner       string          `json:"owner,omitempty"` // 生效的来源
+	Action      Action          `json:"action"`          // 正在对音乐做的处理
+	Paused      bool            `json:"paused"`          // 音乐是否因焦点被暂停
+	DuckPercent int             `json:"duck_percent"`
+	Rules       map[string]Rule `json:"rules"`
+}
+
+// Manager 音频焦点管理器
+type Manager struct {
+	target      Target
+	rules       map[string]Rule
+	duckPercent int
+	holders     map[string]*Holder
+	owner       string
+	action      Action // 已经应用的处理
+	paused      bool   // 是否由焦点暂停了音乐，只有这时才在结束后继续播放
+	timer       *time.Timer
+	mutex       sync.Mutex
+}
+
+// New 创建焦点管理器，没有规则的来源按 ActionNone、优先级 0 处理
+func New(target Target, rules map[string]Rule, duckPercent int) *Manager {
+	m := &Manager{
+		target:  target,
+		holders: make(map[string]*Holder),
+		action:  ActionNone,
+	}
+	if err := m.SetRules(rules, duckPercent); err != nil {
+		log.Printf("[Focus] 焦点规则无效: %v", err)
+		m.rules, m.duckPercent = map[string]Rule{}, 100
+	}
+	return m
+}
+
+// Acquire 来源占用焦点，hold 大于 0 时在 hold 之后自动释放，再次调用会重新计时
+func (m *Manager) Acquire(source string, hold time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	h, ok := m.holders[source]
+	if !ok {
+		h = &Holder{Source: source, Since: now}
+		m.holders[source] = h
+		log.Printf("[Focus] %s 占用焦点", source)
+	}
+	h.Expires = nil
+	if hold > 0 {
+		expires := now.Add(hold)
+		h.Expires = &expires
+	}
+	m.updateLocked()
+	m.scheduleLocked()
+}
+
+// Release 来源释放焦点
+func (m *Manager) Release(source string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.holders[source]; !ok {
+		return
+	}
+	delete(m.holders, source)
+	log.Printf("[Focus] %s 释放焦点", source)
+	m.updateLocked()
+	m.scheduleLocked()
+}
+
+// SetRules 替换处理规则和降低音量时保留的百分比，立即按新规则处理
+func (m *Manager) SetRules(rules map[string]Rule, duckPercent int) error {
+	if duckPercent < 0 || duckPercent > 100 {
+		return fmt.Errorf("百分比必须在 0 到 100 之间")
+	}
+	copied := make(map[string]Rule, len(rules))
+	for source, rule := range rules {
+		if _, err := ParseAction(string(rule.Action)); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+		copied[source] = rule
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	changed := duckPercent != m.duckPercent
+	m.rules, m.duckPercent = copied, duckPercent
+	if changed && m.action == ActionDuck {
+		if err := m.target.Duck(duckPercent); err != nil {
+			log.Printf("[Focus] 降低音量失败: %v", err)
+		}
+	}
+	m.updateLocked()
+	return nil
+}
+
+// Status 返回焦点状态
+func (m *Manager) Status() Status {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	status := Status{
+		Holders:     []Holder{},
+		Owner:       m.owner,
+		Action:      m.action,
+		Paused:      m.paused,
+		DuckPercent: m.duckPercent,
+		Rules:       make(map[string]Rule, len(m.rules)),
+	}
+	for _, h := range m.holders {
+		status.Holders = append(status.Holders, *h)
+	}
+	sort.Slice(status.Holders, func(i, j int) bool {
+		return status.Holders[i].Since.Before(status.Holders[j].Since)
+	})
+	for source, rule := range m.rules {
+		status.Rules[source] = rule
+	}
+	return status
+}
+
+// ruleLocked 返回来源的规则
+func (m *Manager) ruleLocked(source string) Rule {
+	if rule, ok := m.rules[source]; ok {
+		return rule
+	}
+	return Rule{Action: ActionNone}
+}
+
+// updateLocked 找出优先级最高的来源，处理方式变化时先撤销之前的处理再应用新的
+func (m *Manager) updateLocked() {
+	owner, action := "", ActionNone
+	best := 0
+	for source, h := range m.holders {
+		rule := m.ruleLocked(source)
+		if owner == "" || rule.Priority > best ||
+			(rule.Priority == best && h.Since.After(m.holders[owner].Since)) {
+			owner, action, best = source, rule.Action, rule.Priority
+		}
+	}
+	m.owner = owner
+	if action == m.action {
+		return
+	}
+	log.Printf("[Focus] 音乐处理方式: %s -> %s", m.action, action)
+
+	switch m.action {
+	case ActionDuck:
+		if err := m.target.Duck(100); err != nil {
+			log.Printf("[Focus] 恢复音量失败: %v", err)
+		}
+	case ActionPause:
+		if m.paused {
+			if err := m.target.Resume(); err != nil {
+				log.Printf("[Focus] 继续播放失败: %v", err)
+			}
+			m.paused = false
+		}
+	}
+
+	switch action {
+	case ActionDuck:
+		if err := m.target.Duck(m.duckPercent); err != nil {
+			log.Printf("[Focus] 降低音量失败: %v", err)
+		}
+	case ActionPause:
+		paused, err := m.target.Pause()
+		if err != nil {
+			log.Printf("[Focus] 暂停失败: %v", err)
+		}
+		m.paused = paused
+	}
+	m.action = action
+}
+
+// scheduleLocked 在最早到期的来源到期时释放它
+func (m *Manager) scheduleLocked() {
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	var next time.Time
+	for _, h := range m.holders {
+		if h.Expires != nil && (next.IsZero() || h.Expires.Before(next)) {
+			next = *h.Expires
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	m.timer = time.AfterFunc(time.Until(next), m.expire)
+}
+
+// expire 释放所有已到期的来源
+func (m *Manager) expire() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for source, h := range m.holders {
+		if h.Expires != nil && !h.Expires.After(now) {
+			delete(m.holders, source)
+			log.Printf("[Focus] %s 空闲，释放焦点", source)
+		}
+	}
+	m.updateLocked()
+	m.scheduleLocked()
+}
@@ -0,0 +1,218 @@
+// Package history 记录播放历史：每次播放的开始、跳过、播放完成和实际收听时长
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Result 一次播放的结果
+type Result string
+
+const (
+	ResultPlaying   Result = "playing"   // 正在播放
+	ResultCompleted Result = "completed" // 播放到结尾
+	ResultSkipped   Result = "skipped"   // 未播放完就切换到了其他歌曲
+	ResultStopped   Result = "stopped"   // 未播放完就停止了播放
+)
+
+// Entry 一次播放的记录
+type Entry struct {
+	ID       int64      `json:"id"`
+	Key      string     `json:"key"` // 本地路径、网络地址，或 netease:<歌曲ID>
+	Title    string     `json:"title,omitempty"`
+	Artists  []string   `json:"artists,omitempty"`
+	Album    string     `json:"album,omitempty"`
+	SongId   uint       `json:"song_id,omitempty"` // 网易云歌曲ID
+	Live     bool       `json:"live,omitempty"`    // 直播流
+	Started  time.Time  `json:"started"`
+	Ended    *time.Time `json:"ended,omitempty"`
+	Duration float64    `json:"duration,omitempty"` // 歌曲时长（秒），直播流为 0
+	Listened float64    `json:"listened"`           // 实际收听的秒数，不包括暂停和跳过的部分
+	Result   Result     `json:"result"`
+}
+
+// Counted 判断是否算作一次完整的收听：播放完成，或听了一半以上（最多要求 4 分钟）。
+// 统计排行时只计算这样的播放，刚开始就跳过的不算
+func (e Entry) Counted() bool {
+	if e.Result == ResultCompleted {
+		return true
+	}
+	required := 240.0
+	if e.Duration > 0 && e.Duration/2 < required {
+		required = e.Duration / 2
+	}
+	return e.Listened >= required
+}
+
+// Store 播放历史。每次开始和结束播放时向文件追加一行 JSON，相同 ID 的后一行覆盖前一行，
+// 超过保存上限时删除最旧的记录并重写文件
+type Store struct {
+	path    string
+	limit   int
+	entries []Entry // 按 ID 从旧到新排列
+	lines   int     // 文件中的行数
+	nextID  int64
+	mutex   sync.Mutex
+}
+
+// Open 打开播放历史文件，文件不存在时创建空的历史。limit 为最多保存的记录数，0 表示不限制
+func Open(path string, limit int) (*Store, error) {
+	s := &Store{path: path, limit: limit, nextID: 1}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开播放历史失败: %v", err)
+	}
+	defer f.Close()
+
+	byID := make(map[int64]Entry)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		s.lines++
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.ID == 0 {
+			// 断电时最后一行可能不完整
+			log.Printf("[History] 跳过损坏的记录: %v", err)
+			continue
+		}
+		byID[entry.ID] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取播放历史失败: %v", err)
+	}
+
+	for _, entry := range byID {
+		// 上次退出时还在播放的记录
+		if entry.Result == ResultPlaying {
+			entry.Result = ResultStopped
+		}
+		s.entries = append(s.entries, entry)
+		if entry.ID >= s.nextID {
+			s.nextID = entry.ID + 1
+		}
+	}
+	sort.Slice(s.entries, func(i, j int) bool {
+		return s.entries[i].ID < s.entries[j].ID
+	})
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.compactLocked(); err != nil {
+		log.Printf("[History] %v", err)
+	}
+	return s, nil
+}
+
+// Add 记录一次新的播放，返回分配了 ID 的记录
+func (s *Store) Add(entry Entry) (Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry.ID = s.nextID
+	s.nextID++
+	if entry.Started.IsZero() {
+		entry.Started = time.Now()
+	}
+	if entry.Result == "" {
+		entry.Result = ResultPlaying
+	}
+	s.entries = append(s.entries, entry)
+	return entry, s.appendLocked(entry)
+}
+
+// Update 更新已有的记录，记录已被删除时忽略
+func (s *Store) Update(entry Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i := sort.Search(len(s.entries), func(i int) bool {
+		return s.entries[i].ID >= entry.ID
+	})
+	if i == len(s.entries) || s.entries[i].ID != entry.ID {
+		return nil
+	}
+	s.entries[i] = entry
+	return s.appendLocked(entry)
+}
+
+// Entries 返回所有记录，最新的在前
+func (s *Store) Entries() []Entry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries := make([]Entry, len(s.entries))
+	for i, entry := range s.entries {
+		entries[len(entries)-1-i] = entry
+	}
+	return entries
+}
+
+// appendLocked 向文件追加一行，行数过多时重写文件，调用方需持有锁
+func (s *Store) appendLocked(entry Entry) error {
+	if s.limit > 0 && (len(s.entries) > s.limit || s.lines >= 2*s.limit) {
+		return s.compactLocked()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("保存播放历史失败: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("保存播放历史失败: %v", err)
+	}
+	s.lines++
+	return nil
+}
+
+// compactLocked 删除超出上限的旧记录，每条记录只保留一行，调用方需持有锁
+func (s *Store) compactLocked() error {
+	if s.limit > 0 && len(s.entries) > s.limit {
+		s.entries = append([]Entry(nil), s.entries[len(s.entries)-s.limit:]...)
+	}
+	if s.lines == len(s.entries) {
+		return nil
+	}
+
+	f, err := os.Create(s.path + ".tmp")
+	if err != nil {
+		return fmt.Errorf("保存播放历史失败: %v", err)
+	}
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, entry := range s.entries {
+		if err := encoder.Encode(entry); err != nil {
+			f.Close()
+			return fmt.Errorf("保存播放历史失败: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("保存播放历史失败: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("保存播放历史失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免断电时历史损坏
+	if err := os.Rename(s.path+".tmp", s.path); err != nil {
+		return fmt.Errorf("保存播放历史失败: %v", err)
+	}
+	s.lines = len(s.entries)
+	return nil
+}
@@ -0,0 +1,165 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// dateLayout 按天统计时使用的日期格式（本地时间）
+const dateLayout = "2006-01-02"
+
+// TrackStat 一首歌的收听统计
+type TrackStat struct {
+	Key        string    `json:"key"`
+	Title      string    `json:"title,omitempty"`
+	Artists    []string  `json:"artists,omitempty"`
+	Album      string    `json:"album,omitempty"`
+	SongId     uint      `json:"song_id,omitempty"`
+	Plays      int       `json:"plays"`    // 完整收听的次数，见 Entry.Counted
+	Skips      int       `json:"skips"`    // 未听够就跳过的次数
+	Listened   float64   `json:"listened"` // 收听的总秒数
+	LastPlayed time.Time `json:"last_played"`
+}
+
+// ArtistStat 一位艺术家的收听统计
+type ArtistStat struct {
+	Artist   string  `json:"artist"`
+	Plays    int     `json:"plays"`
+	Listened float64 `json:"listened"`
+}
+
+// DayStat 一天的收听统计
+type DayStat struct {
+	Date     string  `json:"date"`
+	Plays    int     `json:"plays"` // 开始播放的次数
+	Listened float64 `json:"listened"`
+}
+
+// Stats 一段时间内的收听统计
+type Stats struct {
+	Since      time.Time    `json:"since"`
+	Plays      int          `json:"plays"`
+	Listened   float64      `json:"listened"`
+	TopTracks  []TrackStat  `json:"top_tracks"`
+	TopArtists []ArtistStat `json:"top_artists"`
+	Days       []DayStat    `json:"days"` // 按日期从早到晚
+}
+
+// Stats 统计 since 之后开始的播放，排行最多返回 limit 项。直播流只计入收听时长，不参加排行
+func (s *Store) Stats(since time.Time, limit int) Stats {
+	stats := Stats{Since: since}
+	tracks := make(map[string]*TrackStat)
+	artists := make(map[string]*ArtistStat)
+	days := make(map[string]*DayStat)
+
+	for _, entry := range s.Entries() {
+		if entry.Started.Before(since) {
+			continue
+		}
+		stats.Plays++
+		stats.Listened += entry.Listened
+
+		date := entry.Started.Local().Format(dateLayout)
+		day := days[date]
+		if day == nil {
+			day = &DayStat{Date: date}
+			days[date] = day
+		}
+		day.Plays++
+		day.Listened += entry.Listened
+
+		if entry.Live {
+			continue
+		}
+
+		// Entries 从新到旧，第一次遇到时的标题和艺术家是最新的
+		track := tracks[entry.Key]
+		if track == nil {
+			track = &TrackStat{
+				Key:        entry.Key,
+				Title:      entry.Title,
+				Artists:    entry.Artists,
+				Album:      entry.Album,
+				SongId:     entry.SongId,
+				LastPlayed: entry.Started,
+			}
+			tracks[entry.Key] = track
+		}
+		track.Listened += entry.Listened
+		counted := entry.Counted()
+		if counted {
+			track.Plays++
+		} else if entry.Result != ResultPlaying {
+			track.Skips++
+		}
+
+		for _, name := range entry.Artists {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			artist := artists[name]
+			if artist == nil {
+				artist = &ArtistStat{Artist: name}
+				artists[name] = artist
+			}
+			artist.Listened += entry.Listened
+			if counted {
+				artist.Plays++
+			}
+		}
+	}
+
+	stats.TopTracks = make([]TrackStat, 0, len(tracks))
+	for _, track := range tracks {
+		if track.Plays > 0 {
+			stats.TopTracks = append(stats.TopTracks, *track)
+		}
+	}
+	sort.Slice(stats.TopTracks, func(i, j int) bool {
+		a, b := stats.TopTracks[i], stats.TopTracks[j]
+		if a.Plays != b.Plays {
+			return a.Plays > b.Plays
+		}
+		if a.Listened != b.Listened {
+			return a.Listened > b.Listened
+		}
+		return a.LastPlayed.After(b.LastPlayed)
+	})
+
+	stats.TopArtists = make([]ArtistStat, 0, len(artists))
+	for _, artist := range artists {
+		if artist.Plays > 0 {
+			stats.TopArtists = append(stats.TopArtists, *artist)
+		}
+	}
+	sort.Slice(stats.TopArtists, func(i, j int) bool {
+		a, b := stats.TopArtists[i], stats.TopArtists[j]
+		if a.Plays != b.Plays {
+			return a.Plays > b.Plays
+		}
+		if a.Listened != b.Listened {
+			return a.Listened > b.Listened
+		}
+		return a.Artist < b.Artist
+	})
+
+	if limit > 0 {
+		if len(stats.TopTracks) > limit {
+			stats.TopTracks = stats.TopTracks[:limit]
+		}
+		if len(stats.TopArtists) > limit {
+			stats.TopArtists = stats.TopArtists[:limit]
+		}
+	}
+
+	stats.Days = make([]DayStat, 0, len(days))
+	for _, day := range days {
+		stats.Days = append(stats.Days, *day)
+	}
+	sort.Slice(stats.Days, func(i, j int) bool {
+		return stats.Days[i].Date < stats.Days[j].Date
+	})
+	return stats
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package library
+
+import "math"
+
+// FreeSpace 当前平台无法获取可用空间，不做限制
+func FreeSpace(dir string) (uint64, error) {
+	return math.MaxUint64, nil
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package library
+
+import "syscall"
+
+// FreeSpace 返回 dir 所在文件系统的可用空间（字节）
+func FreeSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
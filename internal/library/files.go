@@ -0,0 +1,115 @@
+package library
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SafeJoin 把相对路径拼接到 root 下，拒绝绝对路径、".." 和指向 root 之外的符号链接
+func SafeJoin(root, rel string) (string, error) {
+	rel = filepath.FromSlash(strings.TrimSpace(rel))
+	if filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" {
+		return "", fmt.Errorf("不允许使用绝对路径: %s", rel)
+	}
+	cleaned := filepath.Clean(rel)
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("路径超出音乐目录: %s", rel)
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(absRoot, cleaned)
+
+	// 已存在的最深一级目录不能通过符号链接跳出 root
+	realRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return "", err
+	}
+	existing := path
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if real != realRoot && !strings.HasPrefix(real, realRoot+string(filepath.Separator)) {
+		return "", fmt.Errorf("路径超出音乐目录: %s", rel)
+	}
+	return path, nil
+}
+
+// ValidName 检查文件或目录名，不允许包含路径分隔符和隐藏文件名
+func ValidName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("无效的名称: %q", name)
+	}
+	if strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0) {
+		return fmt.Errorf("名称不能包含路径分隔符: %q", name)
+	}
+	return nil
+}
+
+// IsAudioExt 判断扩展名是否为支持的音频格式
+func IsAudioExt(name string) bool {
+	return audioExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// SniffFormat 根据文件开头的数据识别音频格式，无法识别时返回空字符串
+func SniffFormat(head []byte) string {
+	switch {
+	case len(head) >= 12 && bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")):
+		return "wav"
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return "flac"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return "ogg"
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return "mp3"
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		return "mp3"
+	}
+	return ""
+}
+
+// sniffExts 各格式允许的扩展名
+var sniffExts = map[string][]string{
+	"mp3":  {".mp3"},
+	"wav":  {".wav"},
+	"flac": {".flac"},
+	"ogg":  {".ogg", ".oga", ".opus"},
+}
+
+// MatchesExt 判断识别出的格式与文件扩展名是否一致
+func MatchesExt(format, name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range sniffExts[format] {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// UniquePath 如果文件已存在，在文件名后加上 " (1)"、" (2)" 等序号
+func UniquePath(path string) string {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
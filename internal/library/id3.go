@@ -0,0 +1,264 @@
+package library
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// ID3v2 帧ID与标签字段的对应关系，v2.2 使用3字符ID
+var id3Frames = map[string]string{
+	"TIT2": "title", "TT2": "title",
+	"TPE1": "artist", "TP1": "artist",
+	"TALB": "album", "TAL": "album",
+	"TPE2": "albumartist", "TP2": "albumartist",
+	"TRCK": "track", "TRK": "track",
+}
+
+// TXXX 用户自定义文本帧中的 ReplayGain 字段，描述不区分大小写
+var id3UserFields = map[string]string{
+	"REPLAYGAIN_TRACK_GAIN": "replaygain_track_gain",
+	"REPLAYGAIN_TRACK_PEAK": "replaygain_track_peak",
+	"REPLAYGAIN_ALBUM_GAIN": "replaygain_album_gain",
+	"REPLAYGAIN_ALBUM_PEAK": "replaygain_album_peak",
+}
+
+// readID3v2 读取文件开头的 ID3v2 标签
+func readID3v2(r io.ReaderAt, tags *Tags) error {
+	header, err := readAt(r, 0, 10)
+	if err != nil || len(header) < 10 {
+		return fmt.Errorf("读取 ID3v2 头失败: %v", err)
+	}
+	major := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+
+	data, err := readAt(r, 10, int(size))
+	if err != nil {
+		return fmt.Errorf("读取 ID3v2 标签失败: %v", err)
+	}
+	parseID3v2(data, major, flags, tags)
+	return nil
+}
+
+// parseID3v2 解析 ID3v2 标签内容（不含10字节的头）
+func parseID3v2(data []byte, major, flags byte, tags *Tags) {
+	if major < 2 || major > 4 {
+		return
+	}
+	// v2.2/v2.3 的去同步作用于整个标签
+	if flags&0x80 != 0 && major < 4 {
+		data = unsync(data)
+	}
+
+	pos := 0
+	if flags&0x40 != 0 && major >= 3 && len(data) >= 4 {
+		// 跳过扩展头
+		if major == 3 {
+			pos = 4 + int(binary.BigEndian.Uint32(data))
+		} else {
+			pos = int(syncsafe(data[0:4]))
+		}
+	}
+
+	idLen, headerLen := 4, 10
+	if major == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	for pos+headerLen <= len(data) {
+		id := string(data[pos : pos+idLen])
+		if id[0] == 0 {
+			break // 填充区
+		}
+
+		var frameSize int
+		var frameFlags uint16
+		switch major {
+		case 2:
+			frameSize = int(data[pos+3])<<16 | int(data[pos+4])<<8 | int(data[pos+5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(data[pos+4:]))
+			frameFlags = binary.BigEndian.Uint16(data[pos+8:])
+		default:
+			frameSize = int(syncsafe(data[pos+4 : pos+8]))
+			frameFlags = binary.BigEndian.Uint16(data[pos+8:])
+		}
+		pos += headerLen
+		if frameSize <= 0 || pos+frameSize > len(data) {
+			break
+		}
+		frame := data[pos : pos+frameSize]
+		pos += frameSize
+
+		// 跳过压缩或加密的帧
+		if major == 3 && frameFlags&0x00C0 != 0 {
+			continue
+		}
+		if major == 4 {
+			if frameFlags&0x000C != 0 {
+				continue
+			}
+			if frameFlags&0x0001 != 0 && len(frame) >= 4 {
+				frame = frame[4:] // 数据长度指示
+			}
+			if frameFlags&0x0002 != 0 {
+				frame = unsync(frame)
+			}
+		}
+
+		switch {
+		case id == "APIC" || id == "PIC":
+			if tags.Cover == nil {
+				tags.Cover = parseAPIC(frame, id == "PIC")
+			}
+		case (id == "TXXX" || id == "TXX") && len(frame) > 1:
+			desc, n := splitText(frame[0], frame[1:])
+			if field := id3UserFields[strings.ToUpper(desc)]; field != "" {
+				tags.merge(field, decodeText(frame[0], frame[1+n:]))
+			}
+		case id3Frames[id] != "" && len(frame) > 1:
+			tags.merge(id3Frames[id], decodeText(frame[0], frame[1:]))
+		}
+	}
+}
+
+// parseAPIC 解析封面帧，v2.2 的 PIC 帧使用3字符图片格式代替 MIME
+func parseAPIC(frame []byte, v22 bool) *Picture {
+	if len(frame) < 4 {
+		return nil
+	}
+	encoding := frame[0]
+	rest := frame[1:]
+
+	var mime string
+	if v22 {
+		switch strings.ToUpper(string(rest[:3])) {
+		case "PNG":
+			mime = "image/png"
+		default:
+			mime = "image/jpeg"
+		}
+		rest = rest[3:]
+	} else {
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			return nil
+		}
+		mime = string(rest[:end])
+		rest = rest[end+1:]
+	}
+	if len(rest) < 1 {
+		return nil
+	}
+	rest = rest[1:] // 图片类型
+
+	// 跳过描述文字
+	_, n := splitText(encoding, rest)
+	rest = rest[n:]
+	if len(rest) == 0 || len(rest) > maxCoverSize {
+		return nil
+	}
+
+	if mime == "" || !strings.Contains(mime, "/") {
+		mime = "image/" + strings.ToLower(mime)
+	}
+	return &Picture{MIME: mime, Data: append([]byte(nil), rest...)}
+}
+
+// splitText 找到以 encoding 编码、以空字符结尾的字符串，返回内容和包括结束符在内的长度
+func splitText(encoding byte, data []byte) (string, int) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return decodeText(encoding, data[:i]), i + 2
+			}
+		}
+		return decodeText(encoding, data), len(data)
+	}
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		return decodeText(encoding, data[:end]), end + 1
+	}
+	return decodeText(encoding, data), len(data)
+}
+
+// decodeText 按 ID3v2 的编码字节解码文本，多个值用 "/" 连接
+func decodeText(encoding byte, data []byte) string {
+	var text string
+	switch encoding {
+	case 1, 2: // UTF-16（带BOM）/ UTF-16BE
+		bigEndian := encoding == 2
+		if len(data) >= 2 {
+			if data[0] == 0xFF && data[1] == 0xFE {
+				bigEndian, data = false, data[2:]
+			} else if data[0] == 0xFE && data[1] == 0xFF {
+				bigEndian, data = true, data[2:]
+			}
+		}
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			if bigEndian {
+				units = append(units, binary.BigEndian.Uint16(data[i:]))
+			} else {
+				units = append(units, binary.LittleEndian.Uint16(data[i:]))
+			}
+		}
+		text = string(utf16.Decode(units))
+	case 3: // UTF-8
+		text = string(data)
+	default: // ISO-8859-1
+		text = latin1(data)
+	}
+
+	text = strings.TrimRight(text, "\x00")
+	return strings.ReplaceAll(text, "\x00", "/")
+}
+
+// readID3v1 读取文件末尾的 ID3v1 标签
+func readID3v1(r io.ReaderAt, size int64, tags *Tags) {
+	if size < 128 {
+		return
+	}
+	data, err := readAt(r, size-128, 128)
+	if err != nil || len(data) < 128 || !bytes.Equal(data[0:3], []byte("TAG")) {
+		return
+	}
+
+	tags.merge("title", latin1(trimID3v1(data[3:33])))
+	tags.merge("artist", latin1(trimID3v1(data[33:63])))
+	tags.merge("album", latin1(trimID3v1(data[63:93])))
+	// ID3v1.1：注释最后两字节为 0 和音轨号
+	if data[125] == 0 && data[126] != 0 && tags.Track == 0 {
+		tags.Track = int(data[126])
+	}
+}
+
+// trimID3v1 去掉定长字段末尾的空字符和空格
+func trimID3v1(b []byte) []byte {
+	if end := bytes.IndexByte(b, 0); end >= 0 {
+		b = b[:end]
+	}
+	return bytes.TrimRight(b, " ")
+}
+
+// latin1 把 ISO-8859-1 字节转换为字符串
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// syncsafe 解析4字节同步安全整数
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
+
+// unsync 还原去同步处理：0xFF 0x00 → 0xFF
+func unsync(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xFF, 0x00}, []byte{0xFF})
+}
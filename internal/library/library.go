@@ -0,0 +1,422 @@
+// Package library 扫描本地音乐目录，读取标签并维护持久化的音乐库索引
+package library
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aku-web/internal/player/probe"
+)
+
+// 支持的音频扩展名
+var audioExts = map[string]bool{
+	".mp3":  true,
+	".wav":  true,
+	".flac": true,
+	".ogg":  true,
+	".oga":  true,
+	".opus": true,
+}
+
+// 分组时缺少标签的显示名称
+const (
+	UnknownArtist = "未知艺术家"
+	UnknownAlbum  = "未知专辑"
+)
+
+// Track 音乐库中的一首歌曲
+type Track struct {
+	ID          string    `json:"id"`
+	Path        string    `json:"path"`
+	Title       string    `json:"title"`
+	Artist      string    `json:"artist"`
+	Album       string    `json:"album"`
+	AlbumArtist string    `json:"album_artist,omitempty"`
+	TrackNo     int       `json:"track_no,omitempty"`
+	Duration    float64   `json:"duration"`
+	Format      string    `json:"format"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time"`
+	HasCover    bool      `json:"has_cover"`
+}
+
+// Album 专辑汇总
+type Album struct {
+	Name     string  `json:"name"`
+	Artist   string  `json:"artist"`
+	Tracks   int     `json:"tracks"`
+	Duration float64 `json:"duration"`
+	CoverID  string  `json:"cover_id,omitempty"` // 带封面的歌曲ID
+}
+
+// Artist 艺术家汇总
+type Artist struct {
+	Name   string `json:"name"`
+	Albums int    `json:"albums"`
+	Tracks int    `json:"tracks"`
+}
+
+// TrackFilter 歌曲列表过滤条件，为空的字段不过滤
+type TrackFilter struct {
+	Album  string
+	Artist string
+}
+
+// ScanResult 一次扫描的结果
+type ScanResult struct {
+	Added   int           `json:"added"`
+	Updated int           `json:"updated"`
+	Removed int           `json:"removed"`
+	Total   int           `json:"total"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Library 本地音乐库
+type Library struct {
+	dirs      []string
+	indexPath string
+	tracks    map[string]*Track // 以文件路径为键
+	lastScan  time.Time
+	mutex     sync.RWMutex
+	scanMu    sync.Mutex
+}
+
+// New 创建音乐库并加载已有的索引，dirs 为需要扫描的目录
+func New(indexPath string, dirs []string) *Library {
+	l := &Library{
+		dirs:      dirs,
+		indexPath: indexPath,
+		tracks:    make(map[string]*Track),
+	}
+	l.load()
+	return l
+}
+
+// Dirs 返回扫描的目录
+func (l *Library) Dirs() []string {
+	return append([]string{}, l.dirs...)
+}
+
+// load 读取磁盘上的索引
+func (l *Library) load() {
+	data, err := os.ReadFile(l.indexPath)
+	if err != nil {
+		return
+	}
+	var tracks []*Track
+	if err := json.Unmarshal(data, &tracks); err != nil {
+		log.Printf("[Library] 音乐库索引损坏，将重新扫描: %v", err)
+		return
+	}
+	for _, t := range tracks {
+		l.tracks[t.Path] = t
+	}
+	log.Printf("[Library] 从索引加载 %d 首歌曲", len(l.tracks))
+}
+
+// save 把索引写入磁盘，先写临时文件再重命名
+func (l *Library) save() error {
+	l.mutex.RLock()
+	tracks := make([]*Track, 0, len(l.tracks))
+	for _, t := range l.tracks {
+		tracks = append(tracks, t)
+	}
+	l.mutex.RUnlock()
+
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].Path < tracks[j].Path })
+	data, err := json.MarshalIndent(tracks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.indexPath+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(l.indexPath+".tmp", l.indexPath)
+}
+
+// Scan 递归扫描所有目录，只重新读取修改时间或大小变化的文件
+func (l *Library) Scan() (ScanResult, error) {
+	l.scanMu.Lock()
+	defer l.scanMu.Unlock()
+
+	start := time.Now()
+	var result ScanResult
+
+	l.mutex.RLock()
+	existing := make(map[string]*Track, len(l.tracks))
+	for path, t := range l.tracks {
+		existing[path] = t
+	}
+	l.mutex.RUnlock()
+
+	found := make(map[string]*Track)
+	for _, dir := range l.dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				log.Printf("[Library] 无法访问 %s: %v", path, err)
+				return nil
+			}
+			if d.IsDir() {
+				if path != dir && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !audioExts[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if old, ok := existing[path]; ok && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) {
+				found[path] = old
+				return nil
+			}
+
+			track := readTrack(path, info)
+			if _, ok := existing[path]; ok {
+				result.Updated++
+			} else {
+				result.Added++
+			}
+			found[path] = track
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("扫描目录 %s 失败: %v", dir, err)
+		}
+	}
+
+	for path := range existing {
+		if _, ok := found[path]; !ok {
+			result.Removed++
+		}
+	}
+
+	l.mutex.Lock()
+	l.tracks = found
+	l.lastScan = time.Now()
+	l.mutex.Unlock()
+
+	result.Total = len(found)
+	result.Elapsed = time.Since(start)
+	log.Printf("[Library] 扫描完成: 新增 %d，更新 %d，删除 %d，共 %d 首，耗时 %v",
+		result.Added, result.Updated, result.Removed, result.Total, result.Elapsed)
+
+	if result.Added > 0 || result.Updated > 0 || result.Removed > 0 {
+		if err := l.save(); err != nil {
+			log.Printf("[Library] 保存音乐库索引失败: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// readTrack 读取单个文件的标签和时长
+func readTrack(path string, info fs.FileInfo) *Track {
+	track := &Track{
+		ID:      trackID(path),
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Format:  strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."),
+	}
+
+	if tags, err := ReadTags(path); err == nil {
+		track.Title = tags.Title
+		track.Artist = tags.Artist
+		track.Album = tags.Album
+		track.AlbumArtist = tags.AlbumArtist
+		track.TrackNo = tags.Track
+		track.HasCover = tags.Cover != nil
+	} else {
+		log.Printf("[Library] 读取标签失败 %s: %v", path, err)
+	}
+	if track.Title == "" {
+		track.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if audio, err := probe.File(path); err == nil {
+		track.Duration = audio.Duration
+		track.Format = audio.Format
+	}
+	return track
+}
+
+// trackID 根据路径生成稳定的歌曲ID
+func trackID(path string) string {
+	sum := sha1.Sum([]byte(path))
+	return hex.EncodeToString(sum[:8])
+}
+
+// LastScan 返回上次扫描完成的时间
+func (l *Library) LastScan() time.Time {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.lastScan
+}
+
+// Track 按ID查找歌曲
+func (l *Library) Track(id string) (Track, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	for _, t := range l.tracks {
+		if t.ID == id {
+			return *t, true
+		}
+	}
+	return Track{}, false
+}
+
+// Tracks 返回符合条件的歌曲，按艺术家、专辑、音轨号排序
+func (l *Library) Tracks(filter TrackFilter) []Track {
+	return l.collect(func(t *Track) bool {
+		return (filter.Album == "" || albumName(t) == filter.Album) &&
+			(filter.Artist == "" || artistName(t) == filter.Artist || t.AlbumArtist == filter.Artist)
+	})
+}
+
+// Search 在标题、艺术家、专辑和文件名中搜索关键字，不区分大小写
+func (l *Library) Search(query string) []Track {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return []Track{}
+	}
+	return l.collect(func(t *Track) bool {
+		return strings.Contains(strings.ToLower(t.Title), query) ||
+			strings.Contains(strings.ToLower(t.Artist), query) ||
+			strings.Contains(strings.ToLower(t.Album), query) ||
+			strings.Contains(strings.ToLower(filepath.Base(t.Path)), query)
+	})
+}
+
+// collect 复制符合条件的歌曲并排序
+func (l *Library) collect(match func(*Track) bool) []Track {
+	l.mutex.RLock()
+	tracks := make([]Track, 0, len(l.tracks))
+	for _, t := range l.tracks {
+		if match(t) {
+			tracks = append(tracks, *t)
+		}
+	}
+	l.mutex.RUnlock()
+
+	sort.Slice(tracks, func(i, j int) bool {
+		a, b := &tracks[i], &tracks[j]
+		if artistName(a) != artistName(b) {
+			return artistName(a) < artistName(b)
+		}
+		if albumName(a) != albumName(b) {
+			return albumName(a) < albumName(b)
+		}
+		if a.TrackNo != b.TrackNo {
+			return a.TrackNo < b.TrackNo
+		}
+		return a.Title < b.Title
+	})
+	return tracks
+}
+
+// Albums 按专辑汇总
+func (l *Library) Albums() []Album {
+	l.mutex.RLock()
+	albums := make(map[string]*Album)
+	for _, t := range l.tracks {
+		name, artist := albumName(t), t.AlbumArtist
+		if artist == "" {
+			artist = artistName(t)
+		}
+		key := name + "\x00" + artist
+		album, ok := albums[key]
+		if !ok {
+			album = &Album{Name: name, Artist: artist}
+			albums[key] = album
+		}
+		album.Tracks++
+		album.Duration += t.Duration
+		if album.CoverID == "" && t.HasCover {
+			album.CoverID = t.ID
+		}
+	}
+	l.mutex.RUnlock()
+
+	result := make([]Album, 0, len(albums))
+	for _, album := range albums {
+		result = append(result, *album)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Artist != result[j].Artist {
+			return result[i].Artist < result[j].Artist
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Artists 按艺术家汇总
+func (l *Library) Artists() []Artist {
+	l.mutex.RLock()
+	artists := make(map[string]*Artist)
+	albums := make(map[string]map[string]bool)
+	for _, t := range l.tracks {
+		name := artistName(t)
+		artist, ok := artists[name]
+		if !ok {
+			artist = &Artist{Name: name}
+			artists[name] = artist
+			albums[name] = make(map[string]bool)
+		}
+		artist.Tracks++
+		albums[name][albumName(t)] = true
+	}
+	l.mutex.RUnlock()
+
+	result := make([]Artist, 0, len(artists))
+	for name, artist := range artists {
+		artist.Albums = len(albums[name])
+		result = append(result, *artist)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Cover 读取歌曲的嵌入封面
+func (l *Library) Cover(id string) (*Picture, error) {
+	track, ok := l.Track(id)
+	if !ok {
+		return nil, fmt.Errorf("歌曲不存在: %s", id)
+	}
+	tags, err := ReadTags(track.Path)
+	if err != nil {
+		return nil, err
+	}
+	if tags.Cover == nil {
+		return nil, fmt.Errorf("歌曲没有封面: %s", id)
+	}
+	return tags.Cover, nil
+}
+
+func artistName(t *Track) string {
+	if t.Artist == "" {
+		return UnknownArtist
+	}
+	return t.Artist
+}
+
+func albumName(t *Track) string {
+	if t.Album == "" {
+		return UnknownAlbum
+	}
+	return t.Album
+}
@@ -0,0 +1,66 @@
+package library
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// RIFF INFO 子块与标签字段的对应关系
+var riffInfoFields = map[string]string{
+	"INAM": "title",
+	"IART": "artist",
+	"IPRD": "album",
+	"ITRK": "track",
+	"IPRT": "track",
+}
+
+// readRIFFTags 读取 WAV 文件中的 LIST/INFO 块，以及部分软件写入的 id3 块
+func readRIFFTags(r io.ReaderAt, size int64, tags *Tags) error {
+	for offset := int64(12); offset+8 <= size; {
+		header, err := readAt(r, offset, 8)
+		if err != nil || len(header) < 8 {
+			break
+		}
+		id := string(header[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+		body := offset + 8
+
+		switch id {
+		case "LIST":
+			if chunkSize >= 4 && chunkSize <= maxCoverSize {
+				data, err := readAt(r, body, int(chunkSize))
+				if err == nil && bytes.Equal(data[0:4], []byte("INFO")) {
+					parseRIFFInfo(data[4:], tags)
+				}
+			}
+		case "id3 ", "ID3 ":
+			if chunkSize >= 10 && chunkSize <= maxCoverSize {
+				data, err := readAt(r, body, int(chunkSize))
+				if err == nil && bytes.Equal(data[0:3], []byte("ID3")) {
+					parseID3v2(data[10:], data[3], data[5], tags)
+				}
+			}
+		}
+
+		// 块按偶数字节对齐
+		offset = body + chunkSize + chunkSize%2
+	}
+	return nil
+}
+
+// parseRIFFInfo 解析 INFO 列表中的子块
+func parseRIFFInfo(data []byte, tags *Tags) {
+	for pos := 0; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		pos += 8
+		if size < 0 || pos+size > len(data) {
+			break
+		}
+		if field := riffInfoFields[id]; field != "" {
+			tags.merge(field, string(data[pos:pos+size]))
+		}
+		pos += size + size%2
+	}
+}
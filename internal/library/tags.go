@@ -0,0 +1,181 @@
+package library
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 嵌入封面的最大大小，超过的封面会被忽略
+const maxCoverSize = 16 * 1024 * 1024
+
+// Tags 从音频文件中读到的标签
+type Tags struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Track       int
+	Cover       *Picture
+	ReplayGain  ReplayGain
+}
+
+// ReplayGain 标签中的增益（dB）和峰值（1 为满刻度），没有的字段为空
+type ReplayGain struct {
+	TrackGain *float64 `json:"track_gain,omitempty"`
+	TrackPeak *float64 `json:"track_peak,omitempty"`
+	AlbumGain *float64 `json:"album_gain,omitempty"`
+	AlbumPeak *float64 `json:"album_peak,omitempty"`
+}
+
+// r128Offset Opus 的 R128_*_GAIN 以 -23 LUFS 为参考，换算为 ReplayGain（-18 LUFS）需要加上的分贝
+const r128Offset = 5
+
+// Picture 嵌入的封面图片
+type Picture struct {
+	MIME string
+	Data []byte
+}
+
+// ReadTags 读取音频文件的标签，支持 ID3v2/ID3v1、RIFF INFO 和 Vorbis comment（FLAC、Ogg）
+func ReadTags(path string) (*Tags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := stat.Size()
+
+	head := make([]byte, 12)
+	if _, err := f.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("读取文件头失败: %v", err)
+	}
+
+	tags := &Tags{}
+	switch {
+	case bytes.Equal(head[0:4], []byte("fLaC")):
+		err = readFLACTags(f, size, tags)
+	case bytes.Equal(head[0:4], []byte("OggS")):
+		err = readOggTags(f, tags)
+	case bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")):
+		err = readRIFFTags(f, size, tags)
+	default:
+		if bytes.Equal(head[0:3], []byte("ID3")) {
+			err = readID3v2(f, tags)
+		}
+		// ID3v1 只用来补充 ID3v2 中缺少的字段
+		readID3v1(f, size, tags)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// merge 只填充尚未设置的字段
+func (t *Tags) merge(key, value string) {
+	value = strings.TrimSpace(strings.Trim(value, "\x00"))
+	if value == "" {
+		return
+	}
+	switch key {
+	case "title":
+		if t.Title == "" {
+			t.Title = value
+		}
+	case "artist":
+		if t.Artist == "" {
+			t.Artist = value
+		}
+	case "album":
+		if t.Album == "" {
+			t.Album = value
+		}
+	case "albumartist":
+		if t.AlbumArtist == "" {
+			t.AlbumArtist = value
+		}
+	case "track":
+		if t.Track == 0 {
+			t.Track = parseTrackNumber(value)
+		}
+	case "replaygain_track_gain":
+		mergeFloat(&t.ReplayGain.TrackGain, parseGain(value))
+	case "replaygain_track_peak":
+		mergeFloat(&t.ReplayGain.TrackPeak, parseFloat(value))
+	case "replaygain_album_gain":
+		mergeFloat(&t.ReplayGain.AlbumGain, parseGain(value))
+	case "replaygain_album_peak":
+		mergeFloat(&t.ReplayGain.AlbumPeak, parseFloat(value))
+	case "r128_track_gain":
+		mergeFloat(&t.ReplayGain.TrackGain, parseR128(value))
+	case "r128_album_gain":
+		mergeFloat(&t.ReplayGain.AlbumGain, parseR128(value))
+	}
+}
+
+// mergeFloat 字段尚未设置时填充
+func mergeFloat(field **float64, value *float64) {
+	if *field == nil && value != nil {
+		*field = value
+	}
+}
+
+// parseFloat 解析数字，失败时返回空
+func parseFloat(value string) *float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// parseGain 解析 "-6.50 dB" 格式的增益
+func parseGain(value string) *float64 {
+	value = strings.TrimSpace(value)
+	if len(value) > 2 && strings.EqualFold(value[len(value)-2:], "dB") {
+		value = value[:len(value)-2]
+	}
+	return parseFloat(value)
+}
+
+// parseR128 解析 Opus 的 R128 增益（Q7.8 定点数）
+func parseR128(value string) *float64 {
+	q, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	gain := float64(q)/256 + r128Offset
+	return &gain
+}
+
+// parseTrackNumber 解析 "3" 或 "3/12" 格式的音轨号
+func parseTrackNumber(value string) int {
+	if slash := strings.Index(value, "/"); slash >= 0 {
+		value = value[:slash]
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// readAt 读取指定位置的数据，读到末尾时返回实际读取的部分
+func readAt(r io.ReaderAt, offset int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := r.ReadAt(buf, offset)
+	if err != nil && !(err == io.EOF && read > 0) {
+		return nil, err
+	}
+	return buf[:read], nil
+}
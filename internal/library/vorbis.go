@@ -0,0 +1,216 @@
+package library
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Vorbis comment 字段与标签字段的对应关系
+var vorbisFields = map[string]string{
+	"TITLE":       "title",
+	"ARTIST":      "artist",
+	"ALBUM":       "album",
+	"ALBUMARTIST": "albumartist",
+	"TRACKNUMBER": "track",
+
+	"REPLAYGAIN_TRACK_GAIN": "replaygain_track_gain",
+	"REPLAYGAIN_TRACK_PEAK": "replaygain_track_peak",
+	"REPLAYGAIN_ALBUM_GAIN": "replaygain_album_gain",
+	"REPLAYGAIN_ALBUM_PEAK": "replaygain_album_peak",
+	"R128_TRACK_GAIN":       "r128_track_gain",
+	"R128_ALBUM_GAIN":       "r128_album_gain",
+}
+
+// FLAC 元数据块类型
+const (
+	flacVorbisComment = 4
+	flacPicture       = 6
+)
+
+// readFLACTags 遍历 FLAC 元数据块，读取 VORBIS_COMMENT 和 PICTURE
+func readFLACTags(r io.ReaderAt, size int64, tags *Tags) error {
+	for offset := int64(4); offset+4 <= size; {
+		header, err := readAt(r, offset, 4)
+		if err != nil || len(header) < 4 {
+			return fmt.Errorf("读取 FLAC 元数据失败: %v", err)
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		offset += 4
+
+		if (blockType == flacVorbisComment || blockType == flacPicture) && length <= maxCoverSize {
+			data, err := readAt(r, offset, length)
+			if err != nil {
+				return fmt.Errorf("读取 FLAC 元数据失败: %v", err)
+			}
+			if blockType == flacVorbisComment {
+				parseVorbisComment(data, tags)
+			} else if tags.Cover == nil {
+				tags.Cover = parseFLACPicture(data)
+			}
+		}
+
+		offset += int64(length)
+		if last {
+			break
+		}
+	}
+	return nil
+}
+
+// readOggTags 读取 Ogg Vorbis/Opus 的第二个包（注释头）
+func readOggTags(r io.ReaderAt, tags *Tags) error {
+	packets, err := readOggPackets(r, 2)
+	if err != nil {
+		return err
+	}
+	if len(packets) < 2 {
+		return nil
+	}
+
+	comment := packets[1]
+	switch {
+	case bytes.HasPrefix(comment, []byte("\x03vorbis")):
+		parseVorbisComment(comment[7:], tags)
+	case bytes.HasPrefix(comment, []byte("OpusTags")):
+		parseVorbisComment(comment[8:], tags)
+	}
+	return nil
+}
+
+// readOggPackets 读取第一个逻辑流的前 count 个包
+func readOggPackets(r io.ReaderAt, count int) ([][]byte, error) {
+	var packets [][]byte
+	var current []byte
+	var serial uint32
+	total := 0
+
+	for offset := int64(0); len(packets) < count; {
+		header, err := readAt(r, offset, 27)
+		if err != nil || len(header) < 27 || !bytes.Equal(header[0:4], []byte("OggS")) {
+			break
+		}
+		pageSerial := binary.LittleEndian.Uint32(header[14:18])
+		if offset == 0 {
+			serial = pageSerial
+		}
+		segments := int(header[26])
+		table, err := readAt(r, offset+27, segments)
+		if err != nil || len(table) < segments {
+			break
+		}
+
+		bodySize := 0
+		for _, lacing := range table {
+			bodySize += int(lacing)
+		}
+		body, err := readAt(r, offset+27+int64(segments), bodySize)
+		if err != nil {
+			break
+		}
+		offset += 27 + int64(segments) + int64(bodySize)
+		if pageSerial != serial {
+			continue // 跳过其他逻辑流
+		}
+
+		// 按分段表把页内数据拼成包，长度为255的分段表示包未结束
+		pos := 0
+		for _, lacing := range table {
+			end := pos + int(lacing)
+			if end > len(body) {
+				end = len(body)
+			}
+			current = append(current, body[pos:end]...)
+			pos = end
+			total += int(lacing)
+			if total > maxCoverSize {
+				return packets, fmt.Errorf("Ogg 注释头过大")
+			}
+			if lacing < 255 {
+				packets = append(packets, current)
+				current = nil
+				if len(packets) == count {
+					break
+				}
+			}
+		}
+	}
+	return packets, nil
+}
+
+// parseVorbisComment 解析 Vorbis comment 结构（不含前缀）
+func parseVorbisComment(data []byte, tags *Tags) {
+	if len(data) < 8 {
+		return
+	}
+	vendorLen := int(binary.LittleEndian.Uint32(data))
+	pos := 4 + vendorLen
+	if pos+4 > len(data) || vendorLen < 0 {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(data[pos:]))
+	pos += 4
+
+	for i := 0; i < count && pos+4 <= len(data); i++ {
+		length := int(binary.LittleEndian.Uint32(data[pos:]))
+		pos += 4
+		if length < 0 || pos+length > len(data) {
+			return
+		}
+		entry := string(data[pos : pos+length])
+		pos += length
+
+		eq := strings.IndexByte(entry, '=')
+		if eq < 0 {
+			continue
+		}
+		key, value := strings.ToUpper(entry[:eq]), entry[eq+1:]
+		switch {
+		case key == "METADATA_BLOCK_PICTURE":
+			if tags.Cover == nil {
+				if raw, err := base64.StdEncoding.DecodeString(value); err == nil {
+					tags.Cover = parseFLACPicture(raw)
+				}
+			}
+		case vorbisFields[key] != "":
+			tags.merge(vorbisFields[key], value)
+		}
+	}
+}
+
+// parseFLACPicture 解析 FLAC PICTURE 块（Ogg 中以 base64 存放在 METADATA_BLOCK_PICTURE）
+func parseFLACPicture(data []byte) *Picture {
+	pos := 4 // 图片类型
+	readField := func() ([]byte, bool) {
+		if pos+4 > len(data) {
+			return nil, false
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		pos += 4
+		if length < 0 || pos+length > len(data) {
+			return nil, false
+		}
+		field := data[pos : pos+length]
+		pos += length
+		return field, true
+	}
+
+	mime, ok := readField()
+	if !ok {
+		return nil
+	}
+	if _, ok := readField(); !ok { // 描述
+		return nil
+	}
+	pos += 16 // 宽、高、色深、颜色数
+	picture, ok := readField()
+	if !ok || len(picture) == 0 {
+		return nil
+	}
+	return &Picture{MIME: string(mime), Data: append([]byte(nil), picture...)}
+}
@@ -0,0 +1,184 @@
+package loudness
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"aku-web/internal/player/probe"
+)
+
+// 外部解码器统一输出的格式
+const (
+	decodeRate     = 48000
+	decodeChannels = 2
+)
+
+// Result 测量结果
+type Result struct {
+	Loudness float64 `json:"loudness"` // 整体响度（LUFS）
+	Peak     float64 `json:"peak"`     // 采样峰值，1 为满刻度
+}
+
+// Gain 返回达到 ReplayGain 参考响度需要的增益（dB）
+func (r Result) Gain() float64 {
+	return ReferenceLUFS - r.Loudness
+}
+
+// Measure 解码整个文件并测量响度。WAV 直接读取，MP3 使用 mpg123 解码，其他格式需要 ffmpeg
+func Measure(path string) (Result, error) {
+	info, err := probe.File(path)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var meter *Meter
+	switch info.Format {
+	case "wav":
+		meter, err = measureWAV(path)
+	case "mp3":
+		meter, err = measureCommand(exec.Command("mpg123", "-q", "-s", "-e", "s16", "--stereo",
+			"-r", strconv.Itoa(decodeRate), path))
+	default:
+		if _, lookErr := exec.LookPath("ffmpeg"); lookErr != nil {
+			return Result{}, fmt.Errorf("没有 ffmpeg，无法解码 %s", info.Format)
+		}
+		meter, err = measureCommand(exec.Command("ffmpeg", "-v", "error", "-i", path,
+			"-f", "s16le", "-ac", strconv.Itoa(decodeChannels), "-ar", strconv.Itoa(decodeRate), "-"))
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	loudness := meter.Integrated()
+	if math.IsInf(loudness, -1) {
+		return Result{}, fmt.Errorf("音频太短或全部静音")
+	}
+	return Result{Loudness: loudness, Peak: meter.Peak()}, nil
+}
+
+// measureCommand 读取解码器输出的 16 位小端立体声 PCM
+func measureCommand(cmd *exec.Cmd) (*Meter, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动解码器失败: %v", err)
+	}
+
+	meter := NewMeter(decodeRate, decodeChannels)
+	readErr := readPCM(bufio.NewReaderSize(stdout, 64*1024), meter, 2, false)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("解码失败: %v", err)
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return meter, nil
+}
+
+// measureWAV 直接读取 WAV 中的 PCM 数据，支持 16/24/32 位整数和 32 位浮点
+func measureWAV(path string) (*Meter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, err
+	}
+
+	var format, channels, bits int
+	var rate int
+	for {
+		chunk := make([]byte, 8)
+		if _, err := io.ReadFull(f, chunk); err != nil {
+			return nil, fmt.Errorf("WAV 缺少 data 块")
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			data := make([]byte, size+size%2)
+			if _, err := io.ReadFull(f, data); err != nil || size < 16 {
+				return nil, fmt.Errorf("WAV fmt 块不完整")
+			}
+			format = int(binary.LittleEndian.Uint16(data[0:2]))
+			channels = int(binary.LittleEndian.Uint16(data[2:4]))
+			rate = int(binary.LittleEndian.Uint32(data[4:8]))
+			bits = int(binary.LittleEndian.Uint16(data[14:16]))
+			// WAVE_FORMAT_EXTENSIBLE 的实际格式在子格式 GUID 的前两个字节
+			if format == 0xFFFE && size >= 26 {
+				format = int(binary.LittleEndian.Uint16(data[24:26]))
+			}
+		case "data":
+			if channels == 0 || rate == 0 {
+				return nil, fmt.Errorf("WAV 缺少 fmt 块")
+			}
+			float := format == 3
+			if !(format == 1 && (bits == 16 || bits == 24 || bits == 32)) && !(float && bits == 32) {
+				return nil, fmt.Errorf("不支持的 WAV 格式: %d/%d 位", format, bits)
+			}
+			meter := NewMeter(rate, channels)
+			var r io.Reader = f
+			if size > 0 && size != 0xFFFFFFFF {
+				r = io.LimitReader(f, size)
+			}
+			if err := readPCM(bufio.NewReaderSize(r, 64*1024), meter, bits/8, float); err != nil {
+				return nil, err
+			}
+			return meter, nil
+		default:
+			if _, err := f.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readPCM 读取小端 PCM 采样交给响度计，width 为每个采样的字节数
+func readPCM(r io.Reader, meter *Meter, width int, float bool) error {
+	buf := make([]byte, 4096*width*meter.channels)
+	samples := make([]float64, 0, 4096*meter.channels)
+	for {
+		n, err := io.ReadFull(r, buf)
+		n -= n % (width * meter.channels)
+		samples = samples[:0]
+		for i := 0; i < n; i += width {
+			samples = append(samples, decodeSample(buf[i:i+width], float))
+		}
+		meter.Add(samples)
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取音频数据失败: %v", err)
+		}
+	}
+}
+
+// decodeSample 把一个小端采样换算为 -1 到 1
+func decodeSample(b []byte, float bool) float64 {
+	switch len(b) {
+	case 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case 3:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float64(v) / 8388608
+	default:
+		if float {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}
+		return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+	}
+}
@@ -0,0 +1,169 @@
+// Package loudness 按 ITU-R BS.1770 / EBU R128 计算音频的整体响度，用于没有 ReplayGain 标签的歌曲
+package loudness
+
+import (
+	"math"
+)
+
+// ReferenceLUFS ReplayGain 2.0 的参考响度，增益 = ReferenceLUFS - 歌曲响度
+const ReferenceLUFS = -18.0
+
+// 门限和分块参数（BS.1770-4）
+const (
+	blockSubdivisions = 4     // 400ms 的块由 4 个 100ms 的子块组成，相邻块重叠 75%
+	absoluteGate      = -70.0 // 绝对门限（LUFS）
+	relativeGate      = -10.0 // 相对门限（LU）
+)
+
+// biquad 二阶 IIR 滤波器
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// kWeighting 返回指定采样率下的 K 计权滤波器（高架滤波 + 高通滤波）
+func kWeighting(rate int) [2]biquad {
+	fs := float64(rate)
+
+	// 第一级：高架滤波，模拟头部的声学效应
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// 第二级：高通滤波（RLB 计权）
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	highpass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return [2]biquad{shelf, highpass}
+}
+
+// Meter 逐段输入采样，计算整体响度和采样峰值
+type Meter struct {
+	channels   int
+	filters    [][2]biquad
+	subSize    int       // 每个子块的帧数
+	subFrames  int       // 当前子块已有的帧数
+	subEnergy  float64   // 当前子块的能量（各声道之和）
+	recent     []float64 // 最近的子块能量，凑够 blockSubdivisions 个时得到一个块
+	blocks     []float64 // 每个块的均方值
+	peak       float64
+	totalFrame int64
+}
+
+// NewMeter 创建响度计，rate 为采样率，channels 为声道数（各声道权重相同，适用于单声道和立体声）
+func NewMeter(rate, channels int) *Meter {
+	m := &Meter{
+		channels: channels,
+		filters:  make([][2]biquad, channels),
+		subSize:  rate / 10,
+	}
+	for i := range m.filters {
+		m.filters[i] = kWeighting(rate)
+	}
+	return m
+}
+
+// Add 输入交错排列的采样，范围为 -1 到 1
+func (m *Meter) Add(samples []float64) {
+	for i := 0; i+m.channels <= len(samples); i += m.channels {
+		for c := 0; c < m.channels; c++ {
+			x := samples[i+c]
+			if a := math.Abs(x); a > m.peak {
+				m.peak = a
+			}
+			y := m.filters[c][0].process(x)
+			y = m.filters[c][1].process(y)
+			m.subEnergy += y * y
+		}
+		m.subFrames++
+		m.totalFrame++
+		if m.subFrames == m.subSize {
+			m.finishSubBlock()
+		}
+	}
+}
+
+// finishSubBlock 结束一个 100ms 的子块
+func (m *Meter) finishSubBlock() {
+	m.recent = append(m.recent, m.subEnergy)
+	m.subEnergy, m.subFrames = 0, 0
+	if len(m.recent) < blockSubdivisions {
+		return
+	}
+	m.recent = m.recent[len(m.recent)-blockSubdivisions:]
+
+	var sum float64
+	for _, e := range m.recent {
+		sum += e
+	}
+	m.blocks = append(m.blocks, sum/float64(m.subSize*blockSubdivisions))
+}
+
+// Integrated 返回整体响度（LUFS），有效内容不足 400ms 或全部静音时返回负无穷
+func (m *Meter) Integrated() float64 {
+	var sum float64
+	var count int
+	for _, z := range m.blocks {
+		if blockLoudness(z) > absoluteGate {
+			sum += z
+			count++
+		}
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+
+	threshold := blockLoudness(sum/float64(count)) + relativeGate
+	sum, count = 0, 0
+	for _, z := range m.blocks {
+		if l := blockLoudness(z); l > absoluteGate && l > threshold {
+			sum += z
+			count++
+		}
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(sum / float64(count))
+}
+
+// Peak 返回采样峰值，1 为满刻度
+func (m *Meter) Peak() float64 {
+	return m.peak
+}
+
+// Seconds 返回已输入的时长
+func (m *Meter) Seconds() float64 {
+	if m.subSize == 0 {
+		return 0
+	}
+	return float64(m.totalFrame) / float64(m.subSize*10)
+}
+
+// blockLoudness 把均方值换算为响度
+func blockLoudness(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
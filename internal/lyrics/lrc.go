@@ -0,0 +1,211 @@
+// Package lyrics 解析 LRC 格式的歌词，按播放位置查找当前歌词行
+package lyrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// translationTolerance 原文和翻译的时间相差不超过这个秒数视为同一行
+const translationTolerance = 0.05
+
+// Line 一行歌词
+type Line struct {
+	Time        float64 `json:"time"` // 开始时间（秒）
+	Text        string  `json:"text"`
+	Translation string  `json:"translation,omitempty"`
+}
+
+// Lyrics 解析后的歌词，Lines 按时间排序
+type Lyrics struct {
+	Title  string `json:"title,omitempty"`
+	Artist string `json:"artist,omitempty"`
+	Album  string `json:"album,omitempty"`
+	Lines  []Line `json:"lines"`
+}
+
+// Parse 解析 LRC 歌词，支持一行多个时间标签、[offset:] 偏移和逐字时间标签，
+// 无法识别的行（例如网易云的 JSON 制作信息）会被忽略
+func Parse(text string) *Lyrics {
+	l := &Lyrics{Lines: []Line{}}
+	offset := 0.0
+
+	text = strings.TrimPrefix(text, "\ufeff")
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		var times []float64
+		for strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				break
+			}
+			tag := line[1:end]
+			line = strings.TrimSpace(line[end+1:])
+
+			if t, ok := parseTimestamp(tag); ok {
+				times = append(times, t)
+				continue
+			}
+			key, value, ok := strings.Cut(tag, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "ti":
+				l.Title = value
+			case "ar":
+				l.Artist = value
+			case "al":
+				l.Album = value
+			case "offset":
+				// 正数表示歌词提前显示，单位为毫秒
+				if ms, err := strconv.Atoi(value); err == nil {
+					offset = float64(ms) / 1000
+				}
+			}
+		}
+
+		content := stripWordTimes(line)
+		for _, t := range times {
+			l.Lines = append(l.Lines, Line{Time: t, Text: content})
+		}
+	}
+
+	for i := range l.Lines {
+		l.Lines[i].Time -= offset
+		if l.Lines[i].Time < 0 {
+			l.Lines[i].Time = 0
+		}
+	}
+	sort.SliceStable(l.Lines, func(i, j int) bool {
+		return l.Lines[i].Time < l.Lines[j].Time
+	})
+	return l
+}
+
+// parseTimestamp 解析 mm:ss、mm:ss.xx、mm:ss.xxx 或 mm:ss:xx 形式的时间标签
+func parseTimestamp(tag string) (float64, bool) {
+	mm, rest, ok := strings.Cut(tag, ":")
+	if !ok {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(mm)
+	if err != nil || minutes < 0 {
+		return 0, false
+	}
+
+	sec, frac, hasFrac := strings.Cut(rest, ".")
+	if !hasFrac {
+		sec, frac, hasFrac = strings.Cut(rest, ":")
+	}
+	seconds, err := strconv.Atoi(sec)
+	if err != nil || seconds < 0 || seconds >= 60 {
+		return 0, false
+	}
+
+	t := float64(minutes*60 + seconds)
+	if hasFrac {
+		n, err := strconv.Atoi(frac)
+		if err != nil || n < 0 || frac == "" {
+			return 0, false
+		}
+		t += float64(n) / pow10(len(frac))
+	}
+	return t, true
+}
+
+// pow10 返回 10 的 n 次方
+func pow10(n int) float64 {
+	v := 1.0
+	for i := 0; i < n; i++ {
+		v *= 10
+	}
+	return v
+}
+
+// stripWordTimes 去掉增强 LRC 中 <mm:ss.xx> 形式的逐字时间标签
+func stripWordTimes(text string) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(text, '<')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(text[start:], '>')
+		if end < 0 {
+			break
+		}
+		if _, ok := parseTimestamp(text[start+1 : start+end]); !ok {
+			b.WriteString(text[:start+end+1])
+			text = text[start+end+1:]
+			continue
+		}
+		b.WriteString(text[:start])
+		text = text[start+end+1:]
+	}
+	b.WriteString(text)
+	return strings.TrimSpace(b.String())
+}
+
+// Translate 把翻译歌词按时间合并到对应的原文行
+func (l *Lyrics) Translate(translation *Lyrics) {
+	if translation == nil {
+		return
+	}
+	for _, t := range translation.Lines {
+		if t.Text == "" {
+			continue
+		}
+		i := sort.Search(len(l.Lines), func(i int) bool {
+			return l.Lines[i].Time >= t.Time-translationTolerance
+		})
+		if i < len(l.Lines) && l.Lines[i].Time <= t.Time+translationTolerance && l.Lines[i].Translation == "" {
+			l.Lines[i].Translation = t.Text
+		}
+	}
+}
+
+// Index 返回播放到 position 秒时的歌词行，还没到第一行时返回 -1
+func (l *Lyrics) Index(position float64) int {
+	i := sort.Search(len(l.Lines), func(i int) bool {
+		return l.Lines[i].Time > position
+	})
+	return i - 1
+}
+
+// Empty 没有任何带时间的歌词行
+func (l *Lyrics) Empty() bool {
+	return l == nil || len(l.Lines) == 0
+}
+
+// SidecarPath 返回和音频文件同名的 .lrc 歌词文件，不存在时返回空字符串
+func SidecarPath(audioPath string) string {
+	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
+	for _, ext := range []string{".lrc", ".LRC"} {
+		if info, err := os.Stat(base + ext); err == nil && !info.IsDir() {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// LoadSidecar 读取音频文件同名的 .lrc 歌词，没有歌词文件时返回 nil
+func LoadSidecar(audioPath string) (*Lyrics, error) {
+	path := SidecarPath(audioPath)
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取歌词文件失败: %v", err)
+	}
+	return Parse(string(data)), nil
+}
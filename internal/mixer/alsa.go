@@ -0,0 +1,353 @@
+package mixer
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ALSA 通过 amixer 命令操作 ALSA 混音器。参数直接传给 amixer，不经过 shell
+type ALSA struct {
+	card     string
+	controls map[string]Control // 控制项的范围不会变化，读取一次后缓存
+	order    []string           // 控制项在 amixer 输出中的顺序
+	saved    map[string]int     // 没有硬件开关的控制项静音前的音量
+	mutex    sync.Mutex
+}
+
+// NewALSA 创建 ALSA 混音器，card 为 amixer -c 的参数，为空时使用默认声卡
+func NewALSA(card string) *ALSA {
+	return &ALSA{card: card, saved: make(map[string]int)}
+}
+
+// Name 返回实现名称
+func (a *ALSA) Name() string {
+	return "alsa"
+}
+
+// amixer 执行 amixer 命令并返回输出
+func (a *ALSA) amixer(args ...string) (string, error) {
+	if a.card != "" {
+		args = append([]string{"-c", a.card}, args...)
+	}
+	output, err := exec.Command("amixer", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("amixer %s 失败: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// Controls 列出所有带播放音量的控制项
+func (a *ALSA) Controls() ([]Control, error) {
+	if err := a.loadControls(); err != nil {
+		return nil, err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	controls := make([]Control, 0, len(a.order))
+	for _, name := range a.order {
+		controls = append(controls, a.controls[name])
+	}
+	return controls, nil
+}
+
+// loadControls 读取所有控制项的范围，并从元素列表中找到对应的分贝范围
+func (a *ALSA) loadControls() error {
+	a.mutex.Lock()
+	loaded := a.controls != nil
+	a.mutex.Unlock()
+	if loaded {
+		return nil
+	}
+
+	output, err := a.amixer("scontents")
+	if err != nil {
+		return err
+	}
+	blocks := parseSimpleControls(output)
+
+	// 分贝范围只出现在元素列表中，读取失败时仍然可以按原始值和百分比使用
+	var ranges map[string]dbRange
+	if contents, err := a.amixer("contents"); err == nil {
+		ranges = parseDBRanges(contents)
+	}
+
+	controls := make(map[string]Control, len(blocks))
+	var order []string
+	for _, block := range blocks {
+		c := block.control
+		if !block.hasVolume {
+			continue
+		}
+		for _, element := range []string{c.Name + " Playback Volume", c.Name + " Volume", c.Name} {
+			if r, ok := ranges[element]; ok {
+				c.HasDB, c.MinDB, c.MaxDB = true, r.min, r.max
+				break
+			}
+		}
+		controls[c.Name] = c
+		order = append(order, c.Name)
+	}
+
+	a.mutex.Lock()
+	a.controls, a.order = controls, order
+	a.mutex.Unlock()
+	return nil
+}
+
+// control 查找控制项
+func (a *ALSA) control(name string) (Control, error) {
+	if err := a.loadControls(); err != nil {
+		return Control{}, err
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	c, ok := a.controls[name]
+	if !ok {
+		return Control{}, fmt.Errorf("控制项不存在: %s", name)
+	}
+	return c, nil
+}
+
+// Get 读取控制项和当前音量
+func (a *ALSA) Get(name string) (Control, Volume, error) {
+	c, err := a.control(name)
+	if err != nil {
+		return Control{}, Volume{}, err
+	}
+	output, err := a.amixer("sget", name)
+	if err != nil {
+		return Control{}, Volume{}, err
+	}
+	blocks := parseSimpleControls(output)
+	if len(blocks) == 0 || !blocks[0].hasValue {
+		return Control{}, Volume{}, fmt.Errorf("无法解析 %s 的音量", name)
+	}
+	block := blocks[0]
+
+	volume := Volume{Raw: block.value, DB: block.db, Muted: block.off}
+	a.mutex.Lock()
+	if saved, ok := a.saved[name]; ok {
+		volume.Raw, volume.DB, volume.Muted = saved, nil, true
+	}
+	a.mutex.Unlock()
+	volume.Percent = c.Percent(volume.Raw)
+	return c, volume, nil
+}
+
+// SetRaw 按原始值设置音量。模拟静音期间只记录音量，取消静音时恢复
+func (a *ALSA) SetRaw(name string, value int) error {
+	c, err := a.control(name)
+	if err != nil {
+		return err
+	}
+	if err := c.CheckRaw(value); err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	if _, ok := a.saved[name]; ok {
+		a.saved[name] = value
+		a.mutex.Unlock()
+		return nil
+	}
+	a.mutex.Unlock()
+
+	_, err = a.amixer("-q", "sset", name, strconv.Itoa(value))
+	return err
+}
+
+// SetDB 按分贝设置音量
+func (a *ALSA) SetDB(name string, db float64) error {
+	c, err := a.control(name)
+	if err != nil {
+		return err
+	}
+	if err := c.CheckDB(db); err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	_, emulated := a.saved[name]
+	a.mutex.Unlock()
+	if emulated {
+		ratio := (db - c.MinDB) / (c.MaxDB - c.MinDB)
+		return a.SetRaw(name, c.Min+int(ratio*float64(c.Max-c.Min)+0.5))
+	}
+
+	// 负数会被当作选项，需要先用 "--" 结束选项
+	_, err = a.amixer("-q", "--", "sset", name, strconv.FormatFloat(db, 'f', 2, 64)+"dB")
+	return err
+}
+
+// SetMute 静音或取消静音，没有硬件开关时把音量设为最小值模拟
+func (a *ALSA) SetMute(name string, muted bool) error {
+	c, err := a.control(name)
+	if err != nil {
+		return err
+	}
+	if c.HasMute {
+		state := "unmute"
+		if muted {
+			state = "mute"
+		}
+		_, err := a.amixer("-q", "sset", name, state)
+		return err
+	}
+
+	a.mutex.Lock()
+	saved, emulated := a.saved[name]
+	a.mutex.Unlock()
+	switch {
+	case muted && !emulated:
+		_, volume, err := a.Get(name)
+		if err != nil {
+			return err
+		}
+		if _, err := a.amixer("-q", "sset", name, strconv.Itoa(c.Min)); err != nil {
+			return err
+		}
+		a.mutex.Lock()
+		a.saved[name] = volume.Raw
+		a.mutex.Unlock()
+	case !muted && emulated:
+		if _, err := a.amixer("-q", "sset", name, strconv.Itoa(saved)); err != nil {
+			return err
+		}
+		a.mutex.Lock()
+		delete(a.saved, name)
+		a.mutex.Unlock()
+	}
+	return nil
+}
+
+// simpleControl amixer scontents/sget 输出中的一个控制项
+type simpleControl struct {
+	control   Control
+	hasVolume bool // 有播放音量
+	hasValue  bool
+	value     int      // 第一个声道的原始值
+	db        *float64 // 第一个声道的分贝
+	off       bool     // 第一个声道的开关为 off
+}
+
+var (
+	controlHeader = regexp.MustCompile(`^Simple mixer control '(.*)',(\d+)$`)
+	limitsLine    = regexp.MustCompile(`^Limits:(?: Playback)? (-?\d+) - (-?\d+)`)
+	channelLine   = regexp.MustCompile(`^[A-Za-z ]+: (?:Playback )?(-?\d+)(.*)$`)
+	dbValue       = regexp.MustCompile(`\[(-?[\d.]+)dB\]`)
+)
+
+// parseSimpleControls 解析 amixer scontents/sget 的输出
+//
+//	Simple mixer control 'Power Amplifier',0
+//	  Capabilities: volume volume-joined
+//	  Playback channels: Mono
+//	  Limits: 0 - 63
+//	  Mono: 40 [63%] [-23.00dB]
+func parseSimpleControls(output string) []simpleControl {
+	var blocks []simpleControl
+	var current *simpleControl
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := controlHeader.FindStringSubmatch(line); m != nil {
+			blocks = append(blocks, simpleControl{control: Control{Name: m[1]}})
+			current = &blocks[len(blocks)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Capabilities:"):
+			for _, capability := range strings.Fields(line[len("Capabilities:"):]) {
+				switch capability {
+				case "volume", "pvolume":
+					current.hasVolume = true
+				case "switch", "pswitch":
+					current.control.HasMute = true
+				}
+			}
+		case strings.HasPrefix(line, "Limits:"):
+			if m := limitsLine.FindStringSubmatch(line); m != nil {
+				current.control.Min, _ = strconv.Atoi(m[1])
+				current.control.Max, _ = strconv.Atoi(m[2])
+			}
+		case strings.Contains(line, "channels:"), strings.Contains(line, ": Capture "):
+		default:
+			m := channelLine.FindStringSubmatch(line)
+			if m == nil || current.hasValue {
+				continue
+			}
+			current.hasValue = true
+			current.value, _ = strconv.Atoi(m[1])
+			if d := dbValue.FindStringSubmatch(m[2]); d != nil {
+				if db, err := strconv.ParseFloat(d[1], 64); err == nil {
+					current.db = &db
+				}
+			}
+			current.off = strings.Contains(m[2], "[off]")
+		}
+	}
+	return blocks
+}
+
+// dbRange 元素的分贝范围
+type dbRange struct {
+	min, max float64
+}
+
+var (
+	elementHeader = regexp.MustCompile(`^numid=\d+,iface=MIXER,name='(.*)'`)
+	elementLimits = regexp.MustCompile(`min=(-?\d+),max=(-?\d+)`)
+	dbScale       = regexp.MustCompile(`dBscale-min=(-?[\d.]+)dB,step=(-?[\d.]+)dB`)
+	dbMinMax      = regexp.MustCompile(`dBminmax-min=(-?[\d.]+)dB,max=(-?[\d.]+)dB`)
+)
+
+// parseDBRanges 解析 amixer contents 输出中各元素的分贝范围
+//
+//	numid=3,iface=MIXER,name='Power Amplifier Volume'
+//	  ; type=INTEGER,access=rw---R--,values=1,min=0,max=63,step=0
+//	  : values=40
+//	  | dBscale-min=-63.00dB,step=1.00dB,mute=0
+func parseDBRanges(output string) map[string]dbRange {
+	ranges := make(map[string]dbRange)
+	var name string
+	var min, max int
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := elementHeader.FindStringSubmatch(line); m != nil {
+			name, min, max = m[1], 0, 0
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		if m := elementLimits.FindStringSubmatch(line); m != nil && strings.HasPrefix(line, ";") {
+			min, _ = strconv.Atoi(m[1])
+			max, _ = strconv.Atoi(m[2])
+			continue
+		}
+		if m := dbScale.FindStringSubmatch(line); m != nil {
+			lo, _ := strconv.ParseFloat(m[1], 64)
+			step, _ := strconv.ParseFloat(m[2], 64)
+			ranges[name] = dbRange{min: lo, max: lo + step*float64(max-min)}
+		} else if m := dbMinMax.FindStringSubmatch(line); m != nil {
+			lo, _ := strconv.ParseFloat(m[1], 64)
+			hi, _ := strconv.ParseFloat(m[2], 64)
+			ranges[name] = dbRange{min: lo, max: hi}
+		}
+	}
+	return ranges
+}
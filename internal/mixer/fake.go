@@ -0,0 +1,147 @@
+package mixer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Fake 内存中的假混音器，不操作硬件，用于测试和没有声卡的环境。
+// 和 ALSA 一样，没有硬件开关的控制项静音时把音量设为最小值模拟
+type Fake struct {
+	controls map[string]Control
+	values   map[string]int
+	muted    map[string]bool
+	saved    map[string]int // 模拟静音前的音量
+	sets     int
+	mutex    sync.Mutex
+}
+
+// NewFake 创建假混音器，不指定控制项时提供一个与开发板相同的 "Power Amplifier"（0-63，-63dB 到 0dB）
+func NewFake(controls ...Control) *Fake {
+	if len(controls) == 0 {
+		controls = []Control{{Name: "Power Amplifier", Min: 0, Max: 63, HasDB: true, MinDB: -63, MaxDB: 0, HasMute: true}}
+	}
+	f := &Fake{
+		controls: make(map[string]Control),
+		values:   make(map[string]int),
+		muted:    make(map[string]bool),
+		saved:    make(map[string]int),
+	}
+	for _, c := range controls {
+		f.controls[c.Name] = c
+		f.values[c.Name] = c.Min + (c.Max-c.Min)/2
+	}
+	return f
+}
+
+// Name 返回实现名称
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// Controls 列出所有控制项
+func (f *Fake) Controls() ([]Control, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	controls := make([]Control, 0, len(f.controls))
+	for _, c := range f.controls {
+		controls = append(controls, c)
+	}
+	sort.Slice(controls, func(i, j int) bool { return controls[i].Name < controls[j].Name })
+	return controls, nil
+}
+
+// Get 读取控制项和当前音量
+func (f *Fake) Get(control string) (Control, Volume, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	c, ok := f.controls[control]
+	if !ok {
+		return Control{}, Volume{}, fmt.Errorf("控制项不存在: %s", control)
+	}
+	raw := f.values[control]
+	if saved, ok := f.saved[control]; ok {
+		return c, Volume{Raw: saved, Percent: c.Percent(saved), Muted: true}, nil
+	}
+	volume := Volume{Raw: raw, Percent: c.Percent(raw), Muted: f.muted[control]}
+	if c.HasDB {
+		db := c.MinDB + (c.MaxDB-c.MinDB)*float64(raw-c.Min)/float64(c.Max-c.Min)
+		volume.DB = &db
+	}
+	return c, volume, nil
+}
+
+// SetRaw 按原始值设置音量。模拟静音期间只记录音量，取消静音时恢复
+func (f *Fake) SetRaw(control string, value int) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	c, ok := f.controls[control]
+	if !ok {
+		return fmt.Errorf("控制项不存在: %s", control)
+	}
+	if err := c.CheckRaw(value); err != nil {
+		return err
+	}
+	if _, ok := f.saved[control]; ok {
+		f.saved[control] = value
+		return nil
+	}
+	f.values[control] = value
+	f.sets++
+	return nil
+}
+
+// SetDB 按分贝设置音量，取最接近的原始值
+func (f *Fake) SetDB(control string, db float64) error {
+	f.mutex.Lock()
+	c, ok := f.controls[control]
+	f.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("控制项不存在: %s", control)
+	}
+	if err := c.CheckDB(db); err != nil {
+		return err
+	}
+	ratio := (db - c.MinDB) / (c.MaxDB - c.MinDB)
+	return f.SetRaw(control, c.Min+int(ratio*float64(c.Max-c.Min)+0.5))
+}
+
+// SetMute 静音或取消静音，没有硬件开关时把音量设为最小值模拟
+func (f *Fake) SetMute(control string, muted bool) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	c, ok := f.controls[control]
+	if !ok {
+		return fmt.Errorf("控制项不存在: %s", control)
+	}
+	if c.HasMute {
+		f.muted[control] = muted
+		return nil
+	}
+
+	saved, emulated := f.saved[control]
+	switch {
+	case muted && !emulated:
+		f.saved[control] = f.values[control]
+		f.values[control] = c.Min
+	case !muted && emulated:
+		f.values[control] = saved
+		delete(f.saved, control)
+	}
+	return nil
+}
+
+// Value 返回控制项实际的原始值，模拟静音时为最小值
+func (f *Fake) Value(control string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.values[control]
+}
+
+// Sets 返回 SetRaw 成功的次数
+func (f *Fake) Sets() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.sets
+}
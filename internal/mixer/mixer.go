@@ -0,0 +1,103 @@
+// Package mixer 音量控制：列出混音器控制项，读取真实的取值和分贝范围，按原始值、百分比或分贝读写音量，支持静音
+package mixer
+
+import (
+	"fmt"
+	"math"
+)
+
+// Control 混音器控制项
+type Control struct {
+	Name    string  `json:"name"`
+	Min     int     `json:"min"`
+	Max     int     `json:"max"`
+	HasDB   bool    `json:"has_db"`   // 是否知道分贝范围
+	MinDB   float64 `json:"min_db"`   // 最小值对应的分贝
+	MaxDB   float64 `json:"max_db"`   // 最大值对应的分贝
+	HasMute bool    `json:"has_mute"` // 是否有硬件静音开关，没有时静音通过把音量设为最小值实现
+}
+
+// Volume 控制项的当前音量
+type Volume struct {
+	Raw     int      `json:"volume"`  // 原始值，范围为 Control.Min 到 Control.Max
+	Percent int      `json:"percent"` // 按原始值线性换算的百分比
+	DB      *float64 `json:"db,omitempty"`
+	Muted   bool     `json:"muted"`
+}
+
+// Mixer 混音器
+type Mixer interface {
+	// Name 返回实现的名称
+	Name() string
+	// Controls 列出所有带音量的控制项
+	Controls() ([]Control, error)
+	// Get 读取控制项的信息和当前音量
+	Get(control string) (Control, Volume, error)
+	// SetRaw 按原始值设置音量，不改变静音状态
+	SetRaw(control string, value int) error
+	// SetDB 按分贝设置音量，不改变静音状态
+	SetDB(control string, db float64) error
+	// SetMute 静音或取消静音
+	SetMute(control string, muted bool) error
+}
+
+// New 根据名称创建混音器（alsa、fake），card 为 ALSA 声卡，为空时使用默认声卡
+func New(name, card string) (Mixer, error) {
+	switch name {
+	case "", "alsa":
+		return NewALSA(card), nil
+	case "fake":
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("未知的混音器: %s", name)
+	}
+}
+
+// Percent 把原始值换算为百分比
+func (c Control) Percent(raw int) int {
+	if c.Max <= c.Min {
+		return 0
+	}
+	return int(math.Round(float64(raw-c.Min) * 100 / float64(c.Max-c.Min)))
+}
+
+// RawFromPercent 把百分比换算为原始值
+func (c Control) RawFromPercent(percent int) int {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	return c.Min + int(math.Round(float64(c.Max-c.Min)*float64(percent)/100))
+}
+
+// CheckRaw 检查原始值是否在范围内
+func (c Control) CheckRaw(raw int) error {
+	if raw < c.Min || raw > c.Max {
+		return fmt.Errorf("音量必须在 %d 到 %d 之间", c.Min, c.Max)
+	}
+	return nil
+}
+
+// CheckDB 检查分贝值是否在范围内
+func (c Control) CheckDB(db float64) error {
+	if !c.HasDB {
+		return fmt.Errorf("控制项 %s 不支持按分贝设置", c.Name)
+	}
+	if db < c.MinDB || db > c.MaxDB {
+		return fmt.Errorf("分贝必须在 %.2f 到 %.2f 之间", c.MinDB, c.MaxDB)
+	}
+	return nil
+}
+
+// SetPercent 按百分比设置音量
+func SetPercent(m Mixer, control string, percent int) error {
+	c, _, err := m.Get(control)
+	if err != nil {
+		return err
+	}
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("百分比必须在 0 到 100 之间")
+	}
+	return m.SetRaw(control, c.RawFromPercent(percent))
+}
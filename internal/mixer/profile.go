@@ -0,0 +1,92 @@
+package mixer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// Source 声音来源，每个来源记住自己的音量
+type Source string
+
+const (
+	SourceMusic   Source = "music"   // 歌曲
+	SourceRadio   Source = "radio"   // 网络电台
+	SourceAlarm   Source = "alarm"   // 闹钟
+	SourceXiaozhi Source = "xiaozhi" // 小智语音
+)
+
+// Sources 所有声音来源
+var Sources = []Source{SourceMusic, SourceRadio, SourceAlarm, SourceXiaozhi}
+
+// ParseSource 检查来源名称
+func ParseSource(name string) (Source, error) {
+	for _, s := range Sources {
+		if string(s) == name {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("未知的声音来源: %s", name)
+}
+
+// Profiles 各来源记住的音量（原始值），保存为一个 JSON 文件
+type Profiles struct {
+	path    string
+	volumes map[Source]int
+	mutex   sync.Mutex
+}
+
+// NewProfiles 创建音量记录并加载已保存的音量
+func NewProfiles(path string) *Profiles {
+	p := &Profiles{path: path, volumes: make(map[Source]int)}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &p.volumes); err != nil {
+			log.Printf("[Mixer] 音量记录文件损坏: %v", err)
+		}
+	}
+	return p
+}
+
+// Get 返回来源记住的音量，没有记录时 ok 为 false
+func (p *Profiles) Get(source Source) (volume int, ok bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	volume, ok = p.volumes[source]
+	return volume, ok
+}
+
+// Set 记住来源的音量
+func (p *Profiles) Set(source Source, volume int) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if old, ok := p.volumes[source]; ok && old == volume {
+		return nil
+	}
+	p.volumes[source] = volume
+	return p.save()
+}
+
+// All 返回所有记住的音量
+func (p *Profiles) All() map[Source]int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	volumes := make(map[Source]int, len(p.volumes))
+	for s, v := range p.volumes {
+		volumes[s] = v
+	}
+	return volumes
+}
+
+// save 写入磁盘，调用方需持有锁
+func (p *Profiles) save() error {
+	data, err := json.MarshalIndent(p.volumes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("保存音量记录失败: %v", err)
+	}
+	return os.Rename(p.path+".tmp", p.path)
+}
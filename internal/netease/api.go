@@ -1,33 +1,24 @@
 package netease
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"strings"
-	"sync"
+	"net/url"
+	"strconv"
 )
 
-// API 端点
+// API 路径，相对于 Client.BaseURL
 const (
-	playlistDetailAPI = "https://music.163.com/api/v6/playlist/detail"
-	songDetailAPI     = "https://music.163.com/api/v3/song/detail"
-	batchSize         = 50 // 每批处理的歌曲数量
+	playlistDetailPath   = "/api/v6/playlist/detail"
+	songDetailPath       = "/api/v3/song/detail"
+	musicUrlPath         = "/song/media/outer/url" // 跳转到歌曲的播放地址，不可用时跳转到 /404
+	batchSize            = 50                      // 每批请求详情的歌曲数量
+	playlistTrackDetails = 1000                    // 歌单详情附带歌曲详情的数量
 )
 
-// 创建跳过证书验证的 HTTP 客户端
-var insecureClient = &http.Client{
-	Transport: &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
-	},
-}
-
 // PlaylistResponse 表示歌单详情的响应结构
 type PlaylistResponse struct {
 	Code     int `json:"code"`
@@ -38,29 +29,64 @@ type PlaylistResponse struct {
 		TrackIds   []struct {
 			Id uint `json:"id"`
 		} `json:"trackIds"`
+		Tracks []SongDetail `json:"tracks"` // 前 n 首歌曲的详情
 	} `json:"playlist"`
 }
 
 // SongResponse 表示歌曲详情的响应结构
 type SongResponse struct {
-	Songs []struct {
-		Id   uint   `json:"id"`
+	Songs []SongDetail `json:"songs"`
+}
+
+// SongDetail 歌曲详情、搜索、专辑和艺术家接口共用的歌曲结构
+type SongDetail struct {
+	Id   uint   `json:"id"`
+	Name string `json:"name"`
+	Fee  int    `json:"fee"`
+	Dt   int64  `json:"dt"` // 时长（毫秒）
+	Ar   []struct {
+		Id   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"ar"`
+	Al struct {
+		Id   int64  `json:"id"`
 		Name string `json:"name"`
-		Fee  int    `json:"fee"`
-		Ar   []struct {
-			Id   int64  `json:"id"`
-			Name string `json:"name"`
-		} `json:"ar"`
-	} `json:"songs"`
+	} `json:"al"`
+}
+
+// toSong 转换为 Song
+func (d SongDetail) toSong() Song {
+	artists := make([]string, len(d.Ar))
+	for i, ar := range d.Ar {
+		artists[i] = ar.Name
+	}
+	return Song{
+		Id:       d.Id,
+		Name:     d.Name,
+		Artists:  artists,
+		Album:    d.Al.Name,
+		Duration: float64(d.Dt) / 1000,
+		Fee:      d.Fee,
+	}
+}
+
+// toSongs 转换为 Song 列表
+func toSongs(details []SongDetail) []Song {
+	songs := make([]Song, len(details))
+	for i, d := range details {
+		songs[i] = d.toSong()
+	}
+	return songs
 }
 
 // Song 表示歌曲的基本信息
 type Song struct {
-	Id      uint     `json:"id"`
-	Name    string   `json:"name"`
-	Artists []string `json:"artists"`
-	Url     string   `json:"url"`
-	Fee     int      `json:"fee"` // 1 表示 VIP 歌曲
+	Id       uint     `json:"id"`
+	Name     string   `json:"name"`
+	Artists  []string `json:"artists"`
+	Album    string   `json:"album,omitempty"`
+	Duration float64  `json:"duration,omitempty"` // 时长（秒）
+	Fee      int      `json:"fee"`                // 1 表示 VIP 歌曲
 }
 
 // Playlist 表示歌单及其歌曲
@@ -71,20 +97,17 @@ type Playlist struct {
 	Songs       []Song `json:"songs"`
 }
 
-// GetPlaylist 根据歌单ID获取歌单信息和歌曲
-func GetPlaylist(playlistId string, page, pageSize int) (*Playlist, error) {
-	// 1. 获取歌单基本信息
-	playlistInfo, err := getPlaylistInfo(playlistId)
+// GetPlaylist 根据歌单ID获取歌单信息和当前页的歌曲。歌单的歌曲ID列表和歌曲详情会缓存，
+// 翻页时不再重复请求；播放地址会过期，不在这里获取，播放时再通过 GetSongUrl 获取
+func (c *Client) GetPlaylist(playlistId string, page, pageSize int) (*Playlist, error) {
+	// 1. 获取歌单的歌曲ID列表
+	info, err := c.playlistTracks(playlistId)
 	if err != nil {
-		return nil, fmt.Errorf("获取歌单信息失败: %w", err)
-	}
-
-	if playlistInfo.Code == 401 {
-		return nil, errors.New("无权限访问此歌单")
+		return nil, err
 	}
 
 	// 2. 计算分页
-	totalSongs := len(playlistInfo.Playlist.TrackIds)
+	totalSongs := len(info.trackIds)
 	start := (page - 1) * pageSize
 	if start >= totalSongs {
 		return nil, fmt.Errorf("页码超出范围")
@@ -95,99 +118,57 @@ func GetPlaylist(playlistId string, page, pageSize int) (*Playlist, error) {
 	}
 
 	// 3. 获取当前页的歌曲详情
-	var allSongs []Song
-	songIds := make([]uint, end-start)
-	for i, track := range playlistInfo.Playlist.TrackIds[start:end] {
-		songIds[i] = track.Id
-	}
-
-	// 4. 分批处理歌曲
-	for i := 0; i < len(songIds); i += batchSize {
-		batchEnd := i + batchSize
-		if batchEnd > len(songIds) {
-			batchEnd = len(songIds)
-		}
-
-		songs, err := getSongsDetail(songIds[i:batchEnd])
-		if err != nil {
-			log.Printf("警告: 获取歌曲 %d-%d 详情失败: %v", i, batchEnd, err)
-			continue
-		}
-		allSongs = append(allSongs, songs...)
-	}
-
-	if len(allSongs) == 0 {
+	songs := c.getSongsDetail(info.trackIds[start:end])
+	if len(songs) == 0 {
 		return nil, fmt.Errorf("未能获取任何歌曲详情")
 	}
 
-	// 5. 控制并发获取音乐URL
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 10) // 限制并发数为5
-	urlChan := make(chan struct {
-		index int
-		url   string
-	}, len(allSongs))
-
-	for i, song := range allSongs {
-		wg.Add(1)
-		go func(i int, id uint) {
-			defer wg.Done()
-			semaphore <- struct{}{}        // 获取信号量
-			defer func() { <-semaphore }() // 释放信号量
+	return &Playlist{
+		Id:          info.id,
+		Name:        info.name,
+		Description: "",
+		Songs:       songs,
+	}, nil
+}
 
-			url := getMusicUrl(fmt.Sprintf("%d", id))
-			if url != "" {
-				urlChan <- struct {
-					index int
-					url   string
-				}{i, url}
-			}
-		}(i, song.Id)
+// playlistTracks 获取歌单的歌曲ID列表，优先使用缓存。歌单详情中附带的歌曲详情一并缓存，
+// 翻页时通常不需要再请求歌曲详情
+func (c *Client) playlistTracks(playlistId string) (cachedPlaylist, error) {
+	cache := c.metadata()
+	if p, ok := cache.getPlaylist(playlistId); ok {
+		return p, nil
 	}
 
-	// 所有goroutine完成后关闭channel
-	go func() {
-		wg.Wait()
-		close(urlChan)
-	}()
-
-	// 收集URL
-	for result := range urlChan {
-		allSongs[result.index].Url = result.url
+	playlistInfo, err := c.getPlaylistInfo(playlistId)
+	if err != nil {
+		return cachedPlaylist{}, fmt.Errorf("获取歌单信息失败: %w", err)
 	}
-
-	// 6. 创建响应
-	playlist := &Playlist{
-		Id:          playlistInfo.Playlist.Id,
-		Name:        playlistInfo.Playlist.Name,
-		Description: "",
-		Songs:       allSongs,
+	if playlistInfo.Code == 401 {
+		return cachedPlaylist{}, errors.New("无权限访问此歌单")
 	}
-
-	return playlist, nil
-}
-
-// getPlaylistInfo 获取歌单基本信息
-func getPlaylistInfo(playlistId string) (*PlaylistResponse, error) {
-	// 创建请求
-	data := strings.NewReader("id=" + playlistId)
-	req, err := http.NewRequest("POST", playlistDetailAPI, data)
-	if err != nil {
-		return nil, err
+	if err := checkCode(playlistInfo.Code); err != nil {
+		return cachedPlaylist{}, fmt.Errorf("获取歌单信息失败: %w", err)
 	}
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	// 发送请求
-	resp, err := insecureClient.Do(req)
-	if err != nil {
-		return nil, err
+	p := cachedPlaylist{
+		id:       playlistInfo.Playlist.Id,
+		name:     playlistInfo.Playlist.Name,
+		trackIds: make([]uint, len(playlistInfo.Playlist.TrackIds)),
 	}
-	defer resp.Body.Close()
+	for i, track := range playlistInfo.Playlist.TrackIds {
+		p.trackIds[i] = track.Id
+	}
+	cache.putSongs(toSongs(playlistInfo.Playlist.Tracks))
+	cache.putPlaylist(playlistId, p)
+	return p, nil
+}
 
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
+// getPlaylistInfo 获取歌单基本信息，n 为附带歌曲详情的数量
+func (c *Client) getPlaylistInfo(playlistId string) (*PlaylistResponse, error) {
+	body, err := c.postForm(playlistDetailPath, url.Values{
+		"id": {playlistId},
+		"n":  {strconv.Itoa(playlistTrackDetails)},
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -201,8 +182,36 @@ func getPlaylistInfo(playlistId string) (*PlaylistResponse, error) {
 	return playlistResp, nil
 }
 
-// getSongsDetail 获取多首歌曲的详细信息
-func getSongsDetail(songIds []uint) ([]Song, error) {
+// getSongsDetail 获取多首歌曲的详细信息，按 songIds 的顺序返回。已缓存的歌曲不再请求，
+// 其余的分批请求，获取失败的歌曲跳过
+func (c *Client) getSongsDetail(songIds []uint) []Song {
+	cache := c.metadata()
+	found, missing := cache.getSongs(songIds)
+
+	for i := 0; i < len(missing); i += batchSize {
+		batchEnd := min(i+batchSize, len(missing))
+		songs, err := c.fetchSongsDetail(missing[i:batchEnd])
+		if err != nil {
+			log.Printf("警告: 获取 %d 首歌曲详情失败: %v", batchEnd-i, err)
+			continue
+		}
+		cache.putSongs(songs)
+		for _, song := range songs {
+			found[song.Id] = song
+		}
+	}
+
+	songs := make([]Song, 0, len(songIds))
+	for _, id := range songIds {
+		if song, ok := found[id]; ok {
+			songs = append(songs, song)
+		}
+	}
+	return songs
+}
+
+// fetchSongsDetail 请求歌曲详情接口
+func (c *Client) fetchSongsDetail(songIds []uint) ([]Song, error) {
 	// 创建歌曲ID对象
 	songIdObjs := make([]map[string]uint, len(songIds))
 	for i, id := range songIds {
@@ -215,25 +224,7 @@ func getSongsDetail(songIds []uint) ([]Song, error) {
 		return nil, err
 	}
 
-	// 创建请求
-	data := strings.NewReader("c=" + string(jsonData))
-	req, err := http.NewRequest("POST", songDetailAPI, data)
-	if err != nil {
-		return nil, err
-	}
-
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	// 发送请求
-	resp, err := insecureClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.postForm(songDetailPath, url.Values{"c": {string(jsonData)}})
 	if err != nil {
 		return nil, err
 	}
@@ -244,44 +235,34 @@ func getSongsDetail(songIds []uint) ([]Song, error) {
 		return nil, err
 	}
 
-	// 转换为Song对象
-	songs := make([]Song, len(songResp.Songs))
-	for i, song := range songResp.Songs {
-		artists := make([]string, len(song.Ar))
-		for j, ar := range song.Ar {
-			artists[j] = ar.Name
-		}
-
-		songs[i] = Song{
-			Id:      song.Id,
-			Name:    song.Name,
-			Artists: artists,
-			Fee:     song.Fee,
-		}
-	}
-
-	return songs, nil
+	return toSongs(songResp.Songs), nil
 }
 
-// getMusicUrl 获取音乐的直接URL
-func getMusicUrl(id string) string {
-	resp, err := insecureClient.Get("https://music.163.com/song/media/outer/url?id=" + id)
+// getMusicUrl 获取音乐的直接URL，跟随跳转后得到的就是播放地址，歌曲不可用时返回空
+func (c *Client) getMusicUrl(id string) (string, error) {
+	req, err := c.newRequest(http.MethodGet, musicUrlPath+"?id="+url.QueryEscape(id), nil)
 	if err != nil {
-		log.Printf("检查歌曲是否可用出错: %v", err)
-		return ""
+		return "", err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.Request.URL.Path == "/404" {
-		return ""
+		return "", nil
 	}
 
-	return resp.Request.URL.String()
+	return resp.Request.URL.String(), nil
 }
 
 // GetSongUrl 获取单个歌曲的URL
-func GetSongUrl(id uint) (string, error) {
-	url := getMusicUrl(fmt.Sprintf("%d", id))
+func (c *Client) GetSongUrl(id uint) (string, error) {
+	url, err := c.getMusicUrl(fmt.Sprintf("%d", id))
+	if err != nil {
+		return "", err
+	}
 	if url == "" {
 		return "", fmt.Errorf("无法获取歌曲播放地址")
 	}
@@ -0,0 +1,206 @@
+package netease
+
+import (
+	"sync"
+	"time"
+)
+
+// 元数据缓存的默认设置
+const (
+	DefaultPlaylistTTL = 10 * time.Minute // 歌单歌曲列表的有效期，歌单可能被修改，不宜太长
+	DefaultSongTTL     = 24 * time.Hour   // 歌曲详情的有效期
+	maxCachedSongs     = 5000             // 最多缓存的歌曲详情数量
+	maxCachedPlaylists = 50               // 最多缓存的歌单数量
+)
+
+// CacheStats 元数据缓存的统计，命中和未命中按歌单和歌曲分别计数
+type CacheStats struct {
+	Playlists      int   `json:"playlists"`
+	Songs          int   `json:"songs"`
+	PlaylistHits   int64 `json:"playlist_hits"`
+	PlaylistMisses int64 `json:"playlist_misses"`
+	SongHits       int64 `json:"song_hits"`
+	SongMisses     int64 `json:"song_misses"`
+	Requests       int64 `json:"requests"` // 发往接口的请求数（不含播放地址跳转）
+}
+
+// cachedPlaylist 缓存的歌单和歌曲ID列表
+type cachedPlaylist struct {
+	id       int64
+	name     string
+	trackIds []uint
+	expires  time.Time
+}
+
+// cachedSong 缓存的歌曲详情
+type cachedSong struct {
+	song    Song
+	expires time.Time
+}
+
+// cacheInitMu 保护直接构造的 Client 创建缓存
+var cacheInitMu sync.Mutex
+
+// metaCache 歌单和歌曲详情的缓存，播放地址会过期，不缓存
+type metaCache struct {
+	mutex       sync.Mutex
+	playlistTTL time.Duration
+	songTTL     time.Duration
+	playlists   map[string]cachedPlaylist
+	songs       map[uint]cachedSong
+	stats       CacheStats
+}
+
+func newMetaCache(playlistTTL, songTTL time.Duration) *metaCache {
+	return &metaCache{
+		playlistTTL: playlistTTL,
+		songTTL:     songTTL,
+		playlists:   make(map[string]cachedPlaylist),
+		songs:       make(map[uint]cachedSong),
+	}
+}
+
+// metadata 返回客户端的元数据缓存，直接构造的 Client 在第一次使用时创建
+func (c *Client) metadata() *metaCache {
+	cacheInitMu.Lock()
+	defer cacheInitMu.Unlock()
+	if c.cache == nil {
+		c.cache = newMetaCache(DefaultPlaylistTTL, DefaultSongTTL)
+	}
+	return c.cache
+}
+
+// getPlaylist 返回未过期的歌单
+func (m *metaCache) getPlaylist(id string) (cachedPlaylist, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	p, ok := m.playlists[id]
+	if ok && time.Now().Before(p.expires) {
+		m.stats.PlaylistHits++
+		return p, true
+	}
+	m.stats.PlaylistMisses++
+	return cachedPlaylist{}, false
+}
+
+// putPlaylist 缓存歌单，超出数量时先清理过期的，仍然超出时清空
+func (m *metaCache) putPlaylist(key string, p cachedPlaylist) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.playlistTTL <= 0 {
+		return
+	}
+	now := time.Now()
+	if len(m.playlists) >= maxCachedPlaylists {
+		for k, v := range m.playlists {
+			if !now.Before(v.expires) {
+				delete(m.playlists, k)
+			}
+		}
+		if len(m.playlists) >= maxCachedPlaylists {
+			m.playlists = make(map[string]cachedPlaylist)
+		}
+	}
+	p.expires = now.Add(m.playlistTTL)
+	m.playlists[key] = p
+}
+
+// getSongs 返回已缓存的歌曲，没有缓存或已过期的歌曲ID按顺序放在 missing 中
+func (m *metaCache) getSongs(ids []uint) (found map[uint]Song, missing []uint) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	found = make(map[uint]Song, len(ids))
+	for _, id := range ids {
+		if s, ok := m.songs[id]; ok && now.Before(s.expires) {
+			found[id] = s.song
+			m.stats.SongHits++
+		} else {
+			missing = append(missing, id)
+			m.stats.SongMisses++
+		}
+	}
+	return found, missing
+}
+
+// putSongs 缓存歌曲详情，超出数量时先清理过期的，仍然超出时清空
+func (m *metaCache) putSongs(songs []Song) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.songTTL <= 0 {
+		return
+	}
+	now := time.Now()
+	if len(m.songs)+len(songs) > maxCachedSongs {
+		for k, v := range m.songs {
+			if !now.Before(v.expires) {
+				delete(m.songs, k)
+			}
+		}
+		if len(m.songs)+len(songs) > maxCachedSongs {
+			m.songs = make(map[uint]cachedSong)
+		}
+	}
+	expires := now.Add(m.songTTL)
+	for _, s := range songs {
+		m.songs[s.Id] = cachedSong{song: s, expires: expires}
+	}
+}
+
+// countRequest 记录一次发往接口的请求
+func (m *metaCache) countRequest() {
+	m.mutex.Lock()
+	m.stats.Requests++
+	m.mutex.Unlock()
+}
+
+// snapshot 返回当前的统计
+func (m *metaCache) snapshot() CacheStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	stats := m.stats
+	stats.Playlists = len(m.playlists)
+	stats.Songs = len(m.songs)
+	return stats
+}
+
+// clear 清空缓存，统计保留
+func (m *metaCache) clear() {
+	m.mutex.Lock()
+	m.playlists = make(map[string]cachedPlaylist)
+	m.songs = make(map[uint]cachedSong)
+	m.mutex.Unlock()
+}
+
+// SetCacheTTL 设置歌单和歌曲详情的缓存有效期，为 0 时不缓存
+func (c *Client) SetCacheTTL(playlistTTL, songTTL time.Duration) {
+	m := c.metadata()
+	m.mutex.Lock()
+	m.playlistTTL = playlistTTL
+	m.songTTL = songTTL
+	m.mutex.Unlock()
+}
+
+// CacheStats 返回元数据缓存的统计
+func (c *Client) CacheStats() CacheStats {
+	return c.metadata().snapshot()
+}
+
+// ClearCache 清空歌单和歌曲详情的缓存
+func (c *Client) ClearCache() {
+	c.metadata().clear()
+}
+
+// GetCacheStats 返回默认客户端的元数据缓存统计
+func GetCacheStats() CacheStats {
+	return DefaultClient().CacheStats()
+}
+
+// ClearCache 清空默认客户端的元数据缓存
+func ClearCache() {
+	DefaultClient().ClearCache()
+}
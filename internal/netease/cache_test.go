@@ -0,0 +1,94 @@
+package netease_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"aku-web/internal/netease/neteasetest"
+)
+
+// countRequests 统计发往指定路径的请求数
+func countRequests(server *neteasetest.Server, path string) int {
+	n := 0
+	for _, request := range server.Requests() {
+		if strings.HasPrefix(request, "POST "+path) || strings.HasPrefix(request, "GET "+path) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPlaylistCache(t *testing.T) {
+	server := newTestServer(t)
+	client := server.NeteaseClient()
+
+	for page := 1; page <= 3; page++ {
+		if _, err := client.GetPlaylist("100", page, 2); err != nil {
+			t.Fatalf("GetPlaylist 第 %d 页: %v", page, err)
+		}
+	}
+
+	// 歌单详情已附带歌曲详情，翻页不再请求接口
+	if n := countRequests(server, "/api/v6/playlist/detail"); n != 1 {
+		t.Errorf("歌单详情请求了 %d 次, 期望 1 次", n)
+	}
+	if n := countRequests(server, "/api/v3/song/detail"); n != 0 {
+		t.Errorf("歌曲详情请求了 %d 次, 期望 0 次", n)
+	}
+	stats := client.CacheStats()
+	if stats.PlaylistHits != 2 || stats.PlaylistMisses != 1 || stats.Requests != 1 {
+		t.Errorf("统计 = %+v, 期望歌单命中 2 次、未命中 1 次、请求 1 次", stats)
+	}
+
+	// 清空缓存后重新请求
+	client.ClearCache()
+	if _, err := client.GetPlaylist("100", 1, 2); err != nil {
+		t.Fatalf("GetPlaylist: %v", err)
+	}
+	if n := countRequests(server, "/api/v6/playlist/detail"); n != 2 {
+		t.Errorf("清空缓存后歌单详情请求了 %d 次, 期望 2 次", n)
+	}
+
+	// 有效期为 0 时不缓存
+	client.SetCacheTTL(0, 0)
+	client.ClearCache()
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetPlaylist("100", 1, 2); err != nil {
+			t.Fatalf("GetPlaylist: %v", err)
+		}
+	}
+	if n := countRequests(server, "/api/v6/playlist/detail"); n != 4 {
+		t.Errorf("不缓存时歌单详情请求了 %d 次, 期望 4 次", n)
+	}
+}
+
+func TestLargePlaylistCache(t *testing.T) {
+	server := neteasetest.NewServer()
+	t.Cleanup(server.Close)
+
+	// 超过歌单详情附带的 1000 首，后面的歌曲需要单独请求详情
+	trackIds := make([]uint, 1020)
+	for i := range trackIds {
+		trackIds[i] = uint(i + 1)
+		server.AddSong(neteasetest.Song{Id: trackIds[i], Name: fmt.Sprintf("歌曲%d", i+1)})
+	}
+	server.AddPlaylist(neteasetest.Playlist{Id: 200, Name: "大歌单", TrackIds: trackIds})
+	client := server.NeteaseClient()
+
+	for i := 0; i < 2; i++ {
+		playlist, err := client.GetPlaylist("200", 21, 50)
+		if err != nil {
+			t.Fatalf("GetPlaylist: %v", err)
+		}
+		if len(playlist.Songs) != 20 || playlist.Songs[0].Name != "歌曲1001" {
+			t.Fatalf("第 21 页有 %d 首歌曲, 期望 20 首且从 歌曲1001 开始", len(playlist.Songs))
+		}
+	}
+	if n := countRequests(server, "/api/v6/playlist/detail"); n != 1 {
+		t.Errorf("歌单详情请求了 %d 次, 期望 1 次", n)
+	}
+	if n := countRequests(server, "/api/v3/song/detail"); n != 1 {
+		t.Errorf("歌曲详情请求了 %d 次, 期望 1 次", n)
+	}
+}
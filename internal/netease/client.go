@@ -0,0 +1,129 @@
+package netease
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 客户端默认设置
+const (
+	DefaultBaseURL   = "https://music.163.com"
+	DefaultTimeout   = 15 * time.Second
+	DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+)
+
+// Client 网易云音乐接口客户端
+type Client struct {
+	BaseURL    string       // 接口地址，测试时可以指向本地的模拟服务器
+	HTTPClient *http.Client // 发送请求使用的客户端，超时时间在这里设置
+	UserAgent  string
+
+	cache *metaCache // 歌单和歌曲详情的缓存
+}
+
+// NewClient 创建客户端，baseURL 为空时使用 DefaultBaseURL，timeout 为 0 时使用 DefaultTimeout。
+// 使用系统证书库验证服务器证书，需要额外的根证书时调用 LoadCABundle；
+// 歌单和歌曲详情按 DefaultPlaylistTTL、DefaultSongTTL 缓存，可以通过 SetCacheTTL 修改
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSClientConfig:     &tls.Config{MinVersion: tls.VersionTLS12},
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+		},
+		UserAgent: DefaultUserAgent,
+		cache:     newMetaCache(DefaultPlaylistTTL, DefaultSongTTL),
+	}
+}
+
+// 包级函数使用的默认客户端
+var (
+	defaultClient   = NewClient(DefaultBaseURL, DefaultTimeout)
+	defaultClientMu sync.RWMutex
+)
+
+// SetDefaultClient 替换包级函数使用的客户端，例如改为指向模拟服务器
+func SetDefaultClient(c *Client) {
+	defaultClientMu.Lock()
+	defaultClient = c
+	defaultClientMu.Unlock()
+}
+
+// DefaultClient 返回包级函数使用的客户端
+func DefaultClient() *Client {
+	defaultClientMu.RLock()
+	defer defaultClientMu.RUnlock()
+	return defaultClient
+}
+
+// GetPlaylist 使用默认客户端获取歌单信息和歌曲
+func GetPlaylist(playlistId string, page, pageSize int) (*Playlist, error) {
+	return DefaultClient().GetPlaylist(playlistId, page, pageSize)
+}
+
+// GetSongUrl 使用默认客户端获取单个歌曲的URL
+func GetSongUrl(id uint) (string, error) {
+	return DefaultClient().GetSongUrl(id)
+}
+
+// newRequest 创建发往接口地址的请求，path 以 / 开头
+func (c *Client) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	return req, nil
+}
+
+// get 发送 GET 请求，返回响应内容
+func (c *Client) get(path string) ([]byte, error) {
+	req, err := c.newRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(req)
+}
+
+// postForm 以表单形式发送 POST 请求，返回响应内容
+func (c *Client) postForm(path string, form url.Values) ([]byte, error) {
+	req, err := c.newRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.send(req)
+}
+
+// send 发送请求，返回状态码为 200 的响应内容
+func (c *Client) send(req *http.Request) ([]byte, error) {
+	c.metadata().countRequest()
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("服务器返回 %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,54 @@
+package netease
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// lyricPath 歌词接口的路径，lv 和 tv 为 -1 表示同时获取原文和翻译
+const lyricPath = "/api/song/lyric"
+
+// Lyric 歌曲的 LRC 歌词
+type Lyric struct {
+	SongId       uint   `json:"song_id"`
+	Lrc          string `json:"lrc,omitempty"`          // 原文歌词
+	Translation  string `json:"translation,omitempty"`  // 翻译歌词，没有翻译时为空
+	Instrumental bool   `json:"instrumental,omitempty"` // 纯音乐，没有歌词
+}
+
+// GetLyric 获取歌曲的歌词和翻译
+func GetLyric(id uint) (*Lyric, error) {
+	return DefaultClient().GetLyric(id)
+}
+
+// GetLyric 获取歌曲的歌词和翻译，没有收录歌词时 Lrc 为空
+func (c *Client) GetLyric(id uint) (*Lyric, error) {
+	body, err := c.get(fmt.Sprintf("%s?id=%d&lv=-1&tv=-1", lyricPath, id))
+	if err != nil {
+		return nil, fmt.Errorf("获取歌词失败: %w", err)
+	}
+
+	var resp struct {
+		Code    int  `json:"code"`
+		NoLyric bool `json:"nolyric"`
+		Lrc     struct {
+			Lyric string `json:"lyric"`
+		} `json:"lrc"`
+		Tlyric struct {
+			Lyric string `json:"lyric"`
+		} `json:"tlyric"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析歌词失败: %v", err)
+	}
+	if err := checkCode(resp.Code); err != nil {
+		return nil, err
+	}
+
+	return &Lyric{
+		SongId:       id,
+		Lrc:          resp.Lrc.Lyric,
+		Translation:  resp.Tlyric.Lyric,
+		Instrumental: resp.NoLyric,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package netease_test
+
+import (
+	"strings"
+	"testing"
+
+	"aku-web/internal/netease/neteasetest"
+)
+
+// newTestServer 启动带有 5 首歌曲和一个歌单的模拟服务器，第 5 首歌曲没有音频，不可播放
+func newTestServer(t *testing.T) *neteasetest.Server {
+	t.Helper()
+	server := neteasetest.NewServer()
+	t.Cleanup(server.Close)
+
+	names := []string{"晴天", "七里香", "稻香", "夜曲", "青花瓷"}
+	trackIds := make([]uint, len(names))
+	for i, name := range names {
+		song := neteasetest.Song{Id: uint(i + 1), Name: name, Artists: []string{"周杰伦"}, Duration: 240000}
+		if i < len(names)-1 {
+			song.Audio = []byte("ID3 fake audio")
+		}
+		server.AddSong(song)
+		trackIds[i] = song.Id
+	}
+	server.AddPlaylist(neteasetest.Playlist{Id: 100, Name: "测试歌单", TrackIds: trackIds})
+	server.AddPlaylist(neteasetest.Playlist{Id: 101, Name: "私密歌单", Code: 401})
+	return server
+}
+
+func TestGetPlaylist(t *testing.T) {
+	server := newTestServer(t)
+	client := server.NeteaseClient()
+
+	var got []string
+	for page := 1; page <= 3; page++ {
+		playlist, err := client.GetPlaylist("100", page, 2)
+		if err != nil {
+			t.Fatalf("GetPlaylist 第 %d 页: %v", page, err)
+		}
+		if playlist.Id != 100 || playlist.Name != "测试歌单" {
+			t.Errorf("歌单 = %d %q, 期望 100 \"测试歌单\"", playlist.Id, playlist.Name)
+		}
+		for _, song := range playlist.Songs {
+			got = append(got, song.Name)
+			if len(song.Artists) != 1 || song.Artists[0] != "周杰伦" {
+				t.Errorf("歌曲 %d 的艺术家 = %v", song.Id, song.Artists)
+			}
+			if song.Duration != 240 {
+				t.Errorf("歌曲 %d 的时长 = %v, 期望 240", song.Id, song.Duration)
+			}
+		}
+	}
+	if want := "晴天,七里香,稻香,夜曲,青花瓷"; strings.Join(got, ",") != want {
+		t.Errorf("歌曲 = %s, 期望 %s", strings.Join(got, ","), want)
+	}
+
+	if _, err := client.GetPlaylist("100", 4, 2); err == nil {
+		t.Error("页码超出范围时没有返回错误")
+	}
+	if _, err := client.GetPlaylist("101", 1, 2); err == nil {
+		t.Error("无权限的歌单没有返回错误")
+	}
+	if _, err := client.GetPlaylist("404", 1, 2); err == nil {
+		t.Error("不存在的歌单没有返回错误")
+	}
+}
+
+func TestGetSongUrl(t *testing.T) {
+	server := newTestServer(t)
+	client := server.NeteaseClient()
+
+	url, err := client.GetSongUrl(1)
+	if err != nil {
+		t.Fatalf("GetSongUrl: %v", err)
+	}
+	if want := server.MediaURL(1); url != want {
+		t.Errorf("播放地址 = %s, 期望 %s", url, want)
+	}
+
+	if url, err := client.GetSongUrl(5); err == nil {
+		t.Errorf("不可播放的歌曲返回了地址 %s", url)
+	}
+	if url, err := client.GetSongUrl(999); err == nil {
+		t.Errorf("不存在的歌曲返回了地址 %s", url)
+	}
+}
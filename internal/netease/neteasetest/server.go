@@ -0,0 +1,495 @@
+// Package neteasetest 提供模拟网易云音乐接口的本地服务器，用于在没有网络时测试 netease 包和 api 中的网易云接口。
+//
+// 服务器根据添加的歌单和歌曲生成歌单详情、歌曲详情和播放地址跳转的响应，
+// 也可以用 AddResponse 回放从真实接口录下的响应：
+//
+//	server := neteasetest.NewServer()
+//	defer server.Close()
+//	server.AddSong(neteasetest.Song{Id: 1, Name: "晴天", Artists: []string{"周杰伦"}, Audio: mp3Data})
+//	server.AddPlaylist(neteasetest.Playlist{Id: 100, Name: "测试歌单", TrackIds: []uint{1}})
+//	netease.SetDefaultClient(server.NeteaseClient())
+package neteasetest
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aku-web/internal/netease"
+)
+
+// 模拟的接口路径，与 netease 包请求的路径一致
+const (
+	playlistDetailPath = "/api/v6/playlist/detail"
+	songDetailPath     = "/api/v3/song/detail"
+	musicUrlPath       = "/song/media/outer/url"
+	searchPath         = "/api/cloudsearch/pc"
+	albumPathPrefix    = "/api/v1/album/"
+	artistTopSongPath  = "/api/artist/top/song"
+	lyricPath          = "/api/song/lyric"
+	mediaPathPrefix    = "/media/"
+	notFoundPath       = "/404"
+)
+
+// Song 模拟的歌曲
+type Song struct {
+	Id       uint
+	Name     string
+	Artists  []string
+	Album    string
+	Duration int    // 时长（毫秒）
+	Fee      int    // 1 表示 VIP 歌曲
+	Audio    []byte // 音频内容，为空时播放地址跳转到 /404，表示歌曲不可用
+	Lyric    string // LRC 歌词，为空时返回未收录歌词
+	TLyric   string // 翻译歌词
+}
+
+// Album 模拟的专辑
+type Album struct {
+	Id      int64
+	Name    string
+	Artist  string
+	SongIds []uint
+}
+
+// Artist 模拟的艺术家
+type Artist struct {
+	Id         int64
+	Name       string
+	TopSongIds []uint
+}
+
+// Playlist 模拟的歌单
+type Playlist struct {
+	Id       int64
+	Name     string
+	TrackIds []uint
+	Code     int // 接口返回的 code，为 0 时返回 200，401 表示无权限访问
+}
+
+// Response 录下的响应，跳转的响应在 Header 中设置 Location
+type Response struct {
+	Status int // 为 0 时返回 200
+	Header http.Header
+	Body   []byte
+}
+
+// Server 模拟的网易云音乐服务器
+type Server struct {
+	*httptest.Server
+
+	playlists map[string]Playlist
+	songs     map[uint]Song
+	albums    map[int64]Album
+	artists   map[int64]Artist
+	responses map[string]Response // 按路径回放的响应，优先于生成的响应
+	requests  []string            // 收到的请求，格式为 "方法 路径?参数"
+	mutex     sync.Mutex
+}
+
+// NewServer 启动模拟服务器，使用完后需要调用 Close
+func NewServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// NewTLSServer 启动使用 HTTPS 的模拟服务器，证书由 httptest 自行签发，
+// 只有 NeteaseClient 返回的客户端信任它，可以用来测试证书验证
+func NewTLSServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func newServer() *Server {
+	return &Server{
+		playlists: make(map[string]Playlist),
+		songs:     make(map[uint]Song),
+		albums:    make(map[int64]Album),
+		artists:   make(map[int64]Artist),
+		responses: make(map[string]Response),
+	}
+}
+
+// NeteaseClient 返回指向模拟服务器的客户端，HTTPS 服务器的证书会被信任
+func (s *Server) NeteaseClient() *netease.Client {
+	client := netease.NewClient(s.URL, 5*time.Second)
+	client.HTTPClient.Transport = s.Client().Transport
+	return client
+}
+
+// AddSong 添加或替换歌曲
+func (s *Server) AddSong(song Song) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.songs[song.Id] = song
+}
+
+// AddPlaylist 添加或替换歌单
+func (s *Server) AddPlaylist(playlist Playlist) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.playlists[strconv.FormatInt(playlist.Id, 10)] = playlist
+}
+
+// AddAlbum 添加或替换专辑
+func (s *Server) AddAlbum(album Album) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.albums[album.Id] = album
+}
+
+// AddArtist 添加或替换艺术家
+func (s *Server) AddArtist(artist Artist) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.artists[artist.Id] = artist
+}
+
+// AddResponse 让指定路径（不含参数）返回录下的响应
+func (s *Server) AddResponse(path string, response Response) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.responses[path] = response
+}
+
+// Requests 返回收到的请求
+func (s *Server) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// MediaURL 返回歌曲在模拟服务器上的播放地址
+func (s *Server) MediaURL(id uint) string {
+	return fmt.Sprintf("%s%s%d.mp3", s.URL, mediaPathPrefix, id)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	s.mutex.Lock()
+	request := r.Method + " " + r.URL.Path
+	if encoded := r.Form.Encode(); encoded != "" {
+		request += "?" + encoded
+	}
+	s.requests = append(s.requests, request)
+	recorded, ok := s.responses[r.URL.Path]
+	s.mutex.Unlock()
+
+	if ok {
+		for key, values := range recorded.Header {
+			w.Header()[key] = values
+		}
+		status := recorded.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+		w.Write(recorded.Body)
+		return
+	}
+
+	switch {
+	case r.URL.Path == playlistDetailPath:
+		s.handlePlaylistDetail(w, r)
+	case r.URL.Path == songDetailPath:
+		s.handleSongDetail(w, r)
+	case r.URL.Path == musicUrlPath:
+		s.handleMusicUrl(w, r)
+	case r.URL.Path == searchPath:
+		s.handleSearch(w, r)
+	case strings.HasPrefix(r.URL.Path, albumPathPrefix):
+		s.handleAlbum(w, r)
+	case r.URL.Path == artistTopSongPath:
+		s.handleArtistTopSongs(w, r)
+	case r.URL.Path == lyricPath:
+		s.handleLyric(w, r)
+	case strings.HasPrefix(r.URL.Path, mediaPathPrefix):
+		s.handleMedia(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handlePlaylistDetail 模拟歌单详情，参数 id 为歌单ID，n 为附带详情的歌曲数量
+func (s *Server) handlePlaylistDetail(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	playlist, ok := s.playlists[r.Form.Get("id")]
+
+	if !ok {
+		writeJSON(w, map[string]interface{}{"code": 404, "msg": "歌单不存在"})
+		return
+	}
+	if playlist.Code != 0 && playlist.Code != 200 {
+		writeJSON(w, map[string]interface{}{"code": playlist.Code})
+		return
+	}
+
+	trackIds := make([]map[string]uint, len(playlist.TrackIds))
+	for i, id := range playlist.TrackIds {
+		trackIds[i] = map[string]uint{"id": id}
+	}
+	n, _ := strconv.Atoi(r.Form.Get("n"))
+	n = max(0, min(n, len(playlist.TrackIds)))
+	writeJSON(w, map[string]interface{}{
+		"code": 200,
+		"playlist": map[string]interface{}{
+			"id":         playlist.Id,
+			"name":       playlist.Name,
+			"trackCount": len(playlist.TrackIds),
+			"trackIds":   trackIds,
+			"tracks":     s.songDetailsLocked(playlist.TrackIds[:n]),
+		},
+	})
+}
+
+// handleSongDetail 模拟歌曲详情，参数 c 为 [{"id":1},...]，不存在的歌曲不返回
+func (s *Server) handleSongDetail(w http.ResponseWriter, r *http.Request) {
+	var ids []struct {
+		Id uint `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(r.Form.Get("c")), &ids); err != nil {
+		writeJSON(w, map[string]interface{}{"code": 400, "msg": "参数错误"})
+		return
+	}
+
+	songIds := make([]uint, len(ids))
+	for i, id := range ids {
+		songIds[i] = id.Id
+	}
+	s.mutex.Lock()
+	songs := s.songDetailsLocked(songIds)
+	s.mutex.Unlock()
+
+	writeJSON(w, map[string]interface{}{"code": 200, "songs": songs})
+}
+
+// handleSearch 模拟搜索，按名称（歌曲还包括艺术家）包含关键字匹配，type 为 1 歌曲、10 专辑、100 艺术家、1000 歌单
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	keyword := strings.ToLower(r.Form.Get("s"))
+	offset, _ := strconv.Atoi(r.Form.Get("offset"))
+	limit, _ := strconv.Atoi(r.Form.Get("limit"))
+	if limit <= 0 {
+		limit = 30
+	}
+	matches := func(names ...string) bool {
+		for _, name := range names {
+			if strings.Contains(strings.ToLower(name), keyword) {
+				return true
+			}
+		}
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var items []interface{}
+	result := map[string]interface{}{}
+	switch r.Form.Get("type") {
+	case "10":
+		for _, id := range sortedKeys(s.albums) {
+			if album := s.albums[id]; matches(album.Name, album.Artist) {
+				items = append(items, albumJSON(album))
+			}
+		}
+		result["albumCount"] = len(items)
+		result["albums"] = page(items, offset, limit)
+	case "100":
+		for _, id := range sortedKeys(s.artists) {
+			if artist := s.artists[id]; matches(artist.Name) {
+				items = append(items, map[string]interface{}{"id": artist.Id, "name": artist.Name})
+			}
+		}
+		result["artistCount"] = len(items)
+		result["artists"] = page(items, offset, limit)
+	case "1000":
+		for _, key := range sortedKeys(s.playlists) {
+			if playlist := s.playlists[key]; matches(playlist.Name) {
+				items = append(items, map[string]interface{}{
+					"id":         playlist.Id,
+					"name":       playlist.Name,
+					"trackCount": len(playlist.TrackIds),
+				})
+			}
+		}
+		result["playlistCount"] = len(items)
+		result["playlists"] = page(items, offset, limit)
+	default:
+		var ids []uint
+		for _, id := range sortedKeys(s.songs) {
+			if song := s.songs[id]; matches(append([]string{song.Name}, song.Artists...)...) {
+				ids = append(ids, id)
+			}
+		}
+		for _, song := range s.songDetailsLocked(ids) {
+			items = append(items, song)
+		}
+		result["songCount"] = len(items)
+		result["songs"] = page(items, offset, limit)
+	}
+
+	writeJSON(w, map[string]interface{}{"code": 200, "result": result})
+}
+
+// handleAlbum 模拟专辑详情，路径为 /api/v1/album/<专辑ID>
+func (s *Server) handleAlbum(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, albumPathPrefix), 10, 64)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	album, ok := s.albums[id]
+	if !ok {
+		writeJSON(w, map[string]interface{}{"code": 404, "msg": "专辑不存在"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"code":  200,
+		"album": albumJSON(album),
+		"songs": s.songDetailsLocked(album.SongIds),
+	})
+}
+
+// handleArtistTopSongs 模拟艺术家热门歌曲，参数 id 为艺术家ID
+func (s *Server) handleArtistTopSongs(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	artist, ok := s.artists[id]
+	if !ok {
+		writeJSON(w, map[string]interface{}{"code": 404, "msg": "艺术家不存在"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{"code": 200, "songs": s.songDetailsLocked(artist.TopSongIds)})
+}
+
+// handleLyric 模拟歌词，参数 id 为歌曲ID
+func (s *Server) handleLyric(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(r.Form.Get("id"), 10, 64)
+	s.mutex.Lock()
+	song, ok := s.songs[uint(id)]
+	s.mutex.Unlock()
+
+	if !ok {
+		writeJSON(w, map[string]interface{}{"code": 404, "msg": "歌曲不存在"})
+		return
+	}
+	if song.Lyric == "" {
+		writeJSON(w, map[string]interface{}{"code": 200, "uncollected": true})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"code":   200,
+		"lrc":    map[string]interface{}{"version": 1, "lyric": song.Lyric},
+		"tlyric": map[string]interface{}{"version": 1, "lyric": song.TLyric},
+	})
+}
+
+// songDetailsLocked 按歌曲详情接口的格式返回歌曲，不存在的歌曲跳过，调用方需持有锁
+func (s *Server) songDetailsLocked(ids []uint) []map[string]interface{} {
+	songs := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		song, ok := s.songs[id]
+		if !ok {
+			continue
+		}
+		artists := make([]map[string]interface{}, len(song.Artists))
+		for i, name := range song.Artists {
+			artists[i] = map[string]interface{}{"id": i + 1, "name": name}
+		}
+		songs = append(songs, map[string]interface{}{
+			"id":   song.Id,
+			"name": song.Name,
+			"fee":  song.Fee,
+			"dt":   song.Duration,
+			"ar":   artists,
+			"al":   map[string]interface{}{"name": song.Album},
+		})
+	}
+	return songs
+}
+
+// albumJSON 按专辑接口的格式返回专辑
+func albumJSON(album Album) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     album.Id,
+		"name":   album.Name,
+		"size":   len(album.SongIds),
+		"artist": map[string]interface{}{"name": album.Artist},
+	}
+}
+
+// page 返回 offset 开始的最多 limit 项
+func page(items []interface{}, offset, limit int) []interface{} {
+	if offset > len(items) {
+		offset = len(items)
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+// handleMusicUrl 模拟播放地址跳转，和真实接口一样不可用时跳转到 /404
+func (s *Server) handleMusicUrl(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseUint(r.Form.Get("id"), 10, 64)
+	s.mutex.Lock()
+	song, ok := s.songs[uint(id)]
+	s.mutex.Unlock()
+
+	if !ok || len(song.Audio) == 0 {
+		http.Redirect(w, r, notFoundPath, http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("%s%d.mp3", mediaPathPrefix, song.Id), http.StatusFound)
+}
+
+// handleMedia 返回歌曲的音频内容，支持 Range 请求
+func (s *Server) handleMedia(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, mediaPathPrefix)
+	id, err := strconv.ParseUint(strings.TrimSuffix(name, ".mp3"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.mutex.Lock()
+	song, ok := s.songs[uint(id)]
+	s.mutex.Unlock()
+
+	if !ok || len(song.Audio) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "audio/mpeg")
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(song.Audio))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// sortedKeys 按顺序返回 map 的键，使结果稳定
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,287 @@
+package netease
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 搜索和浏览接口的路径
+const (
+	searchPath        = "/api/cloudsearch/pc"
+	albumPathPrefix   = "/api/v1/album/" // 后接专辑ID
+	artistTopSongPath = "/api/artist/top/song"
+	maxSearchLimit    = 100 // 每次搜索最多返回的数量
+)
+
+// SearchType 搜索的类型
+type SearchType string
+
+const (
+	SearchSong     SearchType = "song"
+	SearchAlbum    SearchType = "album"
+	SearchArtist   SearchType = "artist"
+	SearchPlaylist SearchType = "playlist"
+)
+
+// code 返回接口使用的类型编号
+func (t SearchType) code() int {
+	switch t {
+	case SearchAlbum:
+		return 10
+	case SearchArtist:
+		return 100
+	case SearchPlaylist:
+		return 1000
+	default:
+		return 1
+	}
+}
+
+// ParseSearchType 解析搜索类型，为空时搜索歌曲
+func ParseSearchType(name string) (SearchType, error) {
+	switch t := SearchType(strings.ToLower(name)); t {
+	case "":
+		return SearchSong, nil
+	case SearchSong, SearchAlbum, SearchArtist, SearchPlaylist:
+		return t, nil
+	}
+	return "", fmt.Errorf("不支持的搜索类型: %s", name)
+}
+
+// Album 专辑
+type Album struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	Artist      string `json:"artist"`
+	PicUrl      string `json:"pic_url,omitempty"`
+	PublishTime int64  `json:"publish_time,omitempty"` // 发行时间（毫秒时间戳）
+	Size        int    `json:"size"`                   // 歌曲数量
+	Songs       []Song `json:"songs,omitempty"`        // 只有专辑详情包含歌曲
+}
+
+// Artist 艺术家
+type Artist struct {
+	Id        int64  `json:"id"`
+	Name      string `json:"name"`
+	PicUrl    string `json:"pic_url,omitempty"`
+	AlbumSize int    `json:"album_size,omitempty"`
+}
+
+// PlaylistSummary 搜索到的歌单，歌曲需要再通过 GetPlaylist 获取
+type PlaylistSummary struct {
+	Id         int64  `json:"id"`
+	Name       string `json:"name"`
+	CoverUrl   string `json:"cover_url,omitempty"`
+	TrackCount int    `json:"track_count"`
+	PlayCount  int64  `json:"play_count"`
+	Creator    string `json:"creator,omitempty"`
+}
+
+// SearchResult 搜索结果，按类型只填充对应的列表
+type SearchResult struct {
+	Type      SearchType        `json:"type"`
+	Total     int               `json:"total"`
+	Songs     []Song            `json:"songs,omitempty"`
+	Albums    []Album           `json:"albums,omitempty"`
+	Artists   []Artist          `json:"artists,omitempty"`
+	Playlists []PlaylistSummary `json:"playlists,omitempty"`
+}
+
+// albumDetail 专辑接口的专辑结构
+type albumDetail struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	PicUrl      string `json:"picUrl"`
+	PublishTime int64  `json:"publishTime"`
+	Size        int    `json:"size"`
+	Artist      struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+}
+
+// toAlbum 转换为 Album
+func (d albumDetail) toAlbum() Album {
+	return Album{
+		Id:          d.Id,
+		Name:        d.Name,
+		Artist:      d.Artist.Name,
+		PicUrl:      d.PicUrl,
+		PublishTime: d.PublishTime,
+		Size:        d.Size,
+	}
+}
+
+// searchResponse 搜索接口的响应结构
+type searchResponse struct {
+	Code   int `json:"code"`
+	Result struct {
+		SongCount     int           `json:"songCount"`
+		AlbumCount    int           `json:"albumCount"`
+		ArtistCount   int           `json:"artistCount"`
+		PlaylistCount int           `json:"playlistCount"`
+		Songs         []SongDetail  `json:"songs"`
+		Albums        []albumDetail `json:"albums"`
+		Artists       []struct {
+			Id        int64  `json:"id"`
+			Name      string `json:"name"`
+			PicUrl    string `json:"picUrl"`
+			AlbumSize int    `json:"albumSize"`
+		} `json:"artists"`
+		Playlists []struct {
+			Id          int64  `json:"id"`
+			Name        string `json:"name"`
+			CoverImgUrl string `json:"coverImgUrl"`
+			TrackCount  int    `json:"trackCount"`
+			PlayCount   int64  `json:"playCount"`
+			Creator     struct {
+				Nickname string `json:"nickname"`
+			} `json:"creator"`
+		} `json:"playlists"`
+	} `json:"result"`
+}
+
+// Search 按关键字搜索歌曲、专辑、艺术家或歌单，offset 和 limit 用于分页
+func Search(keyword string, kind SearchType, offset, limit int) (*SearchResult, error) {
+	return DefaultClient().Search(keyword, kind, offset, limit)
+}
+
+// GetAlbum 获取专辑信息和歌曲
+func GetAlbum(id int64) (*Album, error) {
+	return DefaultClient().GetAlbum(id)
+}
+
+// GetArtistTopSongs 获取艺术家的热门歌曲
+func GetArtistTopSongs(id int64) ([]Song, error) {
+	return DefaultClient().GetArtistTopSongs(id)
+}
+
+// Search 按关键字搜索
+func (c *Client) Search(keyword string, kind SearchType, offset, limit int) (*SearchResult, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, errors.New("搜索关键字不能为空")
+	}
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	body, err := c.postForm(searchPath, url.Values{
+		"s":      {keyword},
+		"type":   {strconv.Itoa(kind.code())},
+		"offset": {strconv.Itoa(offset)},
+		"limit":  {strconv.Itoa(limit)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("搜索失败: %w", err)
+	}
+
+	var resp searchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析搜索结果失败: %v", err)
+	}
+	if err := checkCode(resp.Code); err != nil {
+		return nil, err
+	}
+
+	result := &SearchResult{Type: kind}
+	switch kind {
+	case SearchAlbum:
+		result.Total = resp.Result.AlbumCount
+		result.Albums = make([]Album, 0, len(resp.Result.Albums))
+		for _, a := range resp.Result.Albums {
+			result.Albums = append(result.Albums, a.toAlbum())
+		}
+	case SearchArtist:
+		result.Total = resp.Result.ArtistCount
+		result.Artists = make([]Artist, 0, len(resp.Result.Artists))
+		for _, a := range resp.Result.Artists {
+			result.Artists = append(result.Artists, Artist{
+				Id:        a.Id,
+				Name:      a.Name,
+				PicUrl:    a.PicUrl,
+				AlbumSize: a.AlbumSize,
+			})
+		}
+	case SearchPlaylist:
+		result.Total = resp.Result.PlaylistCount
+		result.Playlists = make([]PlaylistSummary, 0, len(resp.Result.Playlists))
+		for _, p := range resp.Result.Playlists {
+			result.Playlists = append(result.Playlists, PlaylistSummary{
+				Id:         p.Id,
+				Name:       p.Name,
+				CoverUrl:   p.CoverImgUrl,
+				TrackCount: p.TrackCount,
+				PlayCount:  p.PlayCount,
+				Creator:    p.Creator.Nickname,
+			})
+		}
+	default:
+		result.Total = resp.Result.SongCount
+		result.Songs = toSongs(resp.Result.Songs)
+	}
+	return result, nil
+}
+
+// GetAlbum 获取专辑信息和歌曲
+func (c *Client) GetAlbum(id int64) (*Album, error) {
+	body, err := c.get(albumPathPrefix + strconv.FormatInt(id, 10))
+	if err != nil {
+		return nil, fmt.Errorf("获取专辑失败: %w", err)
+	}
+
+	var resp struct {
+		Code  int          `json:"code"`
+		Album albumDetail  `json:"album"`
+		Songs []SongDetail `json:"songs"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析专辑失败: %v", err)
+	}
+	if err := checkCode(resp.Code); err != nil {
+		return nil, err
+	}
+
+	album := resp.Album.toAlbum()
+	album.Songs = toSongs(resp.Songs)
+	return &album, nil
+}
+
+// GetArtistTopSongs 获取艺术家的热门歌曲
+func (c *Client) GetArtistTopSongs(id int64) ([]Song, error) {
+	body, err := c.postForm(artistTopSongPath, url.Values{"id": {strconv.FormatInt(id, 10)}})
+	if err != nil {
+		return nil, fmt.Errorf("获取热门歌曲失败: %w", err)
+	}
+
+	var resp struct {
+		Code  int          `json:"code"`
+		Songs []SongDetail `json:"songs"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("解析热门歌曲失败: %v", err)
+	}
+	if err := checkCode(resp.Code); err != nil {
+		return nil, err
+	}
+	return toSongs(resp.Songs), nil
+}
+
+// checkCode 检查接口返回的 code
+func checkCode(code int) error {
+	switch code {
+	case 200:
+		return nil
+	case 401:
+		return errors.New("无权限访问")
+	case 404:
+		return errors.New("资源不存在")
+	}
+	return fmt.Errorf("接口返回错误（code %d）", code)
+}
@@ -0,0 +1,44 @@
+package netease_test
+
+import (
+	"strings"
+	"testing"
+
+	"aku-web/internal/netease"
+)
+
+func TestSearch(t *testing.T) {
+	server := newTestServer(t)
+	client := server.NeteaseClient()
+
+	result, err := client.Search("香", netease.SearchSong, 0, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if result.Type != netease.SearchSong {
+		t.Errorf("类型 = %s, 期望 %s", result.Type, netease.SearchSong)
+	}
+	var got []string
+	for _, song := range result.Songs {
+		got = append(got, song.Name)
+	}
+	if want := "七里香,稻香"; strings.Join(got, ",") != want {
+		t.Errorf("搜索结果 = %s, 期望 %s", strings.Join(got, ","), want)
+	}
+	if result.Total != 2 {
+		t.Errorf("总数 = %d, 期望 2", result.Total)
+	}
+
+	// 歌曲也按艺术家匹配，offset 和 limit 用于翻页
+	result, err = client.Search("周杰伦", netease.SearchSong, 1, 2)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if result.Total != 5 || len(result.Songs) != 2 {
+		t.Errorf("总数 = %d, 歌曲数 = %d, 期望 5 和 2", result.Total, len(result.Songs))
+	}
+
+	if _, err := client.Search("  ", netease.SearchSong, 0, 10); err == nil {
+		t.Error("空关键字没有返回错误")
+	}
+}
@@ -0,0 +1,65 @@
+package netease
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrClockWrong 证书尚未生效或已经过期，通常是设备时间不正确
+var ErrClockWrong = errors.New("服务器证书尚未生效或已过期，设备时间可能不正确，请调用 /api/system/sync-time 同步时间后重试")
+
+// ErrUnknownAuthority 证书不是由系统证书库中的机构签发的
+var ErrUnknownAuthority = errors.New("无法验证服务器证书，系统证书库可能过旧，可以在配置的 NeteaseCAFile 中提供根证书")
+
+// LoadCABundle 在系统证书库之外信任 PEM 文件中的根证书，用于系统证书库过旧的设备
+func (c *Client) LoadCABundle(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取证书文件失败: %v", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("证书文件 %s 中没有有效的 PEM 证书", path)
+	}
+
+	transport, ok := c.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("客户端的 Transport 不支持设置证书")
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	transport.TLSClientConfig.RootCAs = pool
+	return nil
+}
+
+// do 发送请求，证书验证失败时换成说明原因的错误
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, explainTLSError(err)
+	}
+	return resp, nil
+}
+
+// explainTLSError 识别设备时间错误和缺少根证书导致的证书验证失败
+func explainTLSError(err error) error {
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) && invalid.Reason == x509.Expired {
+		return fmt.Errorf("%w（设备时间 %s）: %v", ErrClockWrong, time.Now().Format("2006-01-02 15:04:05"), err)
+	}
+	var unknown x509.UnknownAuthorityError
+	if errors.As(err, &unknown) {
+		return fmt.Errorf("%w: %v", ErrUnknownAuthority, err)
+	}
+	return err
+}
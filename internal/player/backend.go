@@ -0,0 +1,60 @@
+package player
+
+import (
+	"fmt"
+)
+
+// Backend 音频播放后端，负责实际的解码和输出
+//
+// 后端通过 SetEventHandler 设置的回调上报事件：EventState（播放状态）、
+// EventFrame（播放进度）、EventJump（跳转完成）、EventTag（标签）、
+// EventError（错误）以及 EventTrackEnd（歌曲自然播放结束）。
+type Backend interface {
+	// Name 返回后端名称
+	Name() string
+	// SetEventHandler 设置事件回调，需在其他方法之前调用
+	SetEventHandler(handler func(Event))
+	// Duration 加载音频获取时长，会打断当前播放
+	Duration(url string) (*AudioDuration, error)
+	// Load 加载并开始播放本地文件或URL
+	Load(path string) error
+	// Pause 暂停播放，已暂停时不做任何事
+	Pause() error
+	// Resume 继续播放，未暂停时不做任何事
+	Resume() error
+	// Seek 跳转到指定秒数，完成后上报 EventJump
+	Seek(position float64) error
+	// Stop 停止播放，但保留播放进程
+	Stop() error
+	// SetVolume 设置播放进程自身的音量（百分比，100 为原始音量），不影响混音器
+	SetVolume(percent float64) error
+	// Position 返回最近一次上报的播放位置（秒）
+	Position() float64
+	// Close 退出播放进程
+	Close() error
+}
+
+// NewBackend 根据名称创建播放后端（mpg123、mpv、fake）
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case "", "mpg123":
+		return newMpg123Backend(), nil
+	case "mpv":
+		return newMpvBackend(), nil
+	case "fake":
+		return NewFakeBackend(), nil
+	default:
+		return nil, fmt.Errorf("未知的播放后端: %s", name)
+	}
+}
+
+// newDuration 根据总秒数创建时长信息
+func newDuration(totalSeconds float64, totalFrames int) *AudioDuration {
+	return &AudioDuration{
+		Minutes:      int(totalSeconds) / 60,
+		Seconds:      int(totalSeconds) % 60,
+		Centiseconds: int((totalSeconds - float64(int(totalSeconds))) * 100),
+		TotalSeconds: totalSeconds,
+		TotalFrames:  totalFrames,
+	}
+}
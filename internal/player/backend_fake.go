@@ -0,0 +1,190 @@
+package player
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FakeBackend 内存中的假播放后端，不发出声音，用于测试和没有声卡的环境
+type FakeBackend struct {
+	// DefaultDuration 未在 Durations 中指定时返回的时长（秒）
+	DefaultDuration float64
+	// Durations 指定URL对应的时长（秒）
+	Durations map[string]float64
+
+	emit     func(Event)
+	loaded   string
+	state    PlayState
+	position float64
+	volume   float64
+	commands []string
+	mutex    sync.Mutex
+}
+
+// NewFakeBackend 创建假播放后端
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{
+		DefaultDuration: 180,
+		Durations:       make(map[string]float64),
+		volume:          100,
+		emit:            func(Event) {},
+	}
+}
+
+// Name 返回后端名称
+func (b *FakeBackend) Name() string {
+	return "fake"
+}
+
+// SetEventHandler 设置事件回调
+func (b *FakeBackend) SetEventHandler(handler func(Event)) {
+	b.emit = handler
+}
+
+// Duration 返回预设的时长
+func (b *FakeBackend) Duration(url string) (*AudioDuration, error) {
+	b.mutex.Lock()
+	b.commands = append(b.commands, "DURATION "+url)
+	seconds, ok := b.Durations[url]
+	if !ok {
+		seconds = b.DefaultDuration
+	}
+	b.mutex.Unlock()
+
+	if seconds <= 0 {
+		return nil, fmt.Errorf("无法获取完整的音频信息")
+	}
+	return newDuration(seconds, 0), nil
+}
+
+// Load 模拟加载并开始播放
+func (b *FakeBackend) Load(path string) error {
+	b.mutex.Lock()
+	b.commands = append(b.commands, "LOAD "+path)
+	b.loaded = path
+	b.position = 0
+	b.mutex.Unlock()
+
+	b.setState(StatePlaying)
+	return nil
+}
+
+// Pause 模拟暂停
+func (b *FakeBackend) Pause() error {
+	b.record("PAUSE")
+	if b.currentState() == StatePlaying {
+		b.setState(StatePaused)
+	}
+	return nil
+}
+
+// Resume 模拟继续播放
+func (b *FakeBackend) Resume() error {
+	b.record("RESUME")
+	if b.currentState() == StatePaused {
+		b.setState(StatePlaying)
+	}
+	return nil
+}
+
+// Seek 模拟跳转并立即上报完成
+func (b *FakeBackend) Seek(position float64) error {
+	b.mutex.Lock()
+	b.commands = append(b.commands, fmt.Sprintf("SEEK %.2f", position))
+	b.position = position
+	b.mutex.Unlock()
+
+	b.emit(Event{Type: EventJump})
+	return nil
+}
+
+// Stop 模拟停止
+func (b *FakeBackend) Stop() error {
+	b.record("STOP")
+	b.mutex.Lock()
+	b.loaded = ""
+	b.position = 0
+	b.mutex.Unlock()
+
+	b.setState(StateStopped)
+	return nil
+}
+
+// SetVolume 记录软件音量
+func (b *FakeBackend) SetVolume(percent float64) error {
+	b.mutex.Lock()
+	b.commands = append(b.commands, fmt.Sprintf("VOLUME %.1f", percent))
+	b.volume = percent
+	b.mutex.Unlock()
+	return nil
+}
+
+// Position 返回模拟的播放位置
+func (b *FakeBackend) Position() float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.position
+}
+
+// Close 模拟退出播放进程
+func (b *FakeBackend) Close() error {
+	b.record("CLOSE")
+	return nil
+}
+
+// Advance 模拟播放前进指定秒数
+func (b *FakeBackend) Advance(seconds float64) {
+	b.mutex.Lock()
+	b.position += seconds
+	position := b.position
+	b.mutex.Unlock()
+
+	b.emit(Event{Type: EventFrame, Frame: &FrameInfo{Seconds: position}})
+}
+
+// Finish 模拟当前歌曲自然播放结束
+func (b *FakeBackend) Finish() {
+	b.setState(StateEnded)
+	b.emit(Event{Type: EventTrackEnd})
+}
+
+// Loaded 返回当前加载的路径
+func (b *FakeBackend) Loaded() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.loaded
+}
+
+// Volume 返回最近一次设置的软件音量
+func (b *FakeBackend) Volume() float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.volume
+}
+
+// Commands 返回收到的所有命令
+func (b *FakeBackend) Commands() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]string{}, b.commands...)
+}
+
+func (b *FakeBackend) record(command string) {
+	b.mutex.Lock()
+	b.commands = append(b.commands, command)
+	b.mutex.Unlock()
+}
+
+func (b *FakeBackend) currentState() PlayState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state
+}
+
+func (b *FakeBackend) setState(state PlayState) {
+	b.mutex.Lock()
+	b.state = state
+	b.mutex.Unlock()
+
+	b.emit(stateEvent(state))
+}
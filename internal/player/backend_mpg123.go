@@ -0,0 +1,326 @@
+package player
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// mpg123Backend 通过 mpg123 -R 远程控制协议播放，只支持 MPEG 音频
+type mpg123Backend struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	mutex sync.Mutex
+
+	emit     func(Event)
+	replies  *eventHub // 用于等待命令回应
+	state    PlayState
+	position float64
+	stateMu  sync.RWMutex
+
+	pendingStops atomic.Int32 // 主动发送 STOP 后尚未收到的 @P 0 数量
+}
+
+func newMpg123Backend() *mpg123Backend {
+	return &mpg123Backend{
+		emit:    func(Event) {},
+		replies: newEventHub(),
+	}
+}
+
+// Name 返回后端名称
+func (b *mpg123Backend) Name() string {
+	return "mpg123"
+}
+
+// SetEventHandler 设置事件回调
+func (b *mpg123Backend) SetEventHandler(handler func(Event)) {
+	b.emit = handler
+}
+
+// Duration 加载音频并通过 FORMAT/SAMPLE 获取时长
+func (b *mpg123Backend) Duration(url string) (*AudioDuration, error) {
+	// 检查是否为常见的不支持格式
+	lowerUrl := strings.ToLower(url)
+	unsupportedFormats := []string{".wav", ".m4a", ".mp4", ".aac", ".ogg", ".flac", ".wma", ".aiff"}
+	for _, format := range unsupportedFormats {
+		if strings.HasSuffix(lowerUrl, format) {
+			return nil, fmt.Errorf("不支持的音频格式: %s，mpg123 仅支持MP3格式", format)
+		}
+	}
+
+	// 先订阅再发送命令，避免错过回应
+	replies, cancel := b.replies.subscribe()
+	defer cancel()
+
+	// 一次性发送所有需要的命令
+	commands := []string{
+		fmt.Sprintf("LOAD %s", url),
+		"FORMAT",
+		"SAMPLE",
+	}
+	for _, cmd := range commands {
+		if err := b.send(cmd); err != nil {
+			return nil, fmt.Errorf("发送命令 %s 失败: %v", cmd, err)
+		}
+	}
+
+	// 读取并解析输出
+	var sampleRate int
+	var totalSamples int64
+	formatFound := false
+	sampleFound := false
+	timeout := time.After(5 * time.Second)
+
+	// 读取直到获取所需的所有信息
+	for !formatFound || !sampleFound {
+		select {
+		case event := <-replies:
+			switch event.Type {
+			case EventFormat:
+				sampleRate = event.Format.SampleRate
+				formatFound = true
+			case EventSample:
+				totalSamples = event.Sample.Total
+				sampleFound = true
+			case EventError:
+				return nil, fmt.Errorf("读取音频信息失败: %s", event.Message)
+			}
+		case <-timeout:
+			return nil, fmt.Errorf("读取音频信息超时")
+		}
+	}
+
+	// 检查是否获取到所需信息
+	if sampleRate == 0 || totalSamples == 0 {
+		log.Printf("[mpg123] 数据不完整 - 采样率: %d, 总采样数: %d", sampleRate, totalSamples)
+		return nil, fmt.Errorf("无法获取完整的音频信息")
+	}
+
+	// 停止当前加载的音频
+	b.sendStop()
+
+	totalSeconds := float64(totalSamples) / float64(sampleRate)
+	log.Printf("音频信息: 采样率=%d Hz, 总采样数=%d, 总时长=%.2f秒",
+		sampleRate, totalSamples, totalSeconds)
+
+	return newDuration(totalSeconds, int(totalSamples)), nil // 使用采样数作为帧数
+}
+
+// Load 加载并开始播放
+func (b *mpg123Backend) Load(path string) error {
+	return b.send(fmt.Sprintf("LOAD %s", path))
+}
+
+// Pause 暂停播放，mpg123 的 PAUSE 命令是切换暂停/继续状态
+func (b *mpg123Backend) Pause() error {
+	if b.currentState() != StatePlaying {
+		return nil
+	}
+	return b.send("PAUSE")
+}
+
+// Resume 继续播放
+func (b *mpg123Backend) Resume() error {
+	if b.currentState() != StatePaused {
+		return nil
+	}
+	return b.send("PAUSE")
+}
+
+// Seek 跳转到指定秒数，mpg123 在成功跳转后会输出 @J
+func (b *mpg123Backend) Seek(position float64) error {
+	return b.send(fmt.Sprintf("JUMP %fs", position))
+}
+
+// Stop 停止播放
+func (b *mpg123Backend) Stop() error {
+	b.mutex.Lock()
+	running := b.cmd != nil
+	b.mutex.Unlock()
+
+	if !running {
+		return nil
+	}
+	return b.sendStop()
+}
+
+// SetVolume 设置 mpg123 的软件音量，新加载的歌曲沿用该音量
+func (b *mpg123Backend) SetVolume(percent float64) error {
+	return b.send(fmt.Sprintf("VOLUME %.1f", percent))
+}
+
+// Position 返回当前播放位置
+func (b *mpg123Backend) Position() float64 {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return b.position
+}
+
+// Close 退出 mpg123 进程
+func (b *mpg123Backend) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.cmd == nil {
+		return nil
+	}
+	fmt.Fprintln(b.stdin, "QUIT")
+	b.cmd.Process.Kill()
+	b.cmd = nil
+	b.stdin = nil
+	return nil
+}
+
+// start 启动 mpg123 进程（如果尚未启动）
+func (b *mpg123Backend) start() error {
+	if b.cmd != nil {
+		return nil
+	}
+
+	cmd := exec.Command("mpg123", "-R")
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("无法获取标准输入管道: %v", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("无法获取标准输出管道: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("无法启动 mpg123: %v", err)
+	}
+
+	b.cmd = cmd
+	b.stdin = stdin
+	b.pendingStops.Store(0)
+	go b.readEvents(cmd, stdout)
+
+	return nil
+}
+
+// send 发送命令到 mpg123，必要时先启动进程
+func (b *mpg123Backend) send(command string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := b.start(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(b.stdin, "%s\n", command)
+	return err
+}
+
+// sendStop 发送 STOP 命令，并记录需要忽略的 @P 0
+func (b *mpg123Backend) sendStop() error {
+	b.pendingStops.Add(1)
+	return b.send("STOP")
+}
+
+// readEvents 持续读取 mpg123 的输出，解析为事件并上报
+func (b *mpg123Backend) readEvents(cmd *exec.Cmd, stdout io.Reader) {
+	var lastFrame time.Time
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		event := parseLine(line)
+		b.replies.publish(event)
+
+		switch event.Type {
+		case EventFrame:
+			b.stateMu.Lock()
+			b.position = event.Frame.Seconds
+			b.stateMu.Unlock()
+
+			// @F 每帧输出一次，限制上报频率
+			if time.Since(lastFrame) < 250*time.Millisecond {
+				continue
+			}
+			lastFrame = time.Now()
+		case EventState:
+			if b.handleStateEvent(*event.State) {
+				b.emit(event)
+				event = Event{Type: EventTrackEnd}
+			}
+		case EventFormat, EventSample:
+			// 只是命令的回应
+			continue
+		}
+
+		b.emit(event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Printf("[mpg123] 读取输出失败: %v", err)
+	}
+
+	// 进程意外退出时清理，下次发送命令会重新启动
+	b.mutex.Lock()
+	if b.cmd == cmd {
+		b.cmd = nil
+		b.stdin = nil
+	}
+	b.mutex.Unlock()
+}
+
+// handleStateEvent 记录 @P 状态，返回是否为歌曲自然播放结束
+func (b *mpg123Backend) handleStateEvent(state PlayState) bool {
+	b.stateMu.Lock()
+	previous := b.state
+	b.state = state
+	if state == StateStopped {
+		b.position = 0
+	}
+	b.stateMu.Unlock()
+
+	switch state {
+	case StatePlaying:
+		// 新的音频已开始播放，之前的 STOP 回应不会再来
+		if previous == StateStopped || previous == StateEnded {
+			b.pendingStops.Store(0)
+		}
+	case StateEnded:
+		return true
+	case StateStopped:
+		// @P 3 之后可能紧跟 @P 0，已经处理过结束
+		if previous == StateEnded {
+			return false
+		}
+		// @P 0 可能是主动 STOP 的回应，也可能是播放自然结束
+		return !b.consumePendingStop()
+	}
+	return false
+}
+
+// consumePendingStop 如果有等待中的 STOP 回应，消耗一个并返回 true
+func (b *mpg123Backend) consumePendingStop() bool {
+	for {
+		n := b.pendingStops.Load()
+		if n <= 0 {
+			return false
+		}
+		if b.pendingStops.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// currentState 获取当前播放状态
+func (b *mpg123Backend) currentState() PlayState {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return b.state
+}
@@ -0,0 +1,397 @@
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"aku-web/internal/config"
+)
+
+// mpv 属性监听ID
+const (
+	mpvObserveTimePos = iota + 1
+	mpvObservePause
+	mpvObserveDuration
+	mpvObserveMetadata
+)
+
+// mpvBackend 通过 mpv 的 JSON IPC 套接字播放，支持 WAV/FLAC/OGG/AAC 等格式
+type mpvBackend struct {
+	socketPath string
+	cmd        *exec.Cmd
+	conn       net.Conn
+	mutex      sync.Mutex // 保护 cmd 和 conn
+
+	requestID int64
+	pending   map[int64]chan mpvReply
+	pendingMu sync.Mutex
+
+	emit     func(Event)
+	state    PlayState
+	position float64
+	duration float64
+	seeking  bool
+	stateMu  sync.RWMutex
+}
+
+// mpvReply mpv 的命令回应
+type mpvReply struct {
+	Error string          `json:"error"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// mpvMessage mpv 发来的消息，可能是命令回应也可能是事件
+type mpvMessage struct {
+	RequestID int64           `json:"request_id"`
+	Error     string          `json:"error"`
+	Data      json.RawMessage `json:"data"`
+	Event     string          `json:"event"`
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Reason    string          `json:"reason"`
+}
+
+func newMpvBackend() *mpvBackend {
+	return &mpvBackend{
+		socketPath: config.MpvSocketPath,
+		pending:    make(map[int64]chan mpvReply),
+		emit:       func(Event) {},
+	}
+}
+
+// Name 返回后端名称
+func (b *mpvBackend) Name() string {
+	return "mpv"
+}
+
+// SetEventHandler 设置事件回调
+func (b *mpvBackend) SetEventHandler(handler func(Event)) {
+	b.emit = handler
+}
+
+// Duration 暂停状态下加载音频，等待 mpv 解析出时长
+func (b *mpvBackend) Duration(url string) (*AudioDuration, error) {
+	if _, err := b.command("set_property", "pause", true); err != nil {
+		return nil, err
+	}
+	if _, err := b.command("loadfile", url, "replace"); err != nil {
+		return nil, fmt.Errorf("加载音频失败: %v", err)
+	}
+	defer b.command("stop")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		data, err := b.command("get_property", "duration")
+		if err == nil {
+			var seconds float64
+			if err := json.Unmarshal(data, &seconds); err == nil && seconds > 0 {
+				return newDuration(seconds, 0), nil
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil, fmt.Errorf("读取音频信息超时")
+}
+
+// Load 加载并开始播放
+func (b *mpvBackend) Load(path string) error {
+	if _, err := b.command("loadfile", path, "replace"); err != nil {
+		return fmt.Errorf("加载音频失败: %v", err)
+	}
+	_, err := b.command("set_property", "pause", false)
+	return err
+}
+
+// Pause 暂停播放
+func (b *mpvBackend) Pause() error {
+	_, err := b.command("set_property", "pause", true)
+	return err
+}
+
+// Resume 继续播放
+func (b *mpvBackend) Resume() error {
+	_, err := b.command("set_property", "pause", false)
+	return err
+}
+
+// Seek 跳转到指定秒数，mpv 在跳转完成后发送 playback-restart
+func (b *mpvBackend) Seek(position float64) error {
+	b.stateMu.Lock()
+	b.seeking = true
+	b.stateMu.Unlock()
+
+	_, err := b.command("seek", position, "absolute")
+	return err
+}
+
+// Stop 停止播放
+func (b *mpvBackend) Stop() error {
+	b.mutex.Lock()
+	running := b.conn != nil
+	b.mutex.Unlock()
+
+	if !running {
+		return nil
+	}
+	_, err := b.command("stop")
+	return err
+}
+
+// SetVolume 设置 mpv 的软件音量，新加载的歌曲沿用该音量
+func (b *mpvBackend) SetVolume(percent float64) error {
+	_, err := b.command("set_property", "volume", percent)
+	return err
+}
+
+// Position 返回当前播放位置
+func (b *mpvBackend) Position() float64 {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return b.position
+}
+
+// Close 退出 mpv 进程
+func (b *mpvBackend) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn = nil
+	}
+	if b.cmd != nil {
+		b.cmd.Process.Kill()
+		b.cmd = nil
+	}
+	return nil
+}
+
+// start 启动 mpv 进程并连接 IPC 套接字（如果尚未连接）
+func (b *mpvBackend) start() error {
+	if b.conn != nil {
+		return nil
+	}
+
+	os.Remove(b.socketPath)
+	cmd := exec.Command("mpv", "--idle=yes", "--no-video", "--no-terminal",
+		"--input-ipc-server="+b.socketPath)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("无法启动 mpv: %v", err)
+	}
+	go cmd.Wait()
+
+	// 等待 mpv 创建套接字
+	var conn net.Conn
+	var err error
+	for i := 0; i < 30; i++ {
+		conn, err = net.Dial("unix", b.socketPath)
+		if err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		return fmt.Errorf("无法连接 mpv IPC: %v", err)
+	}
+
+	b.cmd = cmd
+	b.conn = conn
+	go b.readEvents(conn)
+
+	// 监听需要上报的属性
+	observe := []struct {
+		id   int
+		name string
+	}{
+		{mpvObserveTimePos, "time-pos"},
+		{mpvObservePause, "pause"},
+		{mpvObserveDuration, "duration"},
+		{mpvObserveMetadata, "metadata"},
+	}
+	for _, o := range observe {
+		if err := b.write([]interface{}{"observe_property", o.id, o.name}, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// command 发送命令并等待回应
+func (b *mpvBackend) command(args ...interface{}) (json.RawMessage, error) {
+	b.mutex.Lock()
+	if err := b.start(); err != nil {
+		b.mutex.Unlock()
+		return nil, err
+	}
+
+	b.pendingMu.Lock()
+	b.requestID++
+	id := b.requestID
+	reply := make(chan mpvReply, 1)
+	b.pending[id] = reply
+	b.pendingMu.Unlock()
+
+	err := b.write(args, id)
+	b.mutex.Unlock()
+
+	defer func() {
+		b.pendingMu.Lock()
+		delete(b.pending, id)
+		b.pendingMu.Unlock()
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case r := <-reply:
+		if r.Error != "success" {
+			return nil, fmt.Errorf("mpv: %s", r.Error)
+		}
+		return r.Data, nil
+	case <-time.After(5 * time.Second):
+		return nil, fmt.Errorf("mpv 命令超时: %v", args[0])
+	}
+}
+
+// write 写入一条命令，调用方需持有 mutex
+func (b *mpvBackend) write(args []interface{}, id int64) error {
+	msg := map[string]interface{}{"command": args}
+	if id > 0 {
+		msg["request_id"] = id
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = b.conn.Write(append(data, '\n'))
+	return err
+}
+
+// readEvents 读取 mpv 的回应和事件
+func (b *mpvBackend) readEvents(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var msg mpvMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		if msg.Event == "" {
+			b.pendingMu.Lock()
+			reply, ok := b.pending[msg.RequestID]
+			b.pendingMu.Unlock()
+			if ok {
+				reply <- mpvReply{Error: msg.Error, Data: msg.Data}
+			}
+			continue
+		}
+		b.handleEvent(msg)
+	}
+
+	b.mutex.Lock()
+	if b.conn == conn {
+		log.Printf("[mpv] IPC 连接已断开")
+		b.conn = nil
+		b.cmd = nil
+	}
+	b.mutex.Unlock()
+}
+
+// handleEvent 把 mpv 事件转换为播放器事件
+func (b *mpvBackend) handleEvent(msg mpvMessage) {
+	switch msg.Event {
+	case "property-change":
+		b.handlePropertyChange(msg)
+	case "file-loaded":
+		b.setState(StatePlaying)
+	case "playback-restart":
+		b.stateMu.Lock()
+		seeking := b.seeking
+		b.seeking = false
+		b.stateMu.Unlock()
+		if seeking {
+			b.emit(Event{Type: EventJump})
+		}
+	case "end-file":
+		switch msg.Reason {
+		case "eof":
+			b.setState(StateEnded)
+			b.emit(Event{Type: EventTrackEnd})
+		case "error":
+			b.setState(StateStopped)
+			b.emit(Event{Type: EventError, Message: "mpv 播放出错"})
+		default:
+			b.setState(StateStopped)
+		}
+	}
+}
+
+// handlePropertyChange 处理属性变化
+func (b *mpvBackend) handlePropertyChange(msg mpvMessage) {
+	switch msg.ID {
+	case mpvObserveTimePos:
+		var seconds float64
+		if json.Unmarshal(msg.Data, &seconds) != nil {
+			return
+		}
+		b.stateMu.Lock()
+		b.position = seconds
+		left := b.duration - seconds
+		b.stateMu.Unlock()
+		b.emit(Event{Type: EventFrame, Frame: &FrameInfo{Seconds: seconds, SecondsLeft: left}})
+	case mpvObservePause:
+		var paused bool
+		if json.Unmarshal(msg.Data, &paused) != nil {
+			return
+		}
+		b.stateMu.RLock()
+		state := b.state
+		b.stateMu.RUnlock()
+		if state != StatePlaying && state != StatePaused {
+			return
+		}
+		if paused {
+			b.setState(StatePaused)
+		} else {
+			b.setState(StatePlaying)
+		}
+	case mpvObserveDuration:
+		var seconds float64
+		if json.Unmarshal(msg.Data, &seconds) != nil {
+			return
+		}
+		b.stateMu.Lock()
+		b.duration = seconds
+		b.stateMu.Unlock()
+	case mpvObserveMetadata:
+		var metadata map[string]string
+		if json.Unmarshal(msg.Data, &metadata) != nil {
+			return
+		}
+		for key, value := range metadata {
+			b.emit(Event{Type: EventTag, Tag: &TagInfo{Key: key, Value: value}})
+		}
+	}
+}
+
+// setState 更新播放状态并上报
+func (b *mpvBackend) setState(state PlayState) {
+	b.stateMu.Lock()
+	b.state = state
+	if state == StateStopped {
+		b.position = 0
+	}
+	b.stateMu.Unlock()
+
+	b.emit(stateEvent(state))
+}
@@ -0,0 +1,473 @@
+package player
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aku-web/internal/library"
+)
+
+// 缓存索引文件名，保存在缓存目录下
+const cacheIndexFile = "index.json"
+
+// maxSongURLs 最多记住的网易云播放地址数量，超出时清空重新记录
+const maxSongURLs = 500
+
+// CacheStatus 缓存状态
+type CacheStatus int
+
+const (
+	CacheStatusDownloading CacheStatus = iota
+	CacheStatusCompleted
+	CacheStatusError
+)
+
+// String 返回缓存状态名称
+func (s CacheStatus) String() string {
+	switch s {
+	case CacheStatusCompleted:
+		return "completed"
+	case CacheStatusError:
+		return "error"
+	default:
+		return "downloading"
+	}
+}
+
+// AudioCache 管理音频缓存。缓存以缓存键区分：网易云歌曲的播放地址带有会过期的签名，
+// 每次解析都不同，按歌曲ID作为键；其他地址直接以URL作为键
+type AudioCache struct {
+	CacheDir     string
+	MaxCacheSize int64
+	files        map[string]*CacheInfo // 以缓存键为键
+	mutex        sync.RWMutex
+	downloadMu   sync.RWMutex
+	saveMu       sync.Mutex
+}
+
+// CacheInfo 存储缓存文件信息
+type CacheInfo struct {
+	URL        string // 最近一次使用的地址，只用于下载和显示
+	Path       string
+	Size       int64
+	LastAccess time.Time
+	Status     CacheStatus
+	Duration   *AudioDuration
+	ReadySize  int64               // 从文件开头连续可用的大小
+	Gain       *library.ReplayGain // 下载完成后分析的响度信息，分析前为空
+	chunks     []bool              // 分片位图，服务器不支持 Range 时为空
+	priority   int                 // 优先下载的起始分片，跳转时设置
+	changed    chan struct{}
+	mutex      sync.RWMutex
+}
+
+// CacheEntry 缓存列表中的一项
+type CacheEntry struct {
+	Key        string    `json:"key"`
+	URL        string    `json:"url"`
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	ReadySize  int64     `json:"ready_size"`
+	Duration   float64   `json:"duration"`
+	LastAccess time.Time `json:"last_access"`
+	Status     string    `json:"status"`
+}
+
+// CacheStats 缓存统计
+type CacheStats struct {
+	CacheDir    string `json:"cache_dir"`
+	Entries     int    `json:"entries"`
+	Completed   int    `json:"completed"`
+	Downloading int    `json:"downloading"`
+	TotalSize   int64  `json:"total_size"`
+	MaxSize     int64  `json:"max_size"`
+}
+
+// cacheIndexEntry 磁盘索引中的一项，以缓存键的哈希为键
+type cacheIndexEntry struct {
+	Key        string    `json:"key,omitempty"` // 为空时是以前按URL保存的索引，缓存键就是URL
+	URL        string    `json:"url"`
+	File       string    `json:"file"`
+	Size       int64     `json:"size"`
+	Duration   float64   `json:"duration"`
+	LastAccess time.Time `json:"last_access"`
+	Complete   bool      `json:"complete"`
+
+	Gain *library.ReplayGain `json:"gain,omitempty"`
+}
+
+// NewAudioCache 创建音频缓存，并从磁盘索引恢复上次的缓存
+func NewAudioCache(cacheDir string, maxSize int64) (*AudioCache, error) {
+	// 创建缓存目录
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建缓存目录失败: %v", err)
+	}
+
+	c := &AudioCache{
+		CacheDir:     cacheDir,
+		MaxCacheSize: maxSize,
+		files:        make(map[string]*CacheInfo),
+	}
+	c.load()
+	return c, nil
+}
+
+var (
+	songURLMu sync.Mutex
+	songURLs  = make(map[string]uint) // 网易云播放地址对应的歌曲ID
+)
+
+// RememberSongURL 记录网易云播放地址对应的歌曲ID，同一首歌重新解析出的地址使用同一个缓存
+func RememberSongURL(url string, songId uint) {
+	if songId == 0 {
+		return
+	}
+	songURLMu.Lock()
+	defer songURLMu.Unlock()
+	if len(songURLs) >= maxSongURLs {
+		songURLs = make(map[string]uint)
+	}
+	songURLs[url] = songId
+}
+
+// keyFor 返回地址对应的缓存键，网易云歌曲为 netease:<ID>，其他地址为URL本身
+func keyFor(url string) string {
+	songURLMu.Lock()
+	songId, ok := songURLs[url]
+	songURLMu.Unlock()
+	if ok {
+		return fmt.Sprintf("netease:%d", songId)
+	}
+	return url
+}
+
+// cacheKey 计算缓存键对应的哈希
+func cacheKey(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// pathFor 返回地址对应的缓存文件路径
+func (c *AudioCache) pathFor(url string) string {
+	return filepath.Join(c.CacheDir, "audio_"+cacheKey(keyFor(url)))
+}
+
+// load 读取磁盘索引，校验文件并清理残留的不完整文件
+func (c *AudioCache) load() {
+	index := make(map[string]cacheIndexEntry)
+	if data, err := os.ReadFile(filepath.Join(c.CacheDir, cacheIndexFile)); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			log.Printf("[Cache] 缓存索引损坏，将重建: %v", err)
+		}
+	}
+
+	known := make(map[string]bool)
+	for hash, entry := range index {
+		key := entry.Key
+		if key == "" {
+			key = entry.URL
+		}
+		path := filepath.Join(c.CacheDir, entry.File)
+		stat, err := os.Stat(path)
+		if !entry.Complete || err != nil || stat.Size() != entry.Size || cacheKey(key) != hash {
+			// 未下载完成或文件已损坏
+			os.Remove(path)
+			continue
+		}
+
+		info := &CacheInfo{
+			URL:        entry.URL,
+			Path:       path,
+			Size:       entry.Size,
+			ReadySize:  entry.Size,
+			LastAccess: entry.LastAccess,
+			Status:     CacheStatusCompleted,
+			Gain:       entry.Gain,
+		}
+		if entry.Duration > 0 {
+			info.Duration = newDuration(entry.Duration, 0)
+		}
+		c.files[key] = info
+		known[entry.File] = true
+	}
+
+	// 清理不在索引中的旧文件（包括以前版本留下的 audio_* 临时文件）
+	dirEntries, _ := os.ReadDir(c.CacheDir)
+	for _, e := range dirEntries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "audio_") || known[e.Name()] {
+			continue
+		}
+		os.Remove(filepath.Join(c.CacheDir, e.Name()))
+	}
+
+	log.Printf("[Cache] 从索引恢复 %d 个缓存文件", len(c.files))
+	c.Evict()
+}
+
+// save 把已完成的缓存写入磁盘索引
+func (c *AudioCache) save() {
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	index := make(map[string]cacheIndexEntry)
+	c.mutex.RLock()
+	for key, info := range c.files {
+		info.mutex.RLock()
+		if info.Status == CacheStatusCompleted {
+			entry := cacheIndexEntry{
+				Key:        key,
+				URL:        info.URL,
+				File:       filepath.Base(info.Path),
+				Size:       info.Size,
+				LastAccess: info.LastAccess,
+				Complete:   true,
+				Gain:       info.Gain,
+			}
+			if info.Duration != nil {
+				entry.Duration = info.Duration.TotalSeconds
+			}
+			index[cacheKey(key)] = entry
+		}
+		info.mutex.RUnlock()
+	}
+	c.mutex.RUnlock()
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		log.Printf("[Cache] 序列化缓存索引失败: %v", err)
+		return
+	}
+
+	// 先写临时文件再重命名，避免断电时索引损坏
+	path := filepath.Join(c.CacheDir, cacheIndexFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		log.Printf("[Cache] 保存缓存索引失败: %v", err)
+		return
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		log.Printf("[Cache] 保存缓存索引失败: %v", err)
+	}
+}
+
+// GetCachedFile 获取缓存的文件信息，并更新最近访问时间
+func (c *AudioCache) GetCachedFile(url string) *CacheInfo {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if info, exists := c.files[keyFor(url)]; exists {
+		info.mutex.Lock()
+		info.LastAccess = time.Now()
+		info.mutex.Unlock()
+		return info
+	}
+	return nil
+}
+
+// peek 获取缓存的文件信息，不更新最近访问时间
+func (c *AudioCache) peek(url string) *CacheInfo {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.files[keyFor(url)]
+}
+
+// add 登记新的缓存文件
+func (c *AudioCache) add(url string, info *CacheInfo) {
+	info.URL = url
+	c.mutex.Lock()
+	c.files[keyFor(url)] = info
+	c.mutex.Unlock()
+}
+
+// remove 删除地址对应的缓存项和文件
+func (c *AudioCache) remove(url string) {
+	c.removeKey(keyFor(url))
+}
+
+// removeKey 删除缓存项和对应的文件
+func (c *AudioCache) removeKey(key string) {
+	c.mutex.Lock()
+	info, ok := c.files[key]
+	delete(c.files, key)
+	c.mutex.Unlock()
+
+	if ok {
+		os.Remove(info.Path)
+	}
+}
+
+// complete 下载完成后保存索引，并在超出容量时淘汰旧文件。keep 为需要保留的其他地址，例如正在播放的歌曲
+func (c *AudioCache) complete(url string, keep ...string) {
+	c.Evict(append(keep, url)...)
+	c.save()
+}
+
+// Evict 按最近最少使用淘汰已完成的缓存，直到总大小不超过 MaxCacheSize。
+// keep 指定的地址和正在下载的缓存不会被淘汰
+func (c *AudioCache) Evict(keep ...string) int {
+	type candidate struct {
+		key        string
+		size       int64
+		lastAccess time.Time
+	}
+
+	kept := make(map[string]bool, len(keep))
+	for _, url := range keep {
+		kept[keyFor(url)] = true
+	}
+
+	var total int64
+	var candidates []candidate
+	c.mutex.RLock()
+	for key, info := range c.files {
+		info.mutex.RLock()
+		total += info.ReadySize
+		if info.Status == CacheStatusCompleted && !kept[key] {
+			candidates = append(candidates, candidate{key, info.Size, info.LastAccess})
+		}
+		info.mutex.RUnlock()
+	}
+	c.mutex.RUnlock()
+
+	if c.MaxCacheSize <= 0 || total <= c.MaxCacheSize {
+		return 0
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastAccess.Before(candidates[j].lastAccess)
+	})
+
+	evicted := 0
+	for _, cand := range candidates {
+		if total <= c.MaxCacheSize {
+			break
+		}
+		c.removeKey(cand.key)
+		total -= cand.size
+		evicted++
+		log.Printf("[Cache] 淘汰缓存: %s", cand.key)
+	}
+	if evicted > 0 {
+		c.save()
+	}
+	return evicted
+}
+
+// Purge 删除缓存。url 可以是地址或缓存键，为空时删除全部，正在下载和 keep 指定的缓存会保留
+func (c *AudioCache) Purge(url, keep string) int {
+	target, kept := keyFor(url), keyFor(keep)
+	var keys []string
+	c.mutex.RLock()
+	for key, info := range c.files {
+		if key == kept {
+			continue
+		}
+		info.mutex.RLock()
+		matched := url == "" || key == target || info.URL == url
+		downloading := info.Status == CacheStatusDownloading
+		info.mutex.RUnlock()
+		if matched && !downloading {
+			keys = append(keys, key)
+		}
+	}
+	c.mutex.RUnlock()
+
+	for _, key := range keys {
+		c.removeKey(key)
+	}
+	c.save()
+	return len(keys)
+}
+
+// List 列出所有缓存，最近访问的在前
+func (c *AudioCache) List() []CacheEntry {
+	c.mutex.RLock()
+	entries := make([]CacheEntry, 0, len(c.files))
+	for key, info := range c.files {
+		info.mutex.RLock()
+		entry := CacheEntry{
+			Key:        key,
+			URL:        info.URL,
+			Path:       info.Path,
+			Size:       info.Size,
+			ReadySize:  info.ReadySize,
+			LastAccess: info.LastAccess,
+			Status:     info.Status.String(),
+		}
+		if info.Duration != nil {
+			entry.Duration = info.Duration.TotalSeconds
+		}
+		info.mutex.RUnlock()
+		entries = append(entries, entry)
+	}
+	c.mutex.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastAccess.After(entries[j].LastAccess)
+	})
+	return entries
+}
+
+// Stats 获取缓存统计
+func (c *AudioCache) Stats() CacheStats {
+	stats := CacheStats{
+		CacheDir: c.CacheDir,
+		MaxSize:  c.MaxCacheSize,
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for _, info := range c.files {
+		info.mutex.RLock()
+		stats.Entries++
+		stats.TotalSize += info.ReadySize
+		switch info.Status {
+		case CacheStatusCompleted:
+			stats.Completed++
+		case CacheStatusDownloading:
+			stats.Downloading++
+		}
+		info.mutex.RUnlock()
+	}
+	return stats
+}
+
+// GetCacheStats 获取默认播放器的缓存统计
+func GetCacheStats() (CacheStats, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return CacheStats{}, fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.cache.Stats(), nil
+}
+
+// ListCache 列出默认播放器的缓存
+func ListCache() ([]CacheEntry, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return nil, fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.cache.List(), nil
+}
+
+// PurgeCache 删除默认播放器的缓存，正在播放的歌曲会保留。url 为空时删除全部
+func PurgeCache(url string) (int, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return 0, fmt.Errorf("播放器初始化失败")
+	}
+
+	defaultPlayer.stateMu.RLock()
+	current := defaultPlayer.track.url
+	defaultPlayer.stateMu.RUnlock()
+
+	return defaultPlayer.cache.Purge(url, current), nil
+}
@@ -0,0 +1,142 @@
+package player
+
+import (
+	"fmt"
+	"time"
+)
+
+// fadeStep 淡入淡出时调整软件音量的间隔
+const fadeStep = 100 * time.Millisecond
+
+// SetCrossfade 设置自动切换到队列下一首时淡出淡入的秒数，0 表示关闭
+func SetCrossfade(seconds int) error {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.SetCrossfade(seconds)
+}
+
+// SetCrossfade 设置淡出淡入的秒数
+func (p *AudioPlayer) SetCrossfade(seconds int) error {
+	if seconds < 0 || seconds > 30 {
+		return fmt.Errorf("淡出淡入时间必须在 0 到 30 秒之间")
+	}
+	p.gainMu.Lock()
+	p.crossfade = seconds
+	p.gainMu.Unlock()
+	p.notify()
+	return nil
+}
+
+// crossfadeSeconds 返回淡出淡入的秒数
+func (p *AudioPlayer) crossfadeSeconds() int {
+	p.gainMu.Lock()
+	defer p.gainMu.Unlock()
+	return p.crossfade
+}
+
+// checkCrossfade 根据播放进度在队列歌曲结束前淡出，跳转回淡出范围之前时恢复音量。
+// 播放器同一时间只有一个播放进程，所以是当前歌曲淡出后下一首淡入，而不是两首重叠。
+// 由后端的事件协程调用，不能直接设置音量
+func (p *AudioPlayer) checkCrossfade(position float64) {
+	p.stateMu.RLock()
+	url, item, duration, live := p.track.url, p.track.item, p.track.duration, p.track.live
+	p.stateMu.RUnlock()
+
+	p.gainMu.Lock()
+	seconds, fadingOut := p.crossfade, p.fadeOutURL != "" && p.fadeOutURL == url
+	p.gainMu.Unlock()
+	if item == nil || live || duration == nil {
+		return
+	}
+
+	remaining := duration.TotalSeconds - position
+	if remaining > float64(seconds) || seconds == 0 {
+		// 在事件协程中调用，mpv 的 SetVolume 要等待同一个协程读取回复，必须在其他协程中设置
+		if fadingOut {
+			go p.resetFade()
+		}
+		return
+	}
+	if fadingOut || remaining <= 0 {
+		return
+	}
+	if _, ok := p.queue.PeekNext(); !ok {
+		return
+	}
+
+	p.gainMu.Lock()
+	p.fadeOutURL = url
+	p.fadeGen++
+	gen, from := p.fadeGen, p.fade
+	p.gainMu.Unlock()
+	go p.runFade(gen, from, 0, time.Duration(remaining*float64(time.Second)))
+}
+
+// takeFadeIn 开始加载新的音频时调用：上一首已经淡出时返回 true，由调用方在加载后淡入，
+// 否则取消淡入淡出并恢复音量。调用方需持有 p.mutex
+func (p *AudioPlayer) takeFadeIn(auto bool) bool {
+	p.gainMu.Lock()
+	fadedOut := p.fadeOutURL != ""
+	p.fadeOutURL = ""
+	p.gainMu.Unlock()
+
+	if auto && fadedOut {
+		return true
+	}
+	p.resetFade()
+	return false
+}
+
+// afterLoad 加载新的音频后应用软件音量，上一首淡出过时开始淡入
+func (p *AudioPlayer) afterLoad(fadeIn bool) {
+	p.reapplyGain()
+	if fadeIn {
+		p.startFadeIn()
+	}
+}
+
+// startFadeIn 从当前的系数淡入到原始音量
+func (p *AudioPlayer) startFadeIn() {
+	p.gainMu.Lock()
+	p.fadeGen++
+	gen, from, seconds := p.fadeGen, p.fade, p.crossfade
+	p.gainMu.Unlock()
+	go p.runFade(gen, from, 1, time.Duration(seconds)*time.Second)
+}
+
+// resetFade 取消正在进行的淡入淡出并恢复音量
+func (p *AudioPlayer) resetFade() {
+	p.gainMu.Lock()
+	defer p.gainMu.Unlock()
+	p.fadeGen++
+	p.fadeOutURL = ""
+	if p.fade == 1 {
+		return
+	}
+	p.fade = 1
+	p.applyGainLocked()
+}
+
+// runFade 在 duration 内把淡入淡出系数从 from 调到 to，gen 变化时停止
+func (p *AudioPlayer) runFade(gen int, from, to float64, duration time.Duration) {
+	steps := int(duration / fadeStep)
+	if steps < 1 {
+		steps = 1
+	}
+	ticker := time.NewTicker(duration / time.Duration(steps))
+	defer ticker.Stop()
+
+	for i := 1; i <= steps; i++ {
+		<-ticker.C
+		p.gainMu.Lock()
+		if p.fadeGen != gen {
+			p.gainMu.Unlock()
+			return
+		}
+		p.fade = from + (to-from)*float64(i)/float64(steps)
+		p.applyGainLocked()
+		p.gainMu.Unlock()
+	}
+}
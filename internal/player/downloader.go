@@ -0,0 +1,468 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 分片下载参数
+const (
+	chunkSize       = 512 * 1024 // 分片大小，适合音频流
+	maxChunkRetries = 5          // 每个分片的最大重试次数
+	retryBaseDelay  = 500 * time.Millisecond
+	retryMaxDelay   = 8 * time.Second
+	chunkTimeout    = 30 * time.Second // 单个分片请求（包括读取内容）的超时
+
+	streamHeaderTimeout = 15 * time.Second // 流式下载等待响应头的超时
+	streamIdleTimeout   = 30 * time.Second // 流式下载超过这个时间没有收到数据视为连接已断开
+)
+
+var (
+	// errURLExpired 服务器拒绝访问，通常是下载地址已过期
+	errURLExpired = errors.New("下载地址已失效")
+	// errRangeUnsupported 服务器忽略了 Range 请求头
+	errRangeUnsupported = errors.New("服务器不支持分段下载")
+)
+
+// downloader 把网络音频下载到缓存文件。
+// 服务器支持 Range 时按分片下载，失败的分片带退避重试；否则退化为一次完整的流式下载
+type downloader struct {
+	url      string // 当前使用的下载地址，过期后会被替换
+	file     *os.File
+	info     *CacheInfo
+	client   *http.Client           // 分片请求使用，整个请求有超时
+	streamer *http.Client           // 流式下载使用，没有整体超时，由响应头超时和读取空闲超时限制
+	refresh  func() (string, error) // 重新解析下载地址，可以为空
+}
+
+// newDownloader 创建下载器，refresh 用于在地址过期时获取新地址
+func newDownloader(url string, file *os.File, info *CacheInfo, refresh func() (string, error)) *downloader {
+	return &downloader{
+		url:    url,
+		file:   file,
+		info:   info,
+		client: &http.Client{Timeout: chunkTimeout},
+		streamer: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: streamHeaderTimeout,
+			},
+		},
+		refresh: refresh,
+	}
+}
+
+// run 下载整个文件，出错时把缓存状态设为 CacheStatusError
+func (d *downloader) run() error {
+	defer d.file.Close()
+
+	err := d.fetchWithRetry(0)
+	if errors.Is(err, errRangeUnsupported) {
+		log.Printf("[Download] %v，改为流式下载", err)
+		err = d.streamWithRetry()
+	} else if err == nil {
+		for {
+			index := d.info.nextChunk()
+			if index < 0 {
+				break
+			}
+			if err = d.fetchWithRetry(index); err != nil {
+				break
+			}
+		}
+	}
+
+	d.info.mutex.Lock()
+	if err != nil {
+		d.info.Status = CacheStatusError
+	} else {
+		d.info.Size = d.info.ReadySize
+		d.info.Status = CacheStatusCompleted
+	}
+	d.info.signalLocked()
+	d.info.mutex.Unlock()
+	return err
+}
+
+// fetchWithRetry 下载指定分片，失败时按指数退避重试，地址过期时先重新解析
+func (d *downloader) fetchWithRetry(index int) error {
+	for attempt := 0; ; attempt++ {
+		err := d.fetchChunk(index)
+		if err == nil || errors.Is(err, errRangeUnsupported) {
+			return err
+		}
+		if attempt >= maxChunkRetries {
+			return fmt.Errorf("分片 %d 下载失败: %v", index, err)
+		}
+		if errors.Is(err, errURLExpired) && d.renew() {
+			continue
+		}
+
+		delay := backoff(attempt)
+		log.Printf("[Download] 分片 %d 下载失败，%v 后重试: %v", index, delay, err)
+		time.Sleep(delay)
+	}
+}
+
+// fetchChunk 下载单个分片并写入缓存文件
+func (d *downloader) fetchChunk(index int) error {
+	start := int64(index) * chunkSize
+	end := start + chunkSize - 1
+	if size := d.info.knownSize(); size > 0 && end >= size {
+		end = size - 1
+	}
+
+	req, err := http.NewRequest("GET", d.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return errRangeUnsupported
+	}
+
+	// 第一个分片的响应决定文件总大小
+	if !d.info.hasLayout() {
+		total := parseContentRangeTotal(resp.Header.Get("Content-Range"))
+		if total <= 0 {
+			return errRangeUnsupported
+		}
+		if err := d.file.Truncate(total); err != nil {
+			return fmt.Errorf("预分配文件大小失败: %v", err)
+		}
+		d.info.initLayout(total)
+		if end >= total {
+			end = total - 1
+		}
+	}
+
+	expected := end - start + 1
+	written, err := copyAt(d.file, resp.Body, start, expected)
+	if err != nil {
+		return err
+	}
+	if written != expected {
+		return fmt.Errorf("分片数据不完整: %d/%d 字节", written, expected)
+	}
+
+	d.info.markChunk(index)
+	return nil
+}
+
+// streamWithRetry 不支持 Range 的服务器只能从头下载，失败时整个重新下载
+func (d *downloader) streamWithRetry() error {
+	for attempt := 0; ; attempt++ {
+		err := d.stream()
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxChunkRetries {
+			return fmt.Errorf("流式下载失败: %v", err)
+		}
+		if errors.Is(err, errURLExpired) && d.renew() {
+			continue
+		}
+
+		delay := backoff(attempt)
+		log.Printf("[Download] 流式下载失败，%v 后重试: %v", delay, err)
+		time.Sleep(delay)
+	}
+}
+
+// stream 用一次普通 GET 请求下载整个文件，长度未知时读到结束为止。
+// 大文件或网络慢时下载时间不固定，只在一段时间没有收到数据时中断
+func (d *downloader) stream() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idle := time.AfterFunc(streamIdleTimeout, cancel)
+	defer idle.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.streamer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	if resp.ContentLength > 0 {
+		d.info.mutex.Lock()
+		d.info.Size = resp.ContentLength
+		d.info.mutex.Unlock()
+	}
+
+	// 已经写过的数据内容相同，重新下载时不会让 ReadySize 倒退
+	var offset int64
+	buf := make([]byte, 32*1024)
+	for {
+		idle.Reset(streamIdleTimeout)
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := d.file.WriteAt(buf[:n], offset); werr != nil {
+				return fmt.Errorf("写入缓存文件失败: %v", werr)
+			}
+			offset += int64(n)
+
+			d.info.mutex.Lock()
+			if offset > d.info.ReadySize {
+				d.info.ReadySize = offset
+				d.info.signalLocked()
+			}
+			d.info.mutex.Unlock()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("超过 %v 没有收到数据", streamIdleTimeout)
+			}
+			return err
+		}
+	}
+
+	if resp.ContentLength > 0 && offset != resp.ContentLength {
+		return fmt.Errorf("数据不完整: %d/%d 字节", offset, resp.ContentLength)
+	}
+	return nil
+}
+
+// renew 通过回调重新解析下载地址，成功返回 true
+func (d *downloader) renew() bool {
+	if d.refresh == nil {
+		return false
+	}
+	url, err := d.refresh()
+	if err != nil || url == "" {
+		log.Printf("[Download] 重新解析下载地址失败: %v", err)
+		return false
+	}
+	log.Printf("[Download] 下载地址已过期，使用新地址继续下载")
+	d.url = url
+	return true
+}
+
+// checkStatus 把错误的 HTTP 状态码转换为错误
+func checkStatus(resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("%w: HTTP %d", errURLExpired, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// parseContentRangeTotal 解析 "bytes 0-1023/4096" 中的总大小，未知时返回 -1
+func parseContentRangeTotal(header string) int64 {
+	slash := strings.LastIndex(header, "/")
+	if slash < 0 {
+		return -1
+	}
+	total, err := strconv.ParseInt(strings.TrimSpace(header[slash+1:]), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
+
+// copyAt 从 r 读取最多 limit 字节写入文件的 offset 位置
+func copyAt(file *os.File, r io.Reader, offset, limit int64) (int64, error) {
+	var written int64
+	buf := make([]byte, 32*1024)
+	for written < limit {
+		want := int64(len(buf))
+		if remain := limit - written; remain < want {
+			want = remain
+		}
+		n, err := r.Read(buf[:want])
+		if n > 0 {
+			if _, werr := file.WriteAt(buf[:n], offset+written); werr != nil {
+				return written, fmt.Errorf("写入缓存文件失败: %v", werr)
+			}
+			written += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// backoff 计算第 attempt 次重试前的等待时间
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// hasLayout 是否已经确定文件大小和分片布局
+func (info *CacheInfo) hasLayout() bool {
+	info.mutex.RLock()
+	defer info.mutex.RUnlock()
+	return info.chunks != nil
+}
+
+// knownSize 返回已知的文件大小，未知时返回 0
+func (info *CacheInfo) knownSize() int64 {
+	info.mutex.RLock()
+	defer info.mutex.RUnlock()
+	return info.Size
+}
+
+// initLayout 根据文件大小初始化分片位图
+func (info *CacheInfo) initLayout(size int64) {
+	info.mutex.Lock()
+	info.Size = size
+	info.chunks = make([]bool, (size+chunkSize-1)/chunkSize)
+	info.mutex.Unlock()
+}
+
+// markChunk 标记分片已下载，并推进从头开始连续可用的水位线 ReadySize
+func (info *CacheInfo) markChunk(index int) {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	info.chunks[index] = true
+	ready := int(info.ReadySize / chunkSize)
+	for ready < len(info.chunks) && info.chunks[ready] {
+		ready++
+	}
+	info.ReadySize = int64(ready) * chunkSize
+	if info.ReadySize > info.Size {
+		info.ReadySize = info.Size
+	}
+	info.signalLocked()
+}
+
+// nextChunk 返回下一个需要下载的分片，全部完成时返回 -1。
+// 先下载优先位置之后的分片，再回头补齐前面的空洞
+func (info *CacheInfo) nextChunk() int {
+	info.mutex.RLock()
+	defer info.mutex.RUnlock()
+
+	for i := info.priority; i < len(info.chunks); i++ {
+		if !info.chunks[i] {
+			return i
+		}
+	}
+	for i := int(info.ReadySize / chunkSize); i < info.priority && i < len(info.chunks); i++ {
+		if !info.chunks[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// prioritize 让下载器优先下载 offset 附近的数据，当前分片下载完后生效
+func (info *CacheInfo) prioritize(offset int64) {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	if info.chunks == nil {
+		return
+	}
+	// 从目标的前一个分片开始，方便解码器重新同步
+	index := int(offset/chunkSize) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(info.chunks) {
+		index = len(info.chunks) - 1
+	}
+	info.priority = index
+}
+
+// rangeReadyLocked 判断 [start, end) 的数据是否都已下载，调用方需持有锁
+func (info *CacheInfo) rangeReadyLocked(start, end int64) bool {
+	if info.Status == CacheStatusCompleted || end <= info.ReadySize {
+		return true
+	}
+	if info.chunks == nil {
+		return false
+	}
+	for i := start / chunkSize; i <= (end-1)/chunkSize; i++ {
+		if i >= int64(len(info.chunks)) || !info.chunks[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// waitRange 等待 [start, end) 的数据下载完成，下载失败或超时返回错误
+func (info *CacheInfo) waitRange(start, end int64, timeout time.Duration) error {
+	return info.waitFor(func() bool {
+		return info.rangeReadyLocked(start, end)
+	}, timeout)
+}
+
+// waitFor 等待 ready 返回 true，每次下载进度变化时在持有锁的情况下检查。timeout 为 0 表示一直等待
+func (info *CacheInfo) waitFor(ready func() bool, timeout time.Duration) error {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		info.mutex.Lock()
+		if info.Status == CacheStatusError {
+			info.mutex.Unlock()
+			return fmt.Errorf("下载音频失败")
+		}
+		if ready() {
+			info.mutex.Unlock()
+			return nil
+		}
+		if info.changed == nil {
+			info.changed = make(chan struct{})
+		}
+		changed := info.changed
+		info.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-expired:
+			return fmt.Errorf("等待缓存超时，目标位置的数据尚未下载完成")
+		}
+	}
+}
+
+// signalLocked 唤醒所有等待下载进度的协程，调用方需持有锁
+func (info *CacheInfo) signalLocked() {
+	if info.changed != nil {
+		close(info.changed)
+		info.changed = nil
+	}
+}
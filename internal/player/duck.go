@@ -0,0 +1,66 @@
+package player
+
+import (
+	"fmt"
+	"log"
+)
+
+// SetDuck 把播放器自身的音量降低到 percent（100 为不降低），用于避让小智语音等其他声音。
+// 只调整播放进程的软件音量，混音器和各来源记住的音量不变
+func SetDuck(percent int) error {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.SetDuck(percent)
+}
+
+// SetDuck 设置避让时保留的音量百分比
+func (p *AudioPlayer) SetDuck(percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("百分比必须在 0 到 100 之间")
+	}
+
+	p.gainMu.Lock()
+	defer p.gainMu.Unlock()
+	if p.duck == percent {
+		return nil
+	}
+	p.duck = percent
+	log.Printf("[Focus] 播放器音量调整为 %d%%", percent)
+	return p.applyGainLocked()
+}
+
+// applyGainLocked 按避让百分比、淡入淡出系数和响度均衡设置播放进程的软件音量，调用方需持有 p.gainMu
+func (p *AudioPlayer) applyGainLocked() error {
+	p.applied = float64(p.duck) * p.fade * p.rgFactorLocked()
+	return p.backend.SetVolume(p.applied)
+}
+
+// reapplyGain 加载新的音频后重新应用软件音量，播放进程重启后软件音量会恢复默认，
+// 没有重启时上一首的响度均衡仍然有效，也需要重新设置
+func (p *AudioPlayer) reapplyGain() {
+	p.gainMu.Lock()
+	defer p.gainMu.Unlock()
+	if p.duck == 100 && p.fade == 1 && p.rgGain == nil && p.applied == 100 {
+		return
+	}
+	if err := p.applyGainLocked(); err != nil {
+		log.Printf("[Focus] 设置播放器音量失败: %v", err)
+	}
+}
+
+// PauseIfPlaying 正在播放时暂停，返回是否暂停了播放
+func PauseIfPlaying() (bool, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return false, fmt.Errorf("播放器初始化失败")
+	}
+	if state, _ := defaultPlayer.State(); state != StatePlaying {
+		return false, nil
+	}
+	if err := defaultPlayer.Pause(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,281 @@
+package player
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EventType mpg123 远程协议事件类型
+type EventType string
+
+const (
+	EventFrame        EventType = "frame"         // @F 播放进度
+	EventState        EventType = "state"         // @P 播放状态变化
+	EventStream       EventType = "stream"        // @S 流信息
+	EventTag          EventType = "tag"           // @I 标签信息
+	EventError        EventType = "error"         // @E 错误
+	EventJump         EventType = "jump"          // @J 跳转完成
+	EventFormat       EventType = "format"        // @FORMAT 输出格式
+	EventSample       EventType = "sample"        // @SAMPLE 采样位置
+	EventVolume       EventType = "volume"        // @V 音量
+	EventReady        EventType = "ready"         // @R 播放器启动
+	EventTrackEnd     EventType = "track_end"     // 歌曲自然播放结束
+	EventStatus       EventType = "status"        // 歌曲、缓冲或音量等状态变化
+	EventNowPlaying   EventType = "now_playing"   // 直播流的 StreamTitle 变化，Message 为标题
+	EventVolumeChange EventType = "volume_change" // 混音器音量、静音或声音来源变化，Volume 为原始值
+	EventUnknown      EventType = "unknown"       // 无法识别的输出
+)
+
+// PlayState 播放状态
+type PlayState int
+
+const (
+	StateStopped PlayState = iota
+	StatePaused
+	StatePlaying
+	StateEnded
+)
+
+// String 返回播放状态名称
+func (s PlayState) String() string {
+	switch s {
+	case StatePaused:
+		return "paused"
+	case StatePlaying:
+		return "playing"
+	case StateEnded:
+		return "ended"
+	default:
+		return "stopped"
+	}
+}
+
+// MarshalText 以名称形式序列化播放状态
+func (s PlayState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// FrameInfo @F 帧信息
+type FrameInfo struct {
+	Frame       int     `json:"frame"`
+	FramesLeft  int     `json:"frames_left"`
+	Seconds     float64 `json:"seconds"`
+	SecondsLeft float64 `json:"seconds_left"`
+}
+
+// StreamInfo @S 流信息
+type StreamInfo struct {
+	Version    string `json:"version"`
+	Layer      int    `json:"layer"`
+	SampleRate int    `json:"sample_rate"`
+	Mode       string `json:"mode"`
+	Channels   int    `json:"channels"`
+	Bitrate    int    `json:"bitrate"` // kbps
+}
+
+// TagInfo @I 标签信息
+type TagInfo struct {
+	Key   string `json:"key"` // 例如 title、artist，旧格式或文件名时为空
+	Value string `json:"value"`
+}
+
+// FormatInfo @FORMAT 输出格式
+type FormatInfo struct {
+	SampleRate int `json:"sample_rate"`
+	Channels   int `json:"channels"`
+}
+
+// SampleInfo @SAMPLE 采样信息
+type SampleInfo struct {
+	Position int64 `json:"position"`
+	Total    int64 `json:"total"`
+}
+
+// Event 播放器事件
+type Event struct {
+	Type    EventType   `json:"type"`
+	State   *PlayState  `json:"state,omitempty"` // 只有 state 和 status 事件带有播放状态
+	Frame   *FrameInfo  `json:"frame,omitempty"`
+	Stream  *StreamInfo `json:"stream,omitempty"`
+	Tag     *TagInfo    `json:"tag,omitempty"`
+	Format  *FormatInfo `json:"format,omitempty"`
+	Sample  *SampleInfo `json:"sample,omitempty"`
+	Volume  float64     `json:"volume,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Raw     string      `json:"-"`
+}
+
+// stateEvent 返回播放状态变化事件
+func stateEvent(state PlayState) Event {
+	return Event{Type: EventState, State: &state}
+}
+
+// parseLine 解析一行 mpg123 远程协议输出
+func parseLine(line string) Event {
+	event := Event{Type: EventUnknown, Raw: line}
+	if !strings.HasPrefix(line, "@") {
+		event.Message = line
+		return event
+	}
+
+	head, rest, _ := strings.Cut(line[1:], " ")
+	fields := strings.Fields(rest)
+
+	switch head {
+	case "F":
+		if len(fields) < 4 {
+			return event
+		}
+		event.Type = EventFrame
+		event.Frame = &FrameInfo{
+			Frame:       atoi(fields[0]),
+			FramesLeft:  atoi(fields[1]),
+			Seconds:     atof(fields[2]),
+			SecondsLeft: atof(fields[3]),
+		}
+	case "P":
+		if len(fields) < 1 {
+			return event
+		}
+		state := StateStopped
+		switch fields[0] {
+		case "1":
+			state = StatePaused
+		case "2":
+			state = StatePlaying
+		case "3":
+			state = StateEnded
+		}
+		event = stateEvent(state)
+		event.Raw = line
+	case "S":
+		if len(fields) < 11 {
+			return event
+		}
+		event.Type = EventStream
+		event.Stream = &StreamInfo{
+			Version:    fields[0],
+			Layer:      atoi(fields[1]),
+			SampleRate: atoi(fields[2]),
+			Mode:       fields[3],
+			Channels:   atoi(fields[6]),
+			Bitrate:    atoi(fields[10]),
+		}
+	case "I":
+		event.Type = EventTag
+		event.Tag = parseTag(rest)
+	case "E":
+		event.Type = EventError
+		event.Message = rest
+	case "J":
+		event.Type = EventJump
+		event.Message = rest
+	case "FORMAT":
+		if len(fields) < 2 {
+			return event
+		}
+		event.Type = EventFormat
+		event.Format = &FormatInfo{
+			SampleRate: atoi(fields[0]),
+			Channels:   atoi(fields[1]),
+		}
+	case "SAMPLE":
+		if len(fields) < 2 {
+			return event
+		}
+		event.Type = EventSample
+		position, _ := strconv.ParseInt(fields[0], 10, 64)
+		total, _ := strconv.ParseInt(fields[1], 10, 64)
+		event.Sample = &SampleInfo{Position: position, Total: total}
+	case "V":
+		if len(fields) < 1 {
+			return event
+		}
+		event.Type = EventVolume
+		event.Volume = atof(strings.TrimSuffix(fields[0], "%"))
+	case "R":
+		event.Type = EventReady
+		event.Message = rest
+	default:
+		event.Message = rest
+	}
+	return event
+}
+
+// parseTag 解析 @I 输出，如 "ID3v2.title:xxx" 或 "ID3:..."
+func parseTag(rest string) *TagInfo {
+	key, value, found := strings.Cut(rest, ":")
+	if !found {
+		return &TagInfo{Value: rest}
+	}
+	if strings.HasPrefix(key, "ID3v2.") {
+		return &TagInfo{Key: strings.TrimPrefix(key, "ID3v2."), Value: value}
+	}
+	if key == "ID3" || key == "ID3v1" {
+		return &TagInfo{Value: strings.TrimSpace(value)}
+	}
+	return &TagInfo{Key: key, Value: value}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func atof(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+// eventHub 把事件广播给所有订阅者
+type eventHub struct {
+	subscribers map[chan Event]struct{}
+	mutex       sync.Mutex
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[chan Event]struct{})}
+}
+
+// subscribe 订阅事件，返回事件通道和取消订阅函数
+func (h *eventHub) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 64)
+
+	h.mutex.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mutex.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mutex.Lock()
+			delete(h.subscribers, ch)
+			h.mutex.Unlock()
+		})
+	}
+}
+
+// publish 发布事件，订阅者处理不过来时丢弃，避免阻塞读取
+func (h *eventHub) publish(event Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeEvents 订阅默认播放器的事件
+func SubscribeEvents() (<-chan Event, func(), error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return nil, nil, fmt.Errorf("播放器初始化失败")
+	}
+	ch, cancel := defaultPlayer.events.subscribe()
+	return ch, cancel, nil
+}
@@ -0,0 +1,148 @@
+package player
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"aku-web/internal/history"
+	"aku-web/internal/library"
+)
+
+// maxListenStep 两次进度之间超过这个秒数视为跳转，不计入收听时长
+const maxListenStep = 3.0
+
+var (
+	historyStore *history.Store
+	historyMu    sync.Mutex
+)
+
+// SetHistory 设置播放历史，为空时不记录
+func SetHistory(store *history.Store) {
+	historyMu.Lock()
+	historyStore = store
+	historyMu.Unlock()
+}
+
+// listenSession 正在记录的一次播放
+type listenSession struct {
+	store *history.Store
+	entry history.Entry
+	last  float64 // 上一次的播放进度
+}
+
+// beginListen 新的音频加载成功后开始记录，调用方需持有 p.mutex
+func (p *AudioPlayer) beginListen(url string, item *QueueItem, duration *AudioDuration) {
+	historyMu.Lock()
+	store := historyStore
+	historyMu.Unlock()
+	if store == nil {
+		return
+	}
+
+	entry := history.Entry{Key: url}
+	if duration != nil {
+		entry.Duration = duration.TotalSeconds
+	}
+	if item != nil {
+		entry.Title = item.Title
+		entry.Artists = item.Artists
+		if item.SongId != 0 {
+			entry.Key = fmt.Sprintf("netease:%d", item.SongId)
+			entry.SongId = item.SongId
+		}
+	}
+
+	p.stateMu.RLock()
+	entry.Live, entry.Title = p.track.live, firstNonEmpty(entry.Title, p.track.name, p.track.station)
+	p.stateMu.RUnlock()
+
+	// 本地文件直接读取标签，网络地址等播放后端上报标签
+	if !isRemote(url) && !entry.Live {
+		if tags, err := library.ReadTags(url); err == nil {
+			entry.Title = firstNonEmpty(entry.Title, tags.Title)
+			entry.Album = tags.Album
+			if len(entry.Artists) == 0 && tags.Artist != "" {
+				entry.Artists = []string{tags.Artist}
+			}
+		}
+		entry.Title = firstNonEmpty(entry.Title, strings.TrimSuffix(filepath.Base(url), filepath.Ext(url)))
+	}
+
+	entry, err := store.Add(entry)
+	if err != nil {
+		log.Printf("[History] %v", err)
+	}
+	p.listenMu.Lock()
+	p.listen = &listenSession{store: store, entry: entry}
+	p.listenMu.Unlock()
+}
+
+// listenProgress 根据播放进度累计收听时长，暂停时没有进度，跳转的部分不计入
+func (p *AudioPlayer) listenProgress(position float64) {
+	p.listenMu.Lock()
+	defer p.listenMu.Unlock()
+	if p.listen == nil {
+		return
+	}
+	if step := position - p.listen.last; step > 0 && step <= maxListenStep {
+		p.listen.entry.Listened += step
+	}
+	p.listen.last = position
+}
+
+// listenTag 用播放后端上报的标签补充没有的歌曲信息
+func (p *AudioPlayer) listenTag(tag *TagInfo) {
+	if tag == nil {
+		return
+	}
+	p.listenMu.Lock()
+	defer p.listenMu.Unlock()
+	if p.listen == nil || p.listen.entry.Live {
+		return
+	}
+
+	entry := &p.listen.entry
+	value := strings.TrimSpace(tag.Value)
+	switch strings.ToLower(tag.Key) {
+	case "title":
+		entry.Title = firstNonEmpty(entry.Title, value)
+	case "artist":
+		if len(entry.Artists) == 0 && value != "" {
+			entry.Artists = []string{value}
+		}
+	case "album":
+		entry.Album = firstNonEmpty(entry.Album, value)
+	}
+}
+
+// finishListen 结束正在记录的播放并写入结果
+func (p *AudioPlayer) finishListen(result history.Result) {
+	p.listenMu.Lock()
+	session := p.listen
+	p.listen = nil
+	p.listenMu.Unlock()
+	if session == nil {
+		return
+	}
+
+	now := time.Now()
+	session.entry.Ended = &now
+	session.entry.Result = result
+	if err := session.store.Update(session.entry); err != nil {
+		log.Printf("[History] %v", err)
+	}
+}
+
+// firstNonEmpty 返回第一个不为空的字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package player
+
+import (
+	"io"
+	"strings"
+)
+
+// icyReader 去掉 ICY 流中穿插的元数据块，只返回音频数据。
+// 服务器每发送 metaint 字节音频后跟一个长度字节（乘以16）和对应长度的元数据
+type icyReader struct {
+	r       io.Reader
+	metaint int
+	remain  int // 距离下一个元数据块的字节数
+	onMeta  func(map[string]string)
+}
+
+// newICYReader 创建 ICY 读取器，metaint 为 0 时直接透传
+func newICYReader(r io.Reader, metaint int, onMeta func(map[string]string)) *icyReader {
+	return &icyReader{r: r, metaint: metaint, remain: metaint, onMeta: onMeta}
+}
+
+// Read 读取音频数据
+func (ir *icyReader) Read(p []byte) (int, error) {
+	if ir.metaint <= 0 {
+		return ir.r.Read(p)
+	}
+	if ir.remain == 0 {
+		if err := ir.readMeta(); err != nil {
+			return 0, err
+		}
+		ir.remain = ir.metaint
+	}
+	if len(p) > ir.remain {
+		p = p[:ir.remain]
+	}
+	n, err := ir.r.Read(p)
+	ir.remain -= n
+	return n, err
+}
+
+// readMeta 读取一个元数据块
+func (ir *icyReader) readMeta() error {
+	var length [1]byte
+	if _, err := io.ReadFull(ir.r, length[:]); err != nil {
+		return err
+	}
+	size := int(length[0]) * 16
+	if size == 0 {
+		return nil
+	}
+
+	block := make([]byte, size)
+	if _, err := io.ReadFull(ir.r, block); err != nil {
+		return err
+	}
+	if meta := parseICYMetadata(block); len(meta) > 0 && ir.onMeta != nil {
+		ir.onMeta(meta)
+	}
+	return nil
+}
+
+// parseICYMetadata 解析 StreamTitle='艺术家 - 标题';StreamUrl='...'; 格式的元数据
+func parseICYMetadata(block []byte) map[string]string {
+	s := strings.ToValidUTF8(strings.TrimRight(string(block), "\x00"), "")
+	meta := make(map[string]string)
+	for s != "" {
+		eq := strings.Index(s, "='")
+		if eq < 0 {
+			break
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = s[eq+2:]
+
+		end := strings.Index(s, "';")
+		if end < 0 {
+			end = strings.LastIndex(s, "'")
+			if end < 0 {
+				end = len(s)
+			}
+		}
+		meta[key] = strings.TrimSpace(s[:end])
+		if end+2 > len(s) {
+			break
+		}
+		s = s[end+2:]
+	}
+	return meta
+}
@@ -0,0 +1,304 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aku-web/internal/history"
+	"aku-web/internal/mixer"
+)
+
+// 直播流断线后的最大重连次数
+const maxLiveRetries = 5
+
+// 直播流没有尽头，不能设置整体超时，只限制等待响应头的时间
+var liveClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 15 * time.Second,
+	},
+}
+
+// errLiveWrite 播放后端不再读取管道数据
+var errLiveWrite = errors.New("写入直播管道失败")
+
+// openStream 请求直播流，并要求服务器发送 ICY 元数据
+func openStream(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Icy-MetaData", "1")
+
+	resp, err := liveClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// isLiveResponse 判断响应是否为没有尽头的直播流：带 ICY 头，或者长度未知的音频
+func isLiveResponse(resp *http.Response) bool {
+	if resp.Header.Get("icy-metaint") != "" || resp.Header.Get("icy-name") != "" {
+		return true
+	}
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	return resp.ContentLength < 0 &&
+		(strings.HasPrefix(contentType, "audio/") || strings.HasPrefix(contentType, "application/ogg"))
+}
+
+// detectLive 请求一次URL，是直播流时返回已打开的响应供播放使用
+func detectLive(url string) (*http.Response, bool) {
+	resp, err := openStream(url)
+	if err != nil {
+		return nil, false
+	}
+	if !isLiveResponse(resp) {
+		resp.Body.Close()
+		return nil, false
+	}
+	return resp, true
+}
+
+// liveStream 把直播流去掉 ICY 元数据后写入命名管道，由播放后端读取
+type liveStream struct {
+	url    string
+	fifo   *os.File
+	onMeta func(map[string]string)
+
+	resp    *http.Response
+	stopped bool
+	mutex   sync.Mutex
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// startLiveStream 创建命名管道并开始转发，返回后即可让后端加载 path
+func startLiveStream(url, path string, resp *http.Response, onMeta func(map[string]string)) (*liveStream, error) {
+	if err := makeFifo(path); err != nil {
+		return nil, fmt.Errorf("创建直播管道失败: %v", err)
+	}
+	// 以读写方式打开，不必等待后端打开读端
+	fifo, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("打开直播管道失败: %v", err)
+	}
+
+	l := &liveStream{
+		url:    url,
+		fifo:   fifo,
+		onMeta: onMeta,
+		resp:   resp,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+// run 转发直播流，网络中断时带退避重连，后端停止读取或调用 stop 后退出
+func (l *liveStream) run() {
+	defer close(l.done)
+	defer l.fifo.Close()
+
+	resp := l.resp
+	for attempt := 0; ; {
+		if resp != nil {
+			err := l.copy(resp)
+			resp.Body.Close()
+			if l.isStopped() || errors.Is(err, errLiveWrite) {
+				return
+			}
+			if err == nil || err == io.EOF {
+				log.Printf("[Live] 直播流结束: %s", l.url)
+				return
+			}
+			log.Printf("[Live] 直播流中断: %v", err)
+		}
+
+		if attempt >= maxLiveRetries {
+			log.Printf("[Live] 重连失败次数过多，停止播放: %s", l.url)
+			return
+		}
+		select {
+		case <-time.After(backoff(attempt)):
+		case <-l.quit:
+			return
+		}
+		attempt++
+
+		var err error
+		if resp, err = openStream(l.url); err != nil {
+			log.Printf("[Live] 重连失败: %v", err)
+			resp = nil
+			continue
+		}
+		if !l.setResponse(resp) {
+			resp.Body.Close()
+			return
+		}
+		attempt = 0
+		log.Printf("[Live] 已重新连接: %s", l.url)
+	}
+}
+
+// copy 把一次连接的数据写入管道
+func (l *liveStream) copy(resp *http.Response) error {
+	metaint, _ := strconv.Atoi(resp.Header.Get("icy-metaint"))
+	reader := newICYReader(resp.Body, metaint, l.onMeta)
+
+	buf := make([]byte, 16*1024)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if _, werr := l.fifo.Write(buf[:n]); werr != nil {
+				return fmt.Errorf("%w: %v", errLiveWrite, werr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// setResponse 记录当前连接，已经停止时返回 false
+func (l *liveStream) setResponse(resp *http.Response) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.stopped {
+		return false
+	}
+	l.resp = resp
+	return true
+}
+
+func (l *liveStream) isStopped() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.stopped
+}
+
+// stop 断开连接并关闭管道，等待转发协程退出
+func (l *liveStream) stop() {
+	l.mutex.Lock()
+	if l.stopped {
+		l.mutex.Unlock()
+		return
+	}
+	l.stopped = true
+	close(l.quit)
+	if l.resp != nil {
+		l.resp.Body.Close()
+	}
+	l.mutex.Unlock()
+
+	// 关闭管道让阻塞的写入返回
+	l.fifo.Close()
+	select {
+	case <-l.done:
+	case <-time.After(2 * time.Second):
+		log.Printf("[Live] 等待直播转发退出超时")
+	}
+}
+
+// livePipePath 直播管道路径
+func livePipePath() string {
+	return filepath.Join(os.TempDir(), "aku_live.fifo")
+}
+
+// playLive 播放直播流：不获取时长、不缓存，resp 为已经打开的连接。name 为电台名称，
+// 只有队列中的直播流才传入 item
+func (p *AudioPlayer) playLive(url, name string, item *QueueItem, resp *http.Response) (*AudioDuration, error) {
+	log.Printf("[Live] 开始播放直播流: %s", url)
+
+	p.setTrack(url, item, nil)
+	p.setLive(name, resp.Header.Get("icy-name"))
+	setBaseSource(mixer.SourceRadio)
+	defer p.setBuffering(false)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.isPlaying = false
+	p.stopLiveLocked()
+	p.finishListen(history.ResultSkipped)
+	fadeIn := p.takeFadeIn(p.advancing)
+	p.advancing = false
+
+	live, err := startLiveStream(url, livePipePath(), resp, p.handleICYMetadata)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	p.setTrackGain(nil) // 直播流没有响度信息
+	if err := p.backend.Load(livePipePath()); err != nil {
+		live.stop()
+		return nil, fmt.Errorf("加载音频失败: %v", err)
+	}
+	p.afterLoad(fadeIn)
+
+	p.live = live
+	p.duration = nil
+	p.currentFile = url
+	p.isPlaying = true
+	p.beginListen(url, item, nil)
+	return nil, nil
+}
+
+// stopLiveLocked 停止正在转发的直播流，调用方需持有 p.mutex
+func (p *AudioPlayer) stopLiveLocked() {
+	if p.live != nil {
+		p.live.stop()
+		p.live = nil
+	}
+}
+
+// handleICYMetadata 收到 StreamTitle 时更新正在播放的内容
+func (p *AudioPlayer) handleICYMetadata(meta map[string]string) {
+	title, ok := meta["StreamTitle"]
+	if !ok {
+		return
+	}
+
+	p.stateMu.Lock()
+	changed := p.track.nowPlaying != title
+	p.track.nowPlaying = title
+	if p.track.tags != nil {
+		p.track.tags["StreamTitle"] = title
+	}
+	p.stateMu.Unlock()
+
+	if changed {
+		log.Printf("[Live] 正在播放: %s", title)
+		p.events.publish(Event{Type: EventNowPlaying, Message: title})
+		p.notify()
+	}
+}
+
+// PlayLive 把URL作为直播流播放，name 为电台名称
+func PlayLive(url, name string) (*AudioDuration, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return nil, fmt.Errorf("播放器初始化失败")
+	}
+
+	resp, err := openStream(url)
+	if err != nil {
+		return nil, fmt.Errorf("连接电台失败: %v", err)
+	}
+	defaultPlayer.Stop()
+	return defaultPlayer.playLive(url, name, nil, resp)
+}
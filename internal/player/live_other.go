@@ -0,0 +1,10 @@
+//go:build !unix
+
+package player
+
+import "fmt"
+
+// makeFifo 当前平台不支持命名管道
+func makeFifo(path string) error {
+	return fmt.Errorf("当前平台不支持直播流")
+}
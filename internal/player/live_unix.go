@@ -0,0 +1,14 @@
+//go:build unix
+
+package player
+
+import (
+	"os"
+	"syscall"
+)
+
+// makeFifo 创建命名管道，已存在的文件会被替换
+func makeFifo(path string) error {
+	os.Remove(path)
+	return syscall.Mkfifo(path, 0600)
+}
@@ -2,16 +2,18 @@ package player
 
 import (
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"aku-web/internal/config"
+	"aku-web/internal/history"
+	"aku-web/internal/library"
+	"aku-web/internal/mixer"
+	"aku-web/internal/player/probe"
 )
 
 // AudioDuration 存储音频时长信息
@@ -23,47 +25,47 @@ type AudioDuration struct {
 	TotalFrames  int     // 总帧数
 }
 
-// CacheStatus 缓存状态
-type CacheStatus int
-
-const (
-	CacheStatusDownloading CacheStatus = iota
-	CacheStatusCompleted
-	CacheStatusError
-)
-
-// AudioCache 管理音频缓存
-type AudioCache struct {
-	CacheDir     string
-	MaxCacheSize int64
-	files        map[string]*CacheInfo
-	mutex        sync.RWMutex
-	downloadMu   sync.RWMutex
-}
-
-// CacheInfo 存储缓存文件信息
-type CacheInfo struct {
-	Path       string
-	Size       int64
-	LastAccess time.Time
-	Status     CacheStatus
-	Duration   *AudioDuration
-	ReadySize  int64 // 已下载的大小
-	mutex      sync.RWMutex
-}
-
 // AudioPlayer 音频播放器
 type AudioPlayer struct {
 	cache       *AudioCache
+	backend     Backend
 	currentFile string
 	duration    *AudioDuration
 	isPlaying   bool
 	mutex       sync.RWMutex
-
-	// 播放器控制
-	cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	stdout io.ReadCloser
+	queue       *Queue
+	events      *eventHub
+	live        *liveStream // 正在转发的直播流
+	advancing   bool        // 正在自动切换到队列下一首
+
+	next       *prefetched // 预先准备的队列下一首
+	prefetchMu sync.Mutex
+
+	// 播放进程的软件音量
+	duck       int                 // 避让其他声音时保留的音量百分比，100 为不降低
+	fade       float64             // 淡出淡入的系数，1 为原始音量
+	crossfade  int                 // 自动切换下一首时淡出淡入的秒数，0 表示关闭
+	fadeOutURL string              // 正在淡出的歌曲
+	fadeGen    int                 // 每次开始或取消淡出淡入时加一，旧的淡出淡入随之停止
+	rgMode     string              // 响度均衡模式
+	rgPreamp   float64             // 响度均衡的前级增益（dB）
+	trackGain  *library.ReplayGain // 当前歌曲的响度信息
+	rgGain     *float64            // 当前歌曲调整的分贝，不调整时为空
+	applied    float64             // 最近一次设置的软件音量
+	gainMu     sync.Mutex
+
+	gains *gainStore // 本地文件的响度测量结果
+
+	listen   *listenSession // 正在记录的播放历史
+	listenMu sync.Mutex
+
+	sleep   *sleepTimer // 睡眠定时器
+	sleepMu sync.Mutex
+
+	// 后端上报的实时状态
+	state   PlayState
+	track   trackState
+	stateMu sync.RWMutex
 }
 
 // 全局播放器实例
@@ -96,113 +98,59 @@ func GetAudioDuration(url string) (*AudioDuration, error) {
 
 // GetDuration 获取音频时长（使用当前播放器实例）
 func (p *AudioPlayer) GetDuration(url string) (*AudioDuration, error) {
-	p.mutex.Lock()
-	defer p.mutex.Unlock()
-
-	// 检查是否为常见的不支持格式
-	lowerUrl := strings.ToLower(url)
-	unsupportedFormats := []string{".wav", ".m4a", ".mp4", ".aac", ".ogg", ".flac", ".wma", ".aiff"}
-	for _, format := range unsupportedFormats {
-		if strings.HasSuffix(lowerUrl, format) {
-			return nil, fmt.Errorf("不支持的音频格式: %s，仅支持MP3格式", format)
-		}
-	}
-
-	// 初始化播放器（如果尚未初始化）
-	if err := p.initPlayer(); err != nil {
-		return nil, fmt.Errorf("初始化播放器失败: %v", err)
+	// 优先直接解析文件头，不影响正在进行的播放
+	if duration, err := p.probeDuration(url); err == nil {
+		return duration, nil
 	}
+	return p.backendDuration(url)
+}
 
-	// 一次性发送所有需要的命令
-	commands := []string{
-		fmt.Sprintf("LOAD %s", url),
-		"SILENCE",
-		"FORMAT",
-		"SAMPLE",
+// probeDuration 解析文件头获取时长
+func (p *AudioPlayer) probeDuration(url string) (*AudioDuration, error) {
+	info, err := p.probe(url)
+	if err != nil {
+		log.Printf("解析音频文件头失败: %v", err)
+		return nil, err
 	}
-
-	for _, cmd := range commands {
-		if err := p.sendCommand(cmd); err != nil {
-			return nil, fmt.Errorf("发送命令 %s 失败: %v", cmd, err)
-		}
+	if info.Duration <= 0 {
+		return nil, fmt.Errorf("无法从文件头获取时长")
 	}
+	log.Printf("音频信息: 格式=%s, 采样率=%d Hz, 声道=%d, 码率=%dkbps, 总时长=%.2f秒",
+		info.Format, info.SampleRate, info.Channels, info.Bitrate, info.Duration)
+	return newDuration(info.Duration, int(info.Duration*float64(info.SampleRate))), nil
+}
 
-	// 读取并解析输出
-	buf := make([]byte, 1024)
-	var sampleRate int
-	var totalSamples int64
-	formatFound := false
-	sampleFound := false
-
-	// 读取直到获取所需的所有信息
-	for attempts := 0; attempts < 20; attempts++ { // 设置最大尝试次数，避免无限循环
-		n, err := p.stdout.Read(buf)
-		if err != nil {
-			return nil, fmt.Errorf("读取输出失败: %v", err)
-		}
-
-		output := string(buf[:n])
-		log.Printf("[Debug] 收到输出: %s", output)
-		lines := strings.Split(output, "\n")
+// backendDuration 交给播放后端获取时长，会打断当前播放
+func (p *AudioPlayer) backendDuration(url string) (*AudioDuration, error) {
+	log.Printf("交给播放后端获取时长: %s", url)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
-			log.Printf("[Debug] 处理行: %s", line)
-			// 检查格式信息
-			if strings.HasPrefix(line, "@F") {
-				parts := strings.Fields(line)
-				if len(parts) >= 2 {
-					sampleRate, _ = strconv.Atoi(parts[1])
-					formatFound = true
-					log.Printf("[Debug] 解析到采样率: %d", sampleRate)
-				}
-			}
+	// 加载新的音频会打断当前播放
+	p.isPlaying = false
 
-			// 检查采样信息
-			if strings.HasPrefix(line, "@S") {
-				parts := strings.Fields(line)
-				if len(parts) >= 3 {
-					totalSamples, _ = strconv.ParseInt(parts[2], 10, 64)
-					sampleFound = true
-					log.Printf("[Debug] 解析到总采样数: %d", totalSamples)
-				}
-			}
-		}
+	return p.backend.Duration(url)
+}
 
-		// 如果已经找到所有需要的信息，就退出循环
-		if formatFound && sampleFound {
-			break
+// probe 解析音频信息：已缓存的完整文件直接读取，网络地址使用 Range 请求
+func (p *AudioPlayer) probe(url string) (*probe.Info, error) {
+	if cached := p.cache.GetCachedFile(url); cached != nil {
+		cached.mutex.RLock()
+		completed := cached.Status == CacheStatusCompleted
+		cached.mutex.RUnlock()
+		if completed {
+			return probe.File(cached.Path)
 		}
 	}
-
-	// 检查是否获取到所需信息
-	if sampleRate == 0 || totalSamples == 0 {
-		log.Printf("[Debug] 错误：数据不完整 - 采样率: %d, 总采样数: %d", sampleRate, totalSamples)
-		return nil, fmt.Errorf("无法获取完整的音频信息")
+	if !isRemote(url) {
+		return probe.File(url)
 	}
+	return probe.URL(url)
+}
 
-	// 停止当前加载的音频
-	p.sendCommand("STOP")
-
-	// 计算总时长（秒）
-	totalSeconds := float64(totalSamples) / float64(sampleRate)
-	minutes := int(totalSeconds) / 60
-	seconds := int(totalSeconds) % 60
-	centiseconds := int((totalSeconds - float64(int(totalSeconds))) * 100)
-
-	log.Printf("音频信息: 采样率=%d Hz, 总采样数=%d, 总时长=%.2f秒",
-		sampleRate, totalSamples, totalSeconds)
-
-	return &AudioDuration{
-		Minutes:      minutes,
-		Seconds:      seconds,
-		Centiseconds: centiseconds,
-		TotalSeconds: totalSeconds,
-		TotalFrames:  int(totalSamples), // 使用采样数作为帧数
-	}, nil
+// isRemote 判断是否为网络地址，其他的按本地文件处理
+func isRemote(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
 }
 
 // PlayStream 全局播放流媒体方法
@@ -249,91 +197,156 @@ func SeekTo(position float64) error {
 	return defaultPlayer.SeekTo(position)
 }
 
-// NewAudioPlayer 创建新的音频播放器实例
+// NewAudioPlayer 创建新的音频播放器实例，播放后端由 config.AudioBackend 决定
 func NewAudioPlayer(cacheDir string) (*AudioPlayer, error) {
-	cache := &AudioCache{
-		CacheDir:     cacheDir,
-		MaxCacheSize: 1024 * 1024 * 1024, // 1GB 缓存上限
-		files:        make(map[string]*CacheInfo),
+	backend, err := NewBackend(config.AudioBackend)
+	if err != nil {
+		return nil, err
 	}
+	return NewAudioPlayerWithBackend(cacheDir, backend)
+}
 
-	// 创建缓存目录
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		return nil, fmt.Errorf("创建缓存目录失败: %v", err)
+// NewAudioPlayerWithBackend 使用指定的播放后端创建音频播放器
+func NewAudioPlayerWithBackend(cacheDir string, backend Backend) (*AudioPlayer, error) {
+	cache, err := NewAudioCache(cacheDir, 1024*1024*1024) // 1GB 缓存上限
+	if err != nil {
+		return nil, err
 	}
 
-	return &AudioPlayer{
-		cache: cache,
-	}, nil
+	p := &AudioPlayer{
+		cache:     cache,
+		backend:   backend,
+		queue:     NewQueue(),
+		events:    newEventHub(),
+		duck:      100,
+		fade:      1,
+		crossfade: config.CrossfadeSeconds,
+		rgMode:    config.ReplayGainMode,
+		rgPreamp:  config.ReplayGainPreamp,
+		applied:   100,
+		gains:     newGainStore(cacheDir),
+	}
+	backend.SetEventHandler(p.handleEvent)
+	return p, nil
 }
 
 // PlayStream 改进的流媒体播放方法
 func (p *AudioPlayer) PlayStream(url string) (*AudioDuration, error) {
+	return p.play(url, nil)
+}
+
+// play 播放音频，item 不为空时表示来自播放队列
+func (p *AudioPlayer) play(url string, item *QueueItem) (*AudioDuration, error) {
 	log.Printf("[PlayStream] 开始播放，URL: %s", url)
+	if item != nil {
+		RememberSongURL(url, item.SongId)
+	}
 
-	// 先获取音频时长信息
-	duration, err := p.GetDuration(url)
-	if err != nil {
-		log.Printf("[PlayStream] 获取音频时长失败: %v", err)
-		return nil, err
+	// 先获取音频时长信息，预先缓存过的歌曲已经知道时长
+	var err error
+	duration := p.cachedDuration(url)
+	if duration == nil {
+		duration, err = p.probeDuration(url)
+	}
+	if duration == nil {
+		// 文件头的 Range 请求能拿到文件大小时不会是直播流，只有解析失败的网络地址才检查一次，
+		// 网易云歌曲不可能是直播流
+		if isRemote(url) && (item == nil || item.SongId == 0) {
+			if resp, ok := detectLive(url); ok {
+				return p.playLive(url, "", item, resp)
+			}
+		}
+		if duration, err = p.backendDuration(url); err != nil {
+			log.Printf("[PlayStream] 获取音频时长失败: %v", err)
+			return nil, err
+		}
 	}
-	p.duration = duration
 	log.Printf("[PlayStream] 获取音频时长成功: %.2f秒", duration.TotalSeconds)
+	p.setTrack(url, item, duration)
+	setBaseSource(mixer.SourceMusic)
+	defer p.setBuffering(false)
 
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
+	p.duration = duration
 
-	log.Printf("[PlayStream] 开始播放，URL: %s", url)
+	// 即将加载新的音频，之前的播放结束不应触发自动下一首
+	p.isPlaying = false
+	p.stopLiveLocked()
+	p.finishListen(history.ResultSkipped)
+	fadeIn := p.takeFadeIn(p.advancing)
+	p.advancing = false
+
+	// 本地文件直接交给播放后端，不需要缓存
+	if !isRemote(url) {
+		if _, err := os.Stat(url); err != nil {
+			return nil, fmt.Errorf("音频文件不存在: %v", err)
+		}
+		p.setTrackGain(p.lookupGain(url, url))
+		if err := p.backend.Load(url); err != nil {
+			return nil, fmt.Errorf("加载音频失败: %v", err)
+		}
+		p.afterLoad(fadeIn)
+		p.currentFile = url
+		p.isPlaying = true
+		p.beginListen(url, item, duration)
+		return duration, nil
+	}
 
 	// 开始缓存并获取缓存信息
-	cacheInfo := p.startCaching(url, duration)
+	cacheInfo := p.startCaching(url, duration, refresherFor(item))
 	if cacheInfo == nil {
 		return nil, fmt.Errorf("创建缓存失败")
 	}
 
-	// 初始化播放器
-	if err := p.initPlayer(); err != nil {
-		log.Printf("[PlayStream] 初始化播放器失败: %v", err)
-		return nil, err
-	}
-
 	// 等待足够的数据被缓存（至少1MB或文件大小的10%）
 	const minBufferSize = 1024 * 1024 // 1MB
-	for {
-		cacheInfo.mutex.RLock()
-		readySize := cacheInfo.ReadySize
-		cacheInfo.mutex.RUnlock()
-
-		if readySize >= minBufferSize || (cacheInfo.Size > 0 && readySize >= cacheInfo.Size/10) {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
+	err = cacheInfo.waitFor(func() bool {
+		return cacheInfo.Status == CacheStatusCompleted ||
+			cacheInfo.ReadySize >= minBufferSize ||
+			(cacheInfo.Size > 0 && cacheInfo.ReadySize >= cacheInfo.Size/10)
+	}, 0)
+	if err != nil {
+		return nil, err
 	}
 
 	// 开始播放
 	log.Printf("开始播放: %s", cacheInfo.Path)
-	if err := p.sendCommand(fmt.Sprintf("LOAD %s", cacheInfo.Path)); err != nil {
+	p.setTrackGain(p.lookupGain(url, cacheInfo.Path))
+	if err := p.backend.Load(cacheInfo.Path); err != nil {
 		return nil, fmt.Errorf("加载音频失败: %v", err)
 	}
+	p.afterLoad(fadeIn)
 
 	p.currentFile = url
 	p.isPlaying = true
+	p.beginListen(url, item, duration)
 
 	return duration, nil
 }
 
 // startCaching 开始缓存音频文件
-func (p *AudioPlayer) startCaching(url string, duration *AudioDuration) *CacheInfo {
+// refresh 在下载地址过期时重新获取地址，可以为空
+func (p *AudioPlayer) startCaching(url string, duration *AudioDuration, refresh func() (string, error)) *CacheInfo {
 	p.cache.downloadMu.Lock()
 	defer p.cache.downloadMu.Unlock()
 
-	// 检查是否已经在下载或已缓存
+	// 检查是否已经在下载或已缓存，下载失败的缓存重新下载
 	if cached := p.cache.GetCachedFile(url); cached != nil {
-		return cached // 返回现有缓存
+		cached.mutex.RLock()
+		failed := cached.Status == CacheStatusError
+		cached.mutex.RUnlock()
+		if !failed {
+			if cached.Duration == nil {
+				cached.Duration = duration
+			}
+			return cached // 返回现有缓存
+		}
+		p.cache.remove(url)
 	}
 
-	// 在缓存目录中创建临时文件
-	tmpFile, err := os.CreateTemp(p.cache.CacheDir, "audio_*")
+	// 在缓存目录中创建缓存文件，文件名由URL哈希决定
+	tmpFile, err := os.Create(p.cache.pathFor(url))
 	if err != nil {
 		log.Printf("创建临时文件失败: %v", err)
 		return nil
@@ -349,86 +362,26 @@ func (p *AudioPlayer) startCaching(url string, duration *AudioDuration) *CacheIn
 	}
 
 	// 添加到缓存管理
-	p.cache.mutex.Lock()
-	p.cache.files[url] = cacheInfo
-	p.cache.mutex.Unlock()
+	p.cache.add(url, cacheInfo)
 
 	// 启动下载协程
-	go p.downloadAndCache(url, tmpFile, cacheInfo)
+	go p.downloadAndCache(url, tmpFile, cacheInfo, refresh)
 
 	return cacheInfo
 }
 
 // downloadAndCache 下载并缓存音频文件
-func (p *AudioPlayer) downloadAndCache(url string, tmpFile *os.File, info *CacheInfo) {
-	defer tmpFile.Close()
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	// 获取文件大小
-	resp, err := client.Head(url)
-	if err != nil {
-		log.Printf("获取文件信息失败: %v", err)
-		info.Status = CacheStatusError
-		return
-	}
-	fileSize := resp.ContentLength
-
-	// 使用512KB的分片大小，适合音频流
-	const chunkSize = 512 * 1024
-	chunks := (fileSize + chunkSize - 1) / chunkSize
-
-	// 预分配文件大小
-	if err := tmpFile.Truncate(fileSize); err != nil {
-		log.Printf("预分配文件大小失败: %v", err)
-		info.Status = CacheStatusError
+func (p *AudioPlayer) downloadAndCache(url string, tmpFile *os.File, info *CacheInfo, refresh func() (string, error)) {
+	if err := newDownloader(url, tmpFile, info, refresh).run(); err != nil {
+		log.Printf("缓存音频失败: %v", err)
 		return
 	}
-
-	// 按顺序下载分片，确保流式播放的连续性
-	for i := int64(0); i < chunks; i++ {
-		start := i * chunkSize
-		end := start + chunkSize - 1
-		if end > fileSize {
-			end = fileSize - 1
-		}
-
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			log.Printf("创建请求失败: %v", err)
-			continue
-		}
-		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
-
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Printf("下载分片失败: %v", err)
-			continue
-		}
-
-		if _, err := tmpFile.Seek(start, 0); err != nil {
-			resp.Body.Close()
-			log.Printf("设置文件位置失败: %v", err)
-			continue
-		}
-
-		written, err := io.Copy(tmpFile, resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			log.Printf("写入分片失败: %v", err)
-			continue
-		}
-
-		// 更新已下载大小
-		info.mutex.Lock()
-		info.ReadySize = start + written
-		info.mutex.Unlock()
-	}
-
-	info.Size = fileSize
-	info.Status = CacheStatusCompleted
+	// 正在播放的歌曲可能不是刚下载完的这首（例如预先缓存的下一首），也不能淘汰
+	p.stateMu.RLock()
+	current := p.track.url
+	p.stateMu.RUnlock()
+	p.cache.complete(url, current)
+	p.analyzeCached(url)
 }
 
 // SeekTo 改进的跳转方法
@@ -441,10 +394,8 @@ func (p *AudioPlayer) SeekTo(position float64) error {
 	if !p.isPlaying {
 		return fmt.Errorf("播放器未在播放状态")
 	}
-
-	cached := p.cache.GetCachedFile(p.currentFile)
-	if cached == nil {
-		return fmt.Errorf("未找到缓存文件")
+	if p.live != nil {
+		return fmt.Errorf("直播流不支持跳转")
 	}
 
 	// 检查跳转位置是否有效
@@ -452,124 +403,114 @@ func (p *AudioPlayer) SeekTo(position float64) error {
 		return fmt.Errorf("跳转位置 (%.2f) 超出音频总长度 (%.2f)", position, p.duration.TotalSeconds)
 	}
 
-	// 等待缓存追赶到目标位置
-	maxWaitTime := 5 * time.Second
-	startWait := time.Now()
-
-	for {
+	var cached *CacheInfo
+	var totalSize int64
+	if isRemote(p.currentFile) {
+		cached = p.cache.GetCachedFile(p.currentFile)
+		if cached == nil {
+			return fmt.Errorf("未找到缓存文件")
+		}
 		cached.mutex.RLock()
-		readySize := cached.ReadySize
-		totalSize := cached.Size
-		status := cached.Status
+		totalSize = cached.Size
 		cached.mutex.RUnlock()
+	}
 
-		// 如果已经完成下载，或者已缓存的数据足够，就可以进行跳转
-		estimatedRequiredSize := int64(0)
-		if p.duration != nil && totalSize > 0 {
-			estimatedRequiredSize = int64(float64(totalSize) * (position / p.duration.TotalSeconds))
-		}
-		log.Printf("estimatedRequiredSize: %d, readySize: %d, status: %d", estimatedRequiredSize, readySize, status)
-		if status == CacheStatusCompleted || readySize >= estimatedRequiredSize {
-			break
+	// 按时长比例估算目标位置的字节偏移，让下载器优先下载这一段
+	if cached != nil && p.duration != nil && p.duration.TotalSeconds > 0 && totalSize > 0 {
+		const seekBufferSize = 256 * 1024
+		offset := int64(float64(totalSize) * (position / p.duration.TotalSeconds))
+		end := offset + seekBufferSize
+		if end > totalSize {
+			end = totalSize
 		}
+		log.Printf("[SeekTo] 目标位置约在 %d 字节处", offset)
 
-		// 检查是否超时
-		if time.Since(startWait) > maxWaitTime {
-			return fmt.Errorf("等待缓存超时，目标位置的数据尚未下载完成")
+		cached.prioritize(offset)
+		if err := cached.waitRange(offset, end, 10*time.Second); err != nil {
+			return err
 		}
-
-		// 等待100ms后重试
-		time.Sleep(100 * time.Millisecond)
 	}
 
-	// 发送跳转命令
-	if err := p.sendCommand(fmt.Sprintf("JUMP %fs", position)); err != nil {
+	// 发送跳转命令，后端在跳转完成后上报 EventJump
+	events, cancel := p.events.subscribe()
+	defer cancel()
+	if err := p.backend.Seek(position); err != nil {
 		return fmt.Errorf("跳转命令执行失败: %v", err)
 	}
 
-	// 等待跳转完成的反馈
-	success := make(chan bool)
 	timeout := time.After(2 * time.Second)
-
-	go func() {
-		buf := make([]byte, 1024)
-		for {
-			n, err := p.stdout.Read(buf)
-			if err != nil {
-				success <- false
-				return
-			}
-			output := string(buf[:n])
-			// mpg123 在成功跳转后会输出包含 "@J" 的消息
-			if strings.Contains(output, "@J") {
-				success <- true
-				return
+	for {
+		select {
+		case event := <-events:
+			switch event.Type {
+			case EventJump:
+				log.Printf("[SeekTo] 成功跳转到 %.2f 秒", position)
+				return nil
+			case EventError:
+				return fmt.Errorf("跳转操作失败: %s", event.Message)
 			}
+		case <-timeout:
+			return fmt.Errorf("跳转操作超时")
 		}
-	}()
+	}
+}
 
-	select {
-	case result := <-success:
-		if !result {
-			return fmt.Errorf("跳转操作失败")
+// handleEvent 处理后端上报的事件并转发给订阅者，不能阻塞
+func (p *AudioPlayer) handleEvent(event Event) {
+	switch event.Type {
+	case EventState:
+		p.stateMu.Lock()
+		p.state = *event.State
+		p.stateMu.Unlock()
+	case EventTag:
+		p.setTag(event.Tag)
+		p.listenTag(event.Tag)
+	case EventFrame:
+		if event.Frame != nil {
+			p.listenProgress(event.Frame.Seconds)
+			p.onProgress(event.Frame.Seconds)
 		}
-	case <-timeout:
-		return fmt.Errorf("跳转操作超时")
+	case EventTrackEnd:
+		go p.onTrackEnd()
+	case EventError:
+		log.Printf("[%s] 错误: %s", p.backend.Name(), event.Message)
 	}
 
-	log.Printf("[SeekTo] 成功跳转到 %.2f 秒", position)
-	return nil
+	p.events.publish(event)
 }
 
-// GetCachedFile 获取缓存的文件信息
-func (c *AudioCache) GetCachedFile(url string) *CacheInfo {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-
-	if info, exists := c.files[url]; exists {
-		info.LastAccess = time.Now()
-		return info
-	}
-	return nil
+// State 获取后端上报的播放状态和当前位置（秒）
+func (p *AudioPlayer) State() (PlayState, float64) {
+	p.stateMu.RLock()
+	defer p.stateMu.RUnlock()
+	return p.state, p.backend.Position()
 }
 
-// initPlayer 初始化播放器
-func (p *AudioPlayer) initPlayer() error {
-	if p.cmd != nil {
-		return nil
-	}
+// onTrackEnd 确认仍处于播放状态后，队列中的歌曲交给播放队列处理
+func (p *AudioPlayer) onTrackEnd() {
+	p.mutex.RLock()
+	playing := p.isPlaying
+	p.mutex.RUnlock()
 
-	cmd := exec.Command("mpg123", "-R")
-	var err error
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("无法获取标准输入管道: %v", err)
-	}
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("无法获取标准输出管道: %v", err)
+	if !playing {
+		return
 	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("无法启动 mpg123: %v", err)
+	p.finishListen(history.ResultCompleted)
+	if p.sleepOnTrackEnd() {
+		return
 	}
 
-	p.cmd = cmd
-	p.stdin = stdin
-	p.stdout = stdout
-
-	return nil
-}
-
-// sendCommand 发送命令到 mpg123
-func (p *AudioPlayer) sendCommand(cmd string) error {
-	if p.stdin == nil {
-		return fmt.Errorf("播放器未初始化")
+	// 只有队列中的歌曲结束时才播放下一首，单独播放的地址和电台结束后停止
+	p.stateMu.RLock()
+	queued := p.track.item != nil
+	p.stateMu.RUnlock()
+	if queued {
+		p.handleTrackEnd()
+		return
 	}
-	_, err := fmt.Fprintf(p.stdin, "%s\n", cmd)
-	return err
+	p.mutex.Lock()
+	p.isPlaying = false
+	p.mutex.Unlock()
 }
 
 // Pause 暂停播放
@@ -581,7 +522,7 @@ func (p *AudioPlayer) Pause() error {
 		return fmt.Errorf("没有正在播放的音频")
 	}
 
-	if err := p.sendCommand("PAUSE"); err != nil {
+	if err := p.backend.Pause(); err != nil {
 		return fmt.Errorf("暂停失败: %v", err)
 	}
 
@@ -597,7 +538,7 @@ func (p *AudioPlayer) Resume() error {
 		return fmt.Errorf("没有正在播放的音频")
 	}
 
-	if err := p.sendCommand("PAUSE"); err != nil { // mpg123 的 PAUSE 命令是切换暂停/继续状态
+	if err := p.backend.Resume(); err != nil {
 		return fmt.Errorf("继续播放失败: %v", err)
 	}
 
@@ -609,13 +550,14 @@ func (p *AudioPlayer) Stop() {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if p.cmd != nil {
-		p.sendCommand("STOP")
-		p.sendCommand("QUIT")
-		p.cmd.Process.Kill()
-		p.stdin = nil
-		p.stdout = nil
-		p.cmd = nil
+	if err := p.backend.Stop(); err != nil {
+		log.Printf("停止播放失败: %v", err)
 	}
 	p.isPlaying = false
+	p.stopLiveLocked()
+	p.finishListen(history.ResultStopped)
+
+	p.stateMu.Lock()
+	p.state = StateStopped
+	p.stateMu.Unlock()
 }
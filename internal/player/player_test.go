@@ -0,0 +1,222 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestPlayer 创建使用假播放后端的播放器，files 为在临时目录中创建的音频文件名，
+// 文件内容无法解析，时长由假后端按 Durations 返回
+func newTestPlayer(t *testing.T, files ...string) (*AudioPlayer, *FakeBackend, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	backend := NewFakeBackend()
+	p, err := NewAudioPlayerWithBackend(filepath.Join(dir, "cache"), backend)
+	if err != nil {
+		t.Fatalf("创建播放器失败: %v", err)
+	}
+	p.crossfade = 0
+
+	paths := make([]string, len(files))
+	for i, name := range files {
+		paths[i] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[i], []byte("not audio"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		backend.Durations[paths[i]] = float64(100 * (i + 1))
+	}
+	t.Cleanup(p.Stop)
+	return p, backend, paths
+}
+
+// eventually 等待条件成立，事件回调中的自动切歌在其他协程中进行
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// countCommands 统计假后端收到的以 prefix 开头的命令
+func countCommands(backend *FakeBackend, prefix string) int {
+	n := 0
+	for _, command := range backend.Commands() {
+		if strings.HasPrefix(command, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (p *AudioPlayer) playing() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.isPlaying
+}
+
+func TestPlayPauseResumeStop(t *testing.T) {
+	p, backend, paths := newTestPlayer(t, "a.mp3")
+
+	duration, err := p.PlayStream(paths[0])
+	if err != nil {
+		t.Fatalf("播放失败: %v", err)
+	}
+	if duration.TotalSeconds != 100 {
+		t.Errorf("时长 = %v, 期望 100", duration.TotalSeconds)
+	}
+	if backend.Loaded() != paths[0] {
+		t.Errorf("加载的文件 = %q, 期望 %q", backend.Loaded(), paths[0])
+	}
+	if state, _ := p.State(); state != StatePlaying {
+		t.Errorf("播放后状态 = %s", state)
+	}
+	if status := p.Status(); status.URL != paths[0] || status.State != "playing" || status.Duration != 100 {
+		t.Errorf("播放状态 = %+v", status)
+	}
+
+	if err := p.Pause(); err != nil {
+		t.Fatalf("暂停失败: %v", err)
+	}
+	if state, _ := p.State(); state != StatePaused {
+		t.Errorf("暂停后状态 = %s", state)
+	}
+	if err := p.Resume(); err != nil {
+		t.Fatalf("继续播放失败: %v", err)
+	}
+	if state, _ := p.State(); state != StatePlaying {
+		t.Errorf("继续播放后状态 = %s", state)
+	}
+
+	p.Stop()
+	if state, _ := p.State(); state != StateStopped {
+		t.Errorf("停止后状态 = %s", state)
+	}
+	if err := p.Pause(); err == nil {
+		t.Error("停止后暂停没有返回错误")
+	}
+	if err := p.Resume(); err == nil {
+		t.Error("停止后继续播放没有返回错误")
+	}
+}
+
+func TestPlayMissingFile(t *testing.T) {
+	p, backend, _ := newTestPlayer(t)
+	missing := filepath.Join(t.TempDir(), "missing.mp3")
+	backend.Durations[missing] = 100
+
+	if _, err := p.PlayStream(missing); err == nil {
+		t.Error("播放不存在的文件没有返回错误")
+	}
+	if countCommands(backend, "LOAD") != 0 {
+		t.Errorf("不存在的文件也交给了后端: %v", backend.Commands())
+	}
+}
+
+func TestSeek(t *testing.T) {
+	p, backend, paths := newTestPlayer(t, "a.mp3")
+
+	if err := p.SeekTo(10); err == nil {
+		t.Error("没有播放时跳转没有返回错误")
+	}
+	if _, err := p.PlayStream(paths[0]); err != nil {
+		t.Fatalf("播放失败: %v", err)
+	}
+
+	if err := p.SeekTo(30); err != nil {
+		t.Fatalf("跳转失败: %v", err)
+	}
+	if backend.Position() != 30 {
+		t.Errorf("跳转后位置 = %v, 期望 30", backend.Position())
+	}
+	if err := p.SeekTo(101); err == nil {
+		t.Error("超出时长的跳转没有返回错误")
+	}
+	if backend.Position() != 30 {
+		t.Errorf("跳转失败后位置 = %v, 期望不变", backend.Position())
+	}
+
+	backend.Advance(5)
+	if status := p.Status(); status.Position != 35 {
+		t.Errorf("播放 5 秒后位置 = %v, 期望 35", status.Position)
+	}
+}
+
+func TestTrackEndStopsSinglePlay(t *testing.T) {
+	p, backend, paths := newTestPlayer(t, "a.mp3", "b.mp3")
+
+	// 队列中有歌曲，但单独播放的文件结束后不应该开始播放队列
+	p.queue.Append(QueueItem{Title: "b", URL: paths[1]})
+	if _, err := p.PlayStream(paths[0]); err != nil {
+		t.Fatalf("播放失败: %v", err)
+	}
+
+	backend.Finish()
+	eventually(t, "播放结束", func() bool { return !p.playing() })
+	if n := countCommands(backend, "LOAD"); n != 1 {
+		t.Errorf("加载了 %d 次, 期望 1 次: %v", n, backend.Commands())
+	}
+	if _, ok := p.queue.Current(); ok {
+		t.Error("单独播放结束后队列前进了")
+	}
+}
+
+func TestQueueAdvance(t *testing.T) {
+	p, backend, paths := newTestPlayer(t, "a.mp3", "b.mp3", "c.mp3")
+
+	items := p.queue.Append(
+		QueueItem{Title: "a", URL: paths[0]},
+		QueueItem{Title: "b", URL: paths[1]},
+		QueueItem{Title: "c", URL: paths[2]},
+	)
+	item, err := p.queue.Jump(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.playItem(item); err != nil {
+		t.Fatalf("播放队列失败: %v", err)
+	}
+
+	// 每首歌结束后自动播放下一首
+	for i := 1; i < len(paths); i++ {
+		backend.Finish()
+		want := paths[i]
+		eventually(t, "播放 "+items[i].Title, func() bool { return backend.Loaded() == want && p.playing() })
+
+		current, ok := p.queue.Current()
+		if !ok || current.ID != items[i].ID {
+			t.Errorf("当前队列项 = %+v, 期望 %s", current, items[i].Title)
+		}
+		if status := p.Status(); status.Track == nil || status.Track.ID != items[i].ID || status.Duration != float64(100*(i+1)) {
+			t.Errorf("播放状态 = %+v", status)
+		}
+	}
+
+	// 最后一首结束后停止
+	backend.Finish()
+	eventually(t, "队列结束", func() bool { return !p.playing() })
+	if n := countCommands(backend, "LOAD"); n != len(paths) {
+		t.Errorf("加载了 %d 次, 期望 %d 次: %v", n, len(paths), backend.Commands())
+	}
+
+	// 单曲循环时重复当前歌曲
+	if err := p.queue.SetRepeat(RepeatOne); err != nil {
+		t.Fatal(err)
+	}
+	item, _ = p.queue.Jump(1)
+	if _, err := p.playItem(item); err != nil {
+		t.Fatalf("播放队列失败: %v", err)
+	}
+	loads := countCommands(backend, "LOAD")
+	backend.Finish()
+	eventually(t, "重复播放", func() bool { return countCommands(backend, "LOAD") == loads+1 && p.playing() })
+	if backend.Loaded() != paths[1] {
+		t.Errorf("单曲循环加载了 %q, 期望 %q", backend.Loaded(), paths[1])
+	}
+}
@@ -0,0 +1,130 @@
+package player
+
+import (
+	"log"
+)
+
+// prefetched 预先准备的队列下一首
+type prefetched struct {
+	id  string // QueueItem.ID
+	url string // 解析出的播放地址，解析完成前为空
+}
+
+// onProgress 播放队列中的歌曲时，根据播放进度预取下一首并在需要时开始淡出
+func (p *AudioPlayer) onProgress(position float64) {
+	p.stateMu.RLock()
+	queued := p.track.item != nil && !p.track.live
+	p.stateMu.RUnlock()
+	if !queued {
+		return
+	}
+
+	p.prefetchNext()
+	p.checkCrossfade(position)
+}
+
+// prefetchNext 准备队列中的下一首，每首歌只准备一次
+func (p *AudioPlayer) prefetchNext() {
+	next, ok := p.queue.PeekNext()
+	if !ok {
+		return
+	}
+
+	p.prefetchMu.Lock()
+	if p.next != nil && p.next.id == next.ID {
+		p.prefetchMu.Unlock()
+		return
+	}
+	p.next = &prefetched{id: next.ID}
+	p.prefetchMu.Unlock()
+
+	go p.prepare(next)
+}
+
+// prepare 解析下一首的地址，在当前歌曲缓存完成后开始缓存下一首，自动切换时不需要再等待网络
+func (p *AudioPlayer) prepare(item QueueItem) {
+	url := item.URL
+	if url == "" {
+		if urlResolver == nil {
+			return
+		}
+		resolved, err := urlResolver(item)
+		if err != nil {
+			log.Printf("[Queue] 预先解析下一首失败: %v", err)
+			return
+		}
+		url = resolved
+	}
+	if !p.setPrefetchedURL(item.ID, url) || !isRemote(url) {
+		return
+	}
+	RememberSongURL(url, item.SongId)
+
+	// 等当前歌曲缓存完成再下载，避免争抢带宽
+	p.stateMu.RLock()
+	current := p.track.url
+	p.stateMu.RUnlock()
+	if cached := p.cache.peek(current); cached != nil {
+		cached.waitFor(func() bool {
+			return cached.Status != CacheStatusDownloading
+		}, 0)
+	}
+	if !p.isPrefetching(item.ID) {
+		return
+	}
+
+	// 读不到时长的可能是直播流，不能缓存
+	info, err := p.probe(url)
+	if err != nil || info.Duration <= 0 {
+		log.Printf("[Queue] 下一首无法预先缓存: %s", item.Title)
+		return
+	}
+	duration := newDuration(info.Duration, int(info.Duration*float64(info.SampleRate)))
+	if p.startCaching(url, duration, refresherFor(&item)) != nil {
+		log.Printf("[Queue] 预先缓存下一首: %s", item.Title)
+	}
+}
+
+// setPrefetchedURL 记录解析出的地址，下一首已经变化时返回 false
+func (p *AudioPlayer) setPrefetchedURL(id, url string) bool {
+	p.prefetchMu.Lock()
+	defer p.prefetchMu.Unlock()
+	if p.next == nil || p.next.id != id {
+		return false
+	}
+	p.next.url = url
+	return true
+}
+
+// isPrefetching 判断是否仍在准备指定的队列项
+func (p *AudioPlayer) isPrefetching(id string) bool {
+	p.prefetchMu.Lock()
+	defer p.prefetchMu.Unlock()
+	return p.next != nil && p.next.id == id
+}
+
+// takePrefetched 取出为队列项预先解析的地址，没有时返回空
+func (p *AudioPlayer) takePrefetched(id string) string {
+	p.prefetchMu.Lock()
+	defer p.prefetchMu.Unlock()
+	if p.next == nil || p.next.id != id {
+		return ""
+	}
+	url := p.next.url
+	p.next = nil
+	return url
+}
+
+// cachedDuration 返回已缓存或预先缓存的歌曲的时长，没有时返回空
+func (p *AudioPlayer) cachedDuration(url string) *AudioDuration {
+	cached := p.cache.peek(url)
+	if cached == nil {
+		return nil
+	}
+	cached.mutex.RLock()
+	defer cached.mutex.RUnlock()
+	if cached.Status == CacheStatusError {
+		return nil
+	}
+	return cached.Duration
+}
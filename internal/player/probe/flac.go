@@ -0,0 +1,38 @@
+package probe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// probeFLAC 解析 STREAMINFO 元数据块
+func probeFLAC(r io.ReaderAt, size int64) (*Info, error) {
+	// "fLaC" 之后第一个元数据块必须是 STREAMINFO
+	header, err := readAt(r, 4, 4+34)
+	if err != nil || len(header) < 38 {
+		return nil, fmt.Errorf("FLAC 文件头不完整")
+	}
+	if header[0]&0x7F != 0 {
+		return nil, fmt.Errorf("FLAC 缺少 STREAMINFO")
+	}
+
+	// 跳过最小/最大块大小（4字节）和最小/最大帧大小（6字节）
+	// 之后 64 位：20 位采样率，3 位声道数-1，5 位位深-1，36 位总采样数
+	bits := binary.BigEndian.Uint64(header[4+10 : 4+18])
+	sampleRate := int(bits >> 44)
+	channels := int(bits>>41&0x07) + 1
+	totalSamples := int64(bits & 0xFFFFFFFFF)
+
+	if sampleRate == 0 {
+		return nil, fmt.Errorf("FLAC 采样率无效")
+	}
+
+	info := &Info{
+		Format:     "flac",
+		SampleRate: sampleRate,
+		Channels:   channels,
+		Duration:   float64(totalSamples) / float64(sampleRate),
+	}
+	return info, nil
+}
@@ -0,0 +1,122 @@
+package probe
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// URL 通过 HTTP Range 请求只读取需要的部分（文件头和文件尾）
+func URL(url string) (*Info, error) {
+	size, err := contentLength(url)
+	if err != nil {
+		return nil, err
+	}
+	r := &rangeReader{url: url, size: size, blocks: make(map[int64][]byte)}
+	return probe(r, size, false)
+}
+
+// contentLength 通过 Range 请求确认服务器支持分段下载，并获取文件总大小
+func contentLength(url string) (int64, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("服务器不支持分段请求: %s", resp.Status)
+	}
+	// Content-Range: bytes 0-0/12345
+	contentRange := resp.Header.Get("Content-Range")
+	i := strings.LastIndex(contentRange, "/")
+	if i < 0 {
+		return 0, fmt.Errorf("无效的 Content-Range: %s", contentRange)
+	}
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("无法获取文件大小: %s", contentRange)
+	}
+	return size, nil
+}
+
+// rangeReader 以 64KB 为单位按需下载并缓存数据块
+type rangeReader struct {
+	url    string
+	size   int64
+	blocks map[int64][]byte
+}
+
+const rangeBlockSize = 64 * 1024
+
+// ReadAt 实现 io.ReaderAt
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && off < r.size {
+		index := off / rangeBlockSize
+		block, err := r.block(index)
+		if err != nil {
+			return n, err
+		}
+		within := off - index*rangeBlockSize
+		if within >= int64(len(block)) {
+			return n, io.ErrUnexpectedEOF
+		}
+		copied := copy(p[n:], block[within:])
+		n += copied
+		off += int64(copied)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// block 获取指定编号的数据块
+func (r *rangeReader) block(index int64) ([]byte, error) {
+	if block, ok := r.blocks[index]; ok {
+		return block, nil
+	}
+
+	start := index * rangeBlockSize
+	end := start + rangeBlockSize - 1
+	if end >= r.size {
+		end = r.size - 1
+	}
+
+	req, err := http.NewRequest("GET", r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("分段请求失败: %s", resp.Status)
+	}
+	block, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.blocks[index] = block
+	return block, nil
+}
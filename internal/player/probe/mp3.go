@@ -0,0 +1,269 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// MPEG 版本
+const (
+	mpeg25 = 0
+	mpeg2  = 2
+	mpeg1  = 3
+)
+
+// 码率表（kbps），按 [MPEG1?][layer] 索引
+var bitrateTable = [2][4][16]int{
+	// MPEG2/2.5
+	{
+		{},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},      // Layer III
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},      // Layer II
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0}, // Layer I
+	},
+	// MPEG1
+	{
+		{},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},     // Layer III
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},    // Layer II
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0}, // Layer I
+	},
+}
+
+// 采样率表，按 [version][index] 索引
+var sampleRateTable = [4][3]int{
+	mpeg25: {11025, 12000, 8000},
+	mpeg2:  {22050, 24000, 16000},
+	mpeg1:  {44100, 48000, 32000},
+}
+
+// mp3Frame MPEG 音频帧头
+type mp3Frame struct {
+	version    int
+	layer      int // 1: Layer III, 2: Layer II, 3: Layer I（与帧头编码一致）
+	bitrate    int // kbps
+	sampleRate int
+	channels   int
+	size       int // 帧长度（字节）
+	samples    int // 每帧采样数
+}
+
+// parseFrameHeader 解析4字节帧头
+func parseFrameHeader(b []byte) (*mp3Frame, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return nil, false
+	}
+	version := int(b[1]>>3) & 0x03
+	layer := int(b[1]>>1) & 0x03
+	bitrateIndex := int(b[2]>>4) & 0x0F
+	sampleIndex := int(b[2]>>2) & 0x03
+	padding := int(b[2]>>1) & 0x01
+	channelMode := int(b[3]>>6) & 0x03
+
+	if version == 1 || layer == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleIndex == 3 {
+		return nil, false
+	}
+
+	isMPEG1 := 0
+	if version == mpeg1 {
+		isMPEG1 = 1
+	}
+	frame := &mp3Frame{
+		version:    version,
+		layer:      layer,
+		bitrate:    bitrateTable[isMPEG1][layer][bitrateIndex],
+		sampleRate: sampleRateTable[version][sampleIndex],
+		channels:   2,
+	}
+	if channelMode == 3 {
+		frame.channels = 1
+	}
+
+	switch {
+	case layer == 3: // Layer I
+		frame.samples = 384
+		frame.size = (12*frame.bitrate*1000/frame.sampleRate + padding) * 4
+	case layer == 2 || version == mpeg1: // Layer II，或 MPEG1 Layer III
+		frame.samples = 1152
+		frame.size = 144*frame.bitrate*1000/frame.sampleRate + padding
+	default: // MPEG2/2.5 Layer III
+		frame.samples = 576
+		frame.size = 72*frame.bitrate*1000/frame.sampleRate + padding
+	}
+	return frame, frame.size > 4
+}
+
+// probeMP3 解析 MP3，优先使用 Xing/Info/VBRI 头，否则逐帧扫描或按 CBR 估算
+func probeMP3(r io.ReaderAt, size int64, fullScan bool) (*Info, error) {
+	start, err := skipID3v2(r)
+	if err != nil {
+		return nil, err
+	}
+	end := size
+	if tag, err := readAt(r, size-128, 3); err == nil && bytes.Equal(tag, []byte("TAG")) {
+		end -= 128 // ID3v1 标签
+	}
+
+	// 寻找第一个有效帧（要求下一帧也合法，避免误判）
+	buf, err := readAt(r, start, 64*1024)
+	if err != nil {
+		return nil, fmt.Errorf("读取音频数据失败: %v", err)
+	}
+	var first *mp3Frame
+	offset := -1
+	for i := 0; i+4 <= len(buf); i++ {
+		frame, ok := parseFrameHeader(buf[i:])
+		if !ok {
+			continue
+		}
+		if next := i + frame.size; next+4 <= len(buf) {
+			if _, ok := parseFrameHeader(buf[next:]); !ok {
+				continue
+			}
+		}
+		first, offset = frame, i
+		break
+	}
+	if first == nil {
+		return nil, fmt.Errorf("未找到有效的 MP3 帧")
+	}
+	audioStart := start + int64(offset)
+
+	info := &Info{
+		Format:     "mp3",
+		SampleRate: first.sampleRate,
+		Channels:   first.channels,
+	}
+
+	frameData, err := readAt(r, audioStart, first.size+256)
+	if err != nil {
+		return nil, fmt.Errorf("读取音频数据失败: %v", err)
+	}
+
+	// Xing/Info 头（VBR 或 LAME 编码的 CBR）
+	if frames, skip, ok := parseXing(frameData, first); ok {
+		samples := int64(frames)*int64(first.samples) - int64(skip)
+		info.Duration = float64(samples) / float64(first.sampleRate)
+		info.Bitrate = bitrateFor(end-audioStart-int64(first.size), info.Duration)
+		return info, nil
+	}
+
+	// VBRI 头（Fraunhofer 编码器）
+	if frames, ok := parseVBRI(frameData); ok {
+		info.Duration = float64(frames*first.samples) / float64(first.sampleRate)
+		info.Bitrate = bitrateFor(end-audioStart-int64(first.size), info.Duration)
+		return info, nil
+	}
+
+	if fullScan {
+		frames, err := scanFrames(r, audioStart, end)
+		if err == nil && frames > 0 {
+			info.Duration = float64(frames*first.samples) / float64(first.sampleRate)
+			info.Bitrate = bitrateFor(end-audioStart, info.Duration)
+			return info, nil
+		}
+	}
+
+	// 按首帧码率估算 CBR 时长
+	info.Bitrate = first.bitrate
+	info.Duration = float64(end-audioStart) * 8 / float64(first.bitrate*1000)
+	return info, nil
+}
+
+// skipID3v2 返回 ID3v2 标签之后的偏移
+func skipID3v2(r io.ReaderAt) (int64, error) {
+	header, err := readAt(r, 0, 10)
+	if err != nil {
+		return 0, fmt.Errorf("读取文件头失败: %v", err)
+	}
+	if len(header) < 10 || !bytes.Equal(header[0:3], []byte("ID3")) {
+		return 0, nil
+	}
+	// 同步安全整数
+	size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+	offset := 10 + size
+	if header[5]&0x10 != 0 {
+		offset += 10 // 有 footer
+	}
+	return offset, nil
+}
+
+// parseXing 解析 Xing/Info 头，返回帧数和需要去掉的编码延迟+填充采样数
+func parseXing(data []byte, frame *mp3Frame) (frames int, skip int, ok bool) {
+	// 侧信息长度
+	var sideInfo int
+	switch {
+	case frame.version == mpeg1 && frame.channels == 2:
+		sideInfo = 32
+	case frame.version == mpeg1:
+		sideInfo = 17
+	case frame.channels == 2:
+		sideInfo = 17
+	default:
+		sideInfo = 9
+	}
+
+	pos := 4 + sideInfo
+	if len(data) < pos+8 {
+		return 0, 0, false
+	}
+	tag := string(data[pos : pos+4])
+	if tag != "Xing" && tag != "Info" {
+		return 0, 0, false
+	}
+	flags := binary.BigEndian.Uint32(data[pos+4:])
+	if flags&0x01 == 0 {
+		return 0, 0, false
+	}
+	frames = int(binary.BigEndian.Uint32(data[pos+8:]))
+
+	// LAME 扩展头，位于 Xing 头之后第120字节
+	lame := pos + 120
+	if len(data) >= lame+24 && bytes.Equal(data[lame:lame+4], []byte("LAME")) {
+		b := data[lame+21 : lame+24]
+		delay := int(b[0])<<4 | int(b[1])>>4
+		padding := int(b[1]&0x0F)<<8 | int(b[2])
+		skip = delay + padding
+	}
+	return frames, skip, frames > 0
+}
+
+// parseVBRI 解析 VBRI 头，返回帧数
+func parseVBRI(data []byte) (int, bool) {
+	const pos = 4 + 32
+	if len(data) < pos+18 || !bytes.Equal(data[pos:pos+4], []byte("VBRI")) {
+		return 0, false
+	}
+	frames := int(binary.BigEndian.Uint32(data[pos+14:]))
+	return frames, frames > 0
+}
+
+// scanFrames 逐帧扫描统计帧数
+func scanFrames(r io.ReaderAt, start, end int64) (int, error) {
+	frames := 0
+	header := make([]byte, 4)
+	for offset := start; offset+4 <= end; {
+		if _, err := r.ReadAt(header, offset); err != nil {
+			break
+		}
+		frame, ok := parseFrameHeader(header)
+		if !ok {
+			// 跳过损坏的数据，继续寻找下一个帧头
+			offset++
+			continue
+		}
+		frames++
+		offset += int64(frame.size)
+	}
+	return frames, nil
+}
+
+// bitrateFor 根据数据长度和时长计算平均码率（kbps）
+func bitrateFor(bytes int64, duration float64) int {
+	if duration <= 0 {
+		return 0
+	}
+	return int(float64(bytes) * 8 / duration / 1000)
+}
@@ -0,0 +1,108 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Opus 的 granule position 总是以 48kHz 计数
+const opusGranuleRate = 48000
+
+// 识别头中用到的字段所需的最少字节数
+const (
+	vorbisHeaderSize = 24 // 到 bitrate_nominal 为止
+	opusHeaderSize   = 16 // 到 input_sample_rate 为止
+)
+
+// probeOgg 解析 Ogg Vorbis/Opus：首页取编码参数，末页的 granule position 得到总采样数
+func probeOgg(r io.ReaderAt, size int64) (*Info, error) {
+	first, err := readAt(r, 0, 4096)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件头失败: %v", err)
+	}
+	if len(first) < 28 {
+		return nil, fmt.Errorf("Ogg 页不完整")
+	}
+	serial := binary.LittleEndian.Uint32(first[14:18])
+	segments := int(first[26])
+	packet := 27 + segments
+	if len(first) <= packet {
+		return nil, fmt.Errorf("Ogg 页不完整")
+	}
+	data := first[packet:]
+
+	info := &Info{}
+	var granuleRate int
+	var preSkip int64
+	switch {
+	case bytes.HasPrefix(data, []byte("\x01vorbis")):
+		if len(data) < vorbisHeaderSize {
+			return nil, fmt.Errorf("Vorbis 识别头不完整")
+		}
+		info.Format = "vorbis"
+		info.Channels = int(data[11])
+		info.SampleRate = int(binary.LittleEndian.Uint32(data[12:16]))
+		if nominal := int32(binary.LittleEndian.Uint32(data[20:24])); nominal > 0 {
+			info.Bitrate = int(nominal / 1000)
+		}
+		granuleRate = info.SampleRate
+	case bytes.HasPrefix(data, []byte("OpusHead")):
+		if len(data) < opusHeaderSize {
+			return nil, fmt.Errorf("Opus 识别头不完整")
+		}
+		info.Format = "opus"
+		info.Channels = int(data[9])
+		preSkip = int64(binary.LittleEndian.Uint16(data[10:12]))
+		info.SampleRate = int(binary.LittleEndian.Uint32(data[12:16]))
+		if info.SampleRate == 0 {
+			info.SampleRate = opusGranuleRate
+		}
+		granuleRate = opusGranuleRate
+	default:
+		return nil, fmt.Errorf("不支持的 Ogg 编码")
+	}
+	if granuleRate == 0 {
+		return nil, fmt.Errorf("Ogg 采样率无效")
+	}
+
+	granule, err := lastGranule(r, size, serial)
+	if err != nil {
+		return nil, err
+	}
+	info.Duration = float64(granule-preSkip) / float64(granuleRate)
+	if info.Duration < 0 {
+		info.Duration = 0
+	}
+	return info, nil
+}
+
+// lastGranule 从文件末尾向前查找同一逻辑流最后一页的 granule position
+func lastGranule(r io.ReaderAt, size int64, serial uint32) (int64, error) {
+	const window = 64 * 1024
+	for end := size; end > 0; end -= window - 27 {
+		start := end - window
+		if start < 0 {
+			start = 0
+		}
+		buf, err := readAt(r, start, int(end-start))
+		if err != nil {
+			return 0, fmt.Errorf("读取文件尾失败: %v", err)
+		}
+		for i := bytes.LastIndex(buf, []byte("OggS")); i >= 0; i = bytes.LastIndex(buf[:i], []byte("OggS")) {
+			if i+27 > len(buf) {
+				continue
+			}
+			page := buf[i:]
+			granule := int64(binary.LittleEndian.Uint64(page[6:14]))
+			if binary.LittleEndian.Uint32(page[14:18]) == serial && granule >= 0 {
+				return granule, nil
+			}
+		}
+		if start == 0 {
+			break
+		}
+	}
+	return 0, fmt.Errorf("未找到 Ogg 结束页")
+}
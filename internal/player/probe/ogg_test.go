@@ -0,0 +1,90 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// oggPage 生成只有一个段的 Ogg 页，不计算校验和
+func oggPage(granule int64, serial uint32, data []byte) []byte {
+	page := make([]byte, 27, 28+len(data))
+	copy(page, "OggS")
+	binary.LittleEndian.PutUint64(page[6:14], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:18], serial)
+	page[26] = 1
+	page = append(page, byte(len(data)))
+	return append(page, data...)
+}
+
+func vorbisHeader() []byte {
+	h := make([]byte, 30)
+	copy(h, "\x01vorbis")
+	h[11] = 2
+	binary.LittleEndian.PutUint32(h[12:16], 44100)
+	binary.LittleEndian.PutUint32(h[20:24], 128000)
+	h[29] = 1
+	return h
+}
+
+func opusHeader() []byte {
+	h := make([]byte, 19)
+	copy(h, "OpusHead")
+	h[8] = 1
+	h[9] = 2
+	binary.LittleEndian.PutUint16(h[10:12], 312)
+	binary.LittleEndian.PutUint32(h[12:16], 44100)
+	return h
+}
+
+func TestProbeOgg(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		granule  int64
+		format   string
+		duration float64
+	}{
+		{"vorbis", vorbisHeader(), 441000, "vorbis", 10},
+		{"opus", opusHeader(), 480312, "opus", 10},
+	}
+	for _, tt := range tests {
+		data := append(oggPage(0, 7, tt.header), oggPage(tt.granule, 7, []byte{0})...)
+		info, err := Reader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if info.Format != tt.format || info.Channels != 2 || info.SampleRate != 44100 || info.Duration != tt.duration {
+			t.Errorf("%s: 信息 = %+v", tt.name, info)
+		}
+	}
+}
+
+// 第一页在识别头中间截断的文件应该返回错误，不能越界
+func TestProbeOggTruncated(t *testing.T) {
+	tests := []struct {
+		header []byte
+		size   int
+	}{
+		{vorbisHeader(), vorbisHeaderSize},
+		{opusHeader(), opusHeaderSize},
+	}
+	for _, tt := range tests {
+		header := tt.header
+		page := oggPage(0, 7, header)
+		for n := 4; n < 28+tt.size; n++ {
+			data := page[:n]
+			if _, err := Reader(bytes.NewReader(data), int64(len(data))); err == nil {
+				t.Errorf("%q 截断到 %d 字节时没有返回错误", header[:8], n)
+			}
+		}
+	}
+
+	// 段表声明的长度比实际数据长，识别头只有 20 字节
+	data := oggPage(0, 7, vorbisHeader()[:20])
+	_, err := Reader(bytes.NewReader(data), int64(len(data)))
+	if err == nil || !strings.Contains(err.Error(), "不完整") {
+		t.Errorf("识别头不完整时的错误 = %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+// Package probe 直接解析音频文件头获取时长等信息，不依赖播放进程
+package probe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Info 音频基本信息
+type Info struct {
+	Format     string  `json:"format"`      // mp3/wav/flac/vorbis/opus
+	Duration   float64 `json:"duration"`    // 时长（秒）
+	SampleRate int     `json:"sample_rate"` // 采样率（Hz）
+	Channels   int     `json:"channels"`
+	Bitrate    int     `json:"bitrate"` // 平均码率（kbps）
+}
+
+// ErrUnknownFormat 无法识别的音频格式
+var ErrUnknownFormat = errors.New("无法识别的音频格式")
+
+// File 解析本地文件
+func File(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return probe(f, stat.Size(), true)
+}
+
+// Reader 解析任意支持随机读取的数据，size 为总大小
+func Reader(r io.ReaderAt, size int64) (*Info, error) {
+	return probe(r, size, false)
+}
+
+// probe 识别格式并解析。fullScan 为 true 时，没有 VBR 头的 MP3 会逐帧扫描
+func probe(r io.ReaderAt, size int64, fullScan bool) (*Info, error) {
+	head := make([]byte, 12)
+	if _, err := r.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("读取文件头失败: %v", err)
+	}
+
+	var info *Info
+	var err error
+	switch {
+	case bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")):
+		info, err = probeWAV(r, size)
+	case bytes.Equal(head[0:4], []byte("fLaC")):
+		info, err = probeFLAC(r, size)
+	case bytes.Equal(head[0:4], []byte("OggS")):
+		info, err = probeOgg(r, size)
+	case bytes.Equal(head[0:3], []byte("ID3")) || (head[0] == 0xFF && head[1]&0xE0 == 0xE0):
+		info, err = probeMP3(r, size, fullScan)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Bitrate == 0 && info.Duration > 0 {
+		info.Bitrate = int(float64(size) * 8 / info.Duration / 1000)
+	}
+	return info, nil
+}
+
+// readAt 读取指定位置的数据，读到末尾时返回实际读取的部分
+func readAt(r io.ReaderAt, offset int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := r.ReadAt(buf, offset)
+	if err != nil && !(err == io.EOF && read > 0) {
+		return nil, err
+	}
+	return buf[:read], nil
+}
@@ -0,0 +1,60 @@
+package probe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// probeWAV 解析 RIFF 的 fmt 和 data 块
+func probeWAV(r io.ReaderAt, size int64) (*Info, error) {
+	info := &Info{Format: "wav"}
+	var byteRate int64
+	var dataSize int64 = -1
+
+	// 跳过 "RIFF" + 长度 + "WAVE"
+	offset := int64(12)
+	for offset+8 <= size {
+		header, err := readAt(r, offset, 8)
+		if err != nil || len(header) < 8 {
+			break
+		}
+		id := string(header[0:4])
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+		body := offset + 8
+
+		switch id {
+		case "fmt ":
+			data, err := readAt(r, body, 16)
+			if err != nil || len(data) < 16 {
+				return nil, fmt.Errorf("WAV fmt 块不完整")
+			}
+			info.Channels = int(binary.LittleEndian.Uint16(data[2:4]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(data[4:8]))
+			byteRate = int64(binary.LittleEndian.Uint32(data[8:12]))
+		case "data":
+			dataSize = chunkSize
+			// 流式写入的 WAV 长度可能未填写
+			if dataSize == 0 || dataSize == 0xFFFFFFFF || body+dataSize > size {
+				dataSize = size - body
+			}
+		}
+		if byteRate > 0 && dataSize >= 0 {
+			break
+		}
+
+		// 块按偶数字节对齐
+		offset = body + chunkSize + chunkSize%2
+	}
+
+	if byteRate == 0 {
+		return nil, fmt.Errorf("WAV 缺少 fmt 块")
+	}
+	if dataSize < 0 {
+		return nil, fmt.Errorf("WAV 缺少 data 块")
+	}
+
+	info.Duration = float64(dataSize) / float64(byteRate)
+	info.Bitrate = int(byteRate * 8 / 1000)
+	return info, nil
+}
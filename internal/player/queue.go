@@ -0,0 +1,488 @@
+package player
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+
+	"aku-web/internal/history"
+)
+
+// RepeatMode 循环模式
+type RepeatMode string
+
+const (
+	RepeatOff RepeatMode = "off" // 不循环，队列播放完即停止
+	RepeatOne RepeatMode = "one" // 单曲循环
+	RepeatAll RepeatMode = "all" // 列表循环
+)
+
+// QueueItem 播放队列中的一项
+type QueueItem struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Artists []string `json:"artists,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	SongId  uint     `json:"song_id,omitempty"` // 网易云歌曲ID，播放时再解析URL
+}
+
+// QueueState 播放队列的快照
+type QueueState struct {
+	Items   []QueueItem `json:"items"`
+	Order   []string    `json:"order"`   // 实际播放顺序（随机模式下与 Items 不同）
+	Current int         `json:"current"` // 当前项在 Items 中的下标，-1 表示没有
+	Shuffle bool        `json:"shuffle"`
+	Repeat  RepeatMode  `json:"repeat"`
+}
+
+// URLResolver 将队列项解析为可播放的URL
+type URLResolver func(item QueueItem) (string, error)
+
+// Queue 服务端播放队列
+type Queue struct {
+	items   []QueueItem
+	order   []string // 播放顺序，保存的是 QueueItem.ID
+	pos     int      // 当前项在 order 中的位置，-1 表示尚未开始
+	shuffle bool
+	repeat  RepeatMode
+	nextID  int64
+	mutex   sync.RWMutex
+}
+
+// NewQueue 创建空的播放队列
+func NewQueue() *Queue {
+	return &Queue{
+		pos:    -1,
+		repeat: RepeatOff,
+	}
+}
+
+// Append 在队列末尾追加歌曲，返回带ID的队列项
+func (q *Queue) Append(items ...QueueItem) []QueueItem {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.insertLocked(len(q.items), items)
+}
+
+// Insert 在指定位置插入歌曲
+func (q *Queue) Insert(index int, items ...QueueItem) ([]QueueItem, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if index < 0 || index > len(q.items) {
+		return nil, fmt.Errorf("插入位置超出范围: %d", index)
+	}
+	return q.insertLocked(index, items), nil
+}
+
+// insertLocked 插入歌曲，调用方需持有锁
+func (q *Queue) insertLocked(index int, items []QueueItem) []QueueItem {
+	added := make([]QueueItem, len(items))
+	for i, item := range items {
+		q.nextID++
+		item.ID = fmt.Sprintf("q%d", q.nextID)
+		added[i] = item
+	}
+
+	q.items = append(q.items[:index], append(added, q.items[index:]...)...)
+
+	if !q.shuffle {
+		q.rebuildOrder()
+		return added
+	}
+
+	// 随机模式下，新歌曲插入到当前项之后的随机位置
+	for _, item := range added {
+		at := len(q.order)
+		if q.pos < len(q.order) {
+			at = q.pos + 1 + rand.Intn(len(q.order)-q.pos)
+		}
+		q.order = append(q.order[:at], append([]string{item.ID}, q.order[at:]...)...)
+	}
+	return added
+}
+
+// Remove 根据ID移除歌曲
+func (q *Queue) Remove(id string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	index := q.indexOf(id)
+	if index < 0 {
+		return fmt.Errorf("队列中不存在: %s", id)
+	}
+	q.items = append(q.items[:index], q.items[index+1:]...)
+
+	for i, orderID := range q.order {
+		if orderID != id {
+			continue
+		}
+		q.order = append(q.order[:i], q.order[i+1:]...)
+		// 移除当前项或之前的项时，让下一首仍然指向原来的后继
+		if i <= q.pos {
+			q.pos--
+		}
+		break
+	}
+	return nil
+}
+
+// Move 调整歌曲在列表中的位置
+func (q *Queue) Move(from, to int) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if from < 0 || from >= len(q.items) || to < 0 || to >= len(q.items) {
+		return fmt.Errorf("位置超出范围: %d -> %d", from, to)
+	}
+
+	item := q.items[from]
+	q.items = append(q.items[:from], q.items[from+1:]...)
+	q.items = append(q.items[:to], append([]QueueItem{item}, q.items[to:]...)...)
+
+	if !q.shuffle {
+		q.rebuildOrder()
+	}
+	return nil
+}
+
+// Clear 清空队列
+func (q *Queue) Clear() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.items = nil
+	q.order = nil
+	q.pos = -1
+}
+
+// SetShuffle 开启或关闭随机播放
+func (q *Queue) SetShuffle(enabled bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.shuffle == enabled {
+		return
+	}
+	q.shuffle = enabled
+	if enabled {
+		q.reshuffle()
+	} else {
+		q.rebuildOrder()
+	}
+}
+
+// SetRepeat 设置循环模式
+func (q *Queue) SetRepeat(mode RepeatMode) error {
+	switch mode {
+	case RepeatOff, RepeatOne, RepeatAll:
+	default:
+		return fmt.Errorf("未知的循环模式: %s", mode)
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.repeat = mode
+	return nil
+}
+
+// Current 获取当前项
+func (q *Queue) Current() (QueueItem, bool) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+	return q.currentLocked()
+}
+
+// Snapshot 获取队列快照
+func (q *Queue) Snapshot() QueueState {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	state := QueueState{
+		Items:   append([]QueueItem{}, q.items...),
+		Order:   append([]string{}, q.order...),
+		Current: -1,
+		Shuffle: q.shuffle,
+		Repeat:  q.repeat,
+	}
+	if item, ok := q.currentLocked(); ok {
+		state.Current = q.indexOf(item.ID)
+	}
+	return state
+}
+
+// Next 前进到下一首。auto 为 true 表示由播放结束触发，此时遵循单曲循环
+func (q *Queue) Next(auto bool) (QueueItem, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.order) == 0 {
+		return QueueItem{}, false
+	}
+
+	if auto && q.repeat == RepeatOne && q.pos >= 0 {
+		return q.currentLocked()
+	}
+
+	if q.pos+1 < len(q.order) {
+		q.pos++
+		return q.currentLocked()
+	}
+
+	if q.repeat == RepeatOff && auto {
+		// 播放完毕，停在队尾，下次手动“下一首”会从头开始
+		q.pos = len(q.order)
+		return QueueItem{}, false
+	}
+
+	if q.shuffle {
+		q.pos = -1
+		q.reshuffle()
+	}
+	q.pos = 0
+	return q.currentLocked()
+}
+
+// PeekNext 返回自动播放时的下一首，不移动当前位置。随机模式下列表循环到头时会重新打乱，无法预知下一首
+func (q *Queue) PeekNext() (QueueItem, bool) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if len(q.order) == 0 {
+		return QueueItem{}, false
+	}
+	if q.repeat == RepeatOne && q.pos >= 0 {
+		return q.currentLocked()
+	}
+
+	pos := q.pos + 1
+	if pos >= len(q.order) {
+		if q.repeat != RepeatAll || q.shuffle {
+			return QueueItem{}, false
+		}
+		pos = 0
+	}
+	index := q.indexOf(q.order[pos])
+	if index < 0 {
+		return QueueItem{}, false
+	}
+	return q.items[index], true
+}
+
+// Previous 回到上一首，已经是第一首时重新播放当前项
+func (q *Queue) Previous() (QueueItem, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(q.order) == 0 {
+		return QueueItem{}, false
+	}
+
+	if q.pos > len(q.order)-1 {
+		q.pos = len(q.order) - 1
+	} else if q.pos > 0 {
+		q.pos--
+	} else if q.repeat == RepeatAll {
+		q.pos = len(q.order) - 1
+	} else {
+		q.pos = 0
+	}
+	return q.currentLocked()
+}
+
+// Jump 跳到列表中的指定下标
+func (q *Queue) Jump(index int) (QueueItem, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if index < 0 || index >= len(q.items) {
+		return QueueItem{}, fmt.Errorf("位置超出范围: %d", index)
+	}
+
+	id := q.items[index].ID
+	for i, orderID := range q.order {
+		if orderID == id {
+			q.pos = i
+			break
+		}
+	}
+	return q.items[index], nil
+}
+
+// currentLocked 获取当前项，调用方需持有锁
+func (q *Queue) currentLocked() (QueueItem, bool) {
+	if q.pos < 0 || q.pos >= len(q.order) {
+		return QueueItem{}, false
+	}
+	index := q.indexOf(q.order[q.pos])
+	if index < 0 {
+		return QueueItem{}, false
+	}
+	return q.items[index], true
+}
+
+// indexOf 查找ID在 items 中的下标
+func (q *Queue) indexOf(id string) int {
+	for i, item := range q.items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// rebuildOrder 按列表顺序重建播放顺序，并保持当前项不变
+func (q *Queue) rebuildOrder() {
+	current, hasCurrent := q.currentLocked()
+	finished := q.pos >= len(q.order) && len(q.order) > 0
+
+	q.order = make([]string, len(q.items))
+	for i, item := range q.items {
+		q.order[i] = item.ID
+	}
+
+	switch {
+	case hasCurrent:
+		q.pos = q.indexOf(current.ID)
+	case finished:
+		q.pos = len(q.order)
+	default:
+		q.pos = -1
+	}
+}
+
+// reshuffle 打乱播放顺序，当前项（如果有）放在最前面
+func (q *Queue) reshuffle() {
+	current, hasCurrent := q.currentLocked()
+
+	q.order = make([]string, 0, len(q.items))
+	for _, i := range rand.Perm(len(q.items)) {
+		if hasCurrent && q.items[i].ID == current.ID {
+			continue
+		}
+		q.order = append(q.order, q.items[i].ID)
+	}
+
+	if hasCurrent {
+		q.order = append([]string{current.ID}, q.order...)
+		q.pos = 0
+	} else {
+		q.pos = -1
+	}
+}
+
+var urlResolver URLResolver
+
+// SetURLResolver 设置队列项的URL解析函数（例如网易云歌曲ID转URL）
+func SetURLResolver(resolver URLResolver) {
+	urlResolver = resolver
+}
+
+// refresherFor 返回重新解析队列项播放地址的函数，地址不是由解析函数得到时返回 nil
+func refresherFor(item *QueueItem) func() (string, error) {
+	if item == nil || item.URL != "" || urlResolver == nil {
+		return nil
+	}
+	resolver, resolved := urlResolver, *item
+	return func() (string, error) {
+		return resolver(resolved)
+	}
+}
+
+// GetQueue 获取默认播放器的播放队列
+func GetQueue() (*Queue, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return nil, fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.queue, nil
+}
+
+// QueuePlay 播放队列中指定下标的歌曲
+func QueuePlay(index int) (*AudioDuration, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return nil, fmt.Errorf("播放器初始化失败")
+	}
+	item, err := defaultPlayer.queue.Jump(index)
+	if err != nil {
+		return nil, err
+	}
+	return defaultPlayer.playItem(item)
+}
+
+// QueueNext 播放下一首
+func QueueNext() (*AudioDuration, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return nil, fmt.Errorf("播放器初始化失败")
+	}
+	item, ok := defaultPlayer.queue.Next(false)
+	if !ok {
+		return nil, fmt.Errorf("播放队列为空")
+	}
+	return defaultPlayer.playItem(item)
+}
+
+// QueuePrevious 播放上一首
+func QueuePrevious() (*AudioDuration, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return nil, fmt.Errorf("播放器初始化失败")
+	}
+	item, ok := defaultPlayer.queue.Previous()
+	if !ok {
+		return nil, fmt.Errorf("播放队列为空")
+	}
+	return defaultPlayer.playItem(item)
+}
+
+// playItem 解析并播放队列项
+func (p *AudioPlayer) playItem(item QueueItem) (*AudioDuration, error) {
+	url := item.URL
+	if url == "" {
+		url = p.takePrefetched(item.ID)
+	}
+	if url == "" {
+		if urlResolver == nil {
+			return nil, fmt.Errorf("无法解析队列项: %s", item.Title)
+		}
+		var err error
+		url, err = urlResolver(item)
+		if err != nil {
+			return nil, fmt.Errorf("解析播放地址失败: %v", err)
+		}
+	}
+
+	log.Printf("[Queue] 播放: %s", item.Title)
+	// 切歌时先记为跳过，否则 Stop 会把正在记录的播放记为停止
+	p.finishListen(history.ResultSkipped)
+	p.Stop()
+	return p.play(url, &item)
+}
+
+// handleTrackEnd 当前歌曲播放结束，自动播放队列中的下一首
+func (p *AudioPlayer) handleTrackEnd() {
+	p.mutex.Lock()
+	p.isPlaying = false
+	p.mutex.Unlock()
+
+	item, ok := p.queue.Next(true)
+	if !ok {
+		log.Printf("[Queue] 播放队列已结束")
+		p.resetFade()
+		return
+	}
+
+	p.mutex.Lock()
+	p.advancing = true
+	p.mutex.Unlock()
+	if _, err := p.playItem(item); err != nil {
+		log.Printf("[Queue] 自动播放下一首失败: %v", err)
+		p.mutex.Lock()
+		p.advancing = false
+		p.mutex.Unlock()
+		p.resetFade()
+	}
+}
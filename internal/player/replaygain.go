@@ -0,0 +1,311 @@
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"aku-web/internal/library"
+	"aku-web/internal/loudness"
+)
+
+// 响度均衡模式
+const (
+	ReplayGainOff   = "off"
+	ReplayGainTrack = "track"
+	ReplayGainAlbum = "album"
+)
+
+// localGainFile 本地文件的测量结果，保存在缓存目录下
+const localGainFile = "loudness.json"
+
+// ReplayGainStatus 响度均衡的设置和当前歌曲应用的增益
+type ReplayGainStatus struct {
+	Mode   string   `json:"mode"`
+	Preamp float64  `json:"preamp"`
+	Gain   *float64 `json:"gain,omitempty"` // 当前歌曲调整的分贝，没有响度信息或已关闭时为空
+}
+
+// SetReplayGain 设置响度均衡的模式和前级增益，立即应用到当前歌曲
+func SetReplayGain(mode string, preamp float64) error {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.SetReplayGain(mode, preamp)
+}
+
+// SetReplayGain 设置响度均衡的模式和前级增益
+func (p *AudioPlayer) SetReplayGain(mode string, preamp float64) error {
+	switch mode {
+	case ReplayGainOff, ReplayGainTrack, ReplayGainAlbum:
+	default:
+		return fmt.Errorf("不支持的响度均衡模式: %s", mode)
+	}
+	if preamp < -15 || preamp > 15 {
+		return fmt.Errorf("前级增益必须在 -15 到 15 dB 之间")
+	}
+
+	p.gainMu.Lock()
+	p.rgMode, p.rgPreamp = mode, preamp
+	p.updateReplayGainLocked()
+	err := p.applyGainLocked()
+	p.gainMu.Unlock()
+	p.notify()
+	return err
+}
+
+// replayGainStatus 返回响度均衡的状态
+func (p *AudioPlayer) replayGainStatus() *ReplayGainStatus {
+	p.gainMu.Lock()
+	defer p.gainMu.Unlock()
+	status := &ReplayGainStatus{Mode: p.rgMode, Preamp: p.rgPreamp}
+	if p.rgGain != nil {
+		gain := *p.rgGain
+		status.Gain = &gain
+	}
+	return status
+}
+
+// setTrackGain 记录即将加载的歌曲的响度信息，加载后由 afterLoad 应用
+func (p *AudioPlayer) setTrackGain(gain *library.ReplayGain) {
+	p.gainMu.Lock()
+	defer p.gainMu.Unlock()
+	p.trackGain = gain
+	p.updateReplayGainLocked()
+}
+
+// updateReplayGainLocked 按模式计算当前歌曲的增益，调用方需持有 p.gainMu
+func (p *AudioPlayer) updateReplayGainLocked() {
+	p.rgGain = nil
+	if gain, ok := selectGain(p.trackGain, p.rgMode, p.rgPreamp); ok {
+		p.rgGain = &gain
+	}
+}
+
+// rgFactorLocked 返回响度均衡的音量系数，调用方需持有 p.gainMu
+func (p *AudioPlayer) rgFactorLocked() float64 {
+	if p.rgGain == nil {
+		return 1
+	}
+	return math.Pow(10, *p.rgGain/20)
+}
+
+// selectGain 按模式选择增益并加上前级增益。专辑模式没有专辑增益时使用单曲增益。
+// 软件音量不能超过 100%，所以只降低音量，同时按峰值限制避免削波
+func selectGain(rg *library.ReplayGain, mode string, preamp float64) (float64, bool) {
+	if rg == nil || mode == ReplayGainOff {
+		return 0, false
+	}
+	gain, peak := rg.TrackGain, rg.TrackPeak
+	if mode == ReplayGainAlbum && rg.AlbumGain != nil {
+		gain, peak = rg.AlbumGain, rg.AlbumPeak
+	}
+	if gain == nil {
+		return 0, false
+	}
+
+	db := *gain + preamp
+	if peak != nil && *peak > 0 {
+		if limit := -20 * math.Log10(*peak); db > limit {
+			db = limit
+		}
+	}
+	if db > 0 {
+		db = 0
+	}
+	return db, true
+}
+
+// hasGain 判断是否有可用的增益
+func hasGain(rg library.ReplayGain) bool {
+	return rg.TrackGain != nil || rg.AlbumGain != nil
+}
+
+// lookupGain 查找歌曲的响度信息：依次使用缓存索引或本地测量结果、文件中的 ReplayGain 标签，
+// 都没有时在后台测量，测量结果在下次播放时生效。path 为实际加载的文件
+func (p *AudioPlayer) lookupGain(url, path string) *library.ReplayGain {
+	if p.replayGainMode() == ReplayGainOff {
+		return nil
+	}
+
+	if isRemote(url) {
+		if cached := p.cache.peek(url); cached != nil {
+			cached.mutex.RLock()
+			gain := cached.Gain
+			cached.mutex.RUnlock()
+			if gain != nil {
+				return gain
+			}
+		}
+		// 还在下载的文件也能读到开头的 ID3v2、FLAC 和 Vorbis 标签
+		if tags, err := library.ReadTags(path); err == nil && hasGain(tags.ReplayGain) {
+			return &tags.ReplayGain
+		}
+		p.analyzeCached(url)
+		return nil
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if gain, ok := p.gains.get(path, stat); ok {
+		return &gain
+	}
+	if tags, err := library.ReadTags(path); err == nil && hasGain(tags.ReplayGain) {
+		return &tags.ReplayGain
+	}
+	p.gains.run(path, func() {
+		p.gains.put(path, stat, measureGain(path))
+	})
+	return nil
+}
+
+// analyzeCached 在后台分析下载完成的缓存文件，结果保存在缓存索引中
+func (p *AudioPlayer) analyzeCached(url string) {
+	if p.replayGainMode() == ReplayGainOff {
+		return
+	}
+	cached := p.cache.peek(url)
+	if cached == nil {
+		return
+	}
+	cached.mutex.RLock()
+	ready := cached.Status == CacheStatusCompleted && cached.Gain == nil
+	path := cached.Path
+	cached.mutex.RUnlock()
+	if !ready {
+		return
+	}
+
+	p.gains.run(url, func() {
+		gain := library.ReplayGain{}
+		if tags, err := library.ReadTags(path); err == nil && hasGain(tags.ReplayGain) {
+			gain = tags.ReplayGain
+		} else {
+			gain = measureGain(path)
+		}
+		cached.mutex.Lock()
+		cached.Gain = &gain
+		cached.mutex.Unlock()
+		p.cache.save()
+	})
+}
+
+// replayGainMode 返回响度均衡模式
+func (p *AudioPlayer) replayGainMode() string {
+	p.gainMu.Lock()
+	defer p.gainMu.Unlock()
+	return p.rgMode
+}
+
+// measureGain 测量文件的响度并换算为单曲增益，失败时返回空的结果，避免反复测量
+func measureGain(path string) library.ReplayGain {
+	result, err := loudness.Measure(path)
+	if err != nil {
+		log.Printf("[ReplayGain] 测量响度失败: %s: %v", path, err)
+		return library.ReplayGain{}
+	}
+	gain, peak := result.Gain(), result.Peak
+	log.Printf("[ReplayGain] %s: %.1f LUFS，增益 %.1f dB", filepath.Base(path), result.Loudness, gain)
+	return library.ReplayGain{TrackGain: &gain, TrackPeak: &peak}
+}
+
+// localGain 本地文件的测量结果，文件大小或修改时间变化后失效
+type localGain struct {
+	Size    int64              `json:"size"`
+	ModTime time.Time          `json:"mod_time"`
+	Gain    library.ReplayGain `json:"gain"`
+}
+
+// gainStore 保存本地文件的测量结果，并保证同一时间只测量一个文件
+type gainStore struct {
+	path      string
+	entries   map[string]localGain
+	pending   map[string]bool
+	mutex     sync.Mutex
+	measureMu sync.Mutex
+}
+
+// newGainStore 从缓存目录加载本地文件的测量结果，已不存在的文件会被丢弃
+func newGainStore(cacheDir string) *gainStore {
+	s := &gainStore{
+		path:    filepath.Join(cacheDir, localGainFile),
+		entries: make(map[string]localGain),
+		pending: make(map[string]bool),
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return s
+	}
+	var entries map[string]localGain
+	if err := json.Unmarshal(data, &entries); err != nil {
+		log.Printf("[ReplayGain] 读取测量结果失败: %v", err)
+		return s
+	}
+	for path, entry := range entries {
+		if _, err := os.Stat(path); err == nil {
+			s.entries[path] = entry
+		}
+	}
+	return s
+}
+
+// get 返回仍然有效的测量结果
+func (s *gainStore) get(path string, stat os.FileInfo) (library.ReplayGain, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[path]
+	if !ok || entry.Size != stat.Size() || !entry.ModTime.Equal(stat.ModTime()) {
+		return library.ReplayGain{}, false
+	}
+	return entry.Gain, true
+}
+
+// put 记录测量结果并保存
+func (s *gainStore) put(path string, stat os.FileInfo, gain library.ReplayGain) {
+	s.mutex.Lock()
+	s.entries[path] = localGain{Size: stat.Size(), ModTime: stat.ModTime(), Gain: gain}
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	s.mutex.Unlock()
+	if err != nil {
+		log.Printf("[ReplayGain] 序列化测量结果失败: %v", err)
+		return
+	}
+
+	// 先写临时文件再重命名，避免断电时文件损坏
+	if err := os.WriteFile(s.path+".tmp", data, 0644); err != nil {
+		log.Printf("[ReplayGain] 保存测量结果失败: %v", err)
+		return
+	}
+	if err := os.Rename(s.path+".tmp", s.path); err != nil {
+		log.Printf("[ReplayGain] 保存测量结果失败: %v", err)
+	}
+}
+
+// run 在后台执行测量，同一个 key 正在测量时忽略，多个测量依次进行以免占满 CPU
+func (s *gainStore) run(key string, measure func()) {
+	s.mutex.Lock()
+	if s.pending[key] {
+		s.mutex.Unlock()
+		return
+	}
+	s.pending[key] = true
+	s.mutex.Unlock()
+
+	go func() {
+		s.measureMu.Lock()
+		measure()
+		s.measureMu.Unlock()
+
+		s.mutex.Lock()
+		delete(s.pending, key)
+		s.mutex.Unlock()
+	}()
+}
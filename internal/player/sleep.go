@@ -0,0 +1,271 @@
+package player
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	sleepPollInterval = 500 * time.Millisecond // 睡眠定时器检查剩余时间的间隔
+	sleepIdleLimit    = 10 * time.Second       // 按歌曲结束停止时，持续没有歌曲播放超过这个时间则取消定时器
+)
+
+// SleepStatus 睡眠定时器状态
+type SleepStatus struct {
+	EndOfTrack bool    `json:"end_of_track"` // 是否在当前歌曲结束时停止
+	Remaining  float64 `json:"remaining"`    // 距离停止播放的秒数
+	Fade       float64 `json:"fade"`         // 音量渐弱的秒数
+	Fading     bool    `json:"fading"`       // 是否正在渐弱
+}
+
+// sleepTimer 睡眠定时器，由 sleepMu 保护
+type sleepTimer struct {
+	endOfTrack bool
+	deadline   time.Time // 按时长定时的停止时间
+	fade       time.Duration
+	fading     bool
+	cancel     chan struct{}
+	trackEnded chan struct{} // 当前歌曲结束时关闭
+	endOnce    sync.Once
+}
+
+// SetSleepTimer 设置默认播放器的睡眠定时器，endOfTrack 为 true 时在当前歌曲结束时停止，否则在 duration 后停止
+func SetSleepTimer(duration time.Duration, endOfTrack bool, fade time.Duration) (SleepStatus, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return SleepStatus{}, fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.SetSleepTimer(duration, endOfTrack, fade)
+}
+
+// CancelSleepTimer 取消默认播放器的睡眠定时器
+func CancelSleepTimer() error {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.CancelSleepTimer()
+}
+
+// SetSleepTimer 设置睡眠定时器，停止前在 fade 时间内把音量逐渐降到0，停止后恢复原来的音量。已有的定时器会被替换
+func (p *AudioPlayer) SetSleepTimer(duration time.Duration, endOfTrack bool, fade time.Duration) (SleepStatus, error) {
+	if fade < 0 {
+		return SleepStatus{}, fmt.Errorf("渐弱时间不能为负数")
+	}
+	t := &sleepTimer{
+		endOfTrack: endOfTrack,
+		fade:       fade,
+		cancel:     make(chan struct{}),
+		trackEnded: make(chan struct{}),
+	}
+
+	if endOfTrack {
+		status := p.Status()
+		if status.Live {
+			return SleepStatus{}, fmt.Errorf("直播流没有结束时间")
+		}
+		if status.State == StateStopped.String() || status.Duration <= 0 {
+			return SleepStatus{}, fmt.Errorf("没有正在播放的歌曲")
+		}
+	} else {
+		if duration <= 0 {
+			return SleepStatus{}, fmt.Errorf("定时时长必须大于0")
+		}
+		if t.fade > duration {
+			t.fade = duration
+		}
+		t.deadline = time.Now().Add(duration)
+	}
+
+	p.sleepMu.Lock()
+	if p.sleep != nil {
+		close(p.sleep.cancel)
+	}
+	p.sleep = t
+	p.sleepMu.Unlock()
+
+	go p.runSleepTimer(t)
+	p.notify()
+
+	if endOfTrack {
+		log.Printf("[Sleep] 当前歌曲结束时停止播放")
+	} else {
+		log.Printf("[Sleep] %v 后停止播放", duration)
+	}
+	return p.Status().Sleep.orZero(), nil
+}
+
+// CancelSleepTimer 取消睡眠定时器，正在渐弱时恢复原来的音量
+func (p *AudioPlayer) CancelSleepTimer() error {
+	p.sleepMu.Lock()
+	t := p.sleep
+	p.sleep = nil
+	p.sleepMu.Unlock()
+
+	if t == nil {
+		return fmt.Errorf("没有设置睡眠定时器")
+	}
+	close(t.cancel)
+	p.notify()
+	log.Printf("[Sleep] 已取消睡眠定时器")
+	return nil
+}
+
+// runSleepTimer 等待到渐弱开始的时间，渐弱后停止播放并恢复音量
+func (p *AudioPlayer) runSleepTimer(t *sleepTimer) {
+	ticker := time.NewTicker(sleepPollInterval)
+	defer ticker.Stop()
+
+	var remaining time.Duration
+	var idleSince time.Time
+	for {
+		var ok bool
+		remaining, ok = p.sleepRemaining(t)
+		switch {
+		case ok:
+			idleSince = time.Time{}
+		case idleSince.IsZero():
+			idleSince = time.Now()
+		case time.Since(idleSince) > sleepIdleLimit:
+			log.Printf("[Sleep] 播放已停止，取消睡眠定时器")
+			if p.clearSleepTimer(t) {
+				p.notify()
+			}
+			return
+		}
+		if ok && remaining <= t.fade {
+			break
+		}
+		select {
+		case <-t.cancel:
+			return
+		case <-t.trackEnded:
+			remaining = 0
+		case <-ticker.C:
+			continue
+		}
+		break
+	}
+
+	volume, _ := currentVolume()
+	faded := remaining > 0 && volume > 0
+	if faded {
+		p.sleepMu.Lock()
+		t.fading = true
+		p.sleepMu.Unlock()
+		p.notify()
+
+		if err := FadeVolume(volume, 0, remaining, t.cancel); err == ErrFadeCanceled {
+			ApplyVolume(volume)
+			return
+		} else if err != nil {
+			log.Printf("[Sleep] 音量渐弱失败: %v", err)
+		}
+	}
+
+	// 按歌曲结束停止时等待歌曲真正结束，避免截掉结尾
+	if t.endOfTrack {
+		select {
+		case <-t.cancel:
+		case <-t.trackEnded:
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	stopped := p.clearSleepTimer(t)
+	if stopped {
+		log.Printf("[Sleep] 睡眠定时结束，停止播放")
+		p.Stop()
+	}
+	// 恢复原来的音量，下次播放时不会没有声音
+	if faded {
+		if err := ApplyVolume(volume); err != nil {
+			log.Printf("[Sleep] 恢复音量失败: %v", err)
+		}
+	}
+	if stopped {
+		p.notify()
+	}
+}
+
+// sleepRemaining 返回距离停止播放的时间。按歌曲结束停止而当前没有歌曲在播放时 ok 为 false
+func (p *AudioPlayer) sleepRemaining(t *sleepTimer) (remaining time.Duration, ok bool) {
+	if !t.endOfTrack {
+		return time.Until(t.deadline), true
+	}
+	select {
+	case <-t.trackEnded:
+		return 0, true
+	default:
+	}
+
+	p.stateMu.RLock()
+	state, duration, buffering := p.state, p.track.duration, p.track.buffering
+	p.stateMu.RUnlock()
+	if buffering || state == StateStopped || duration == nil {
+		return 0, false
+	}
+	left := duration.TotalSeconds - p.backend.Position()
+	if left < 0 {
+		left = 0
+	}
+	return time.Duration(left * float64(time.Second)), true
+}
+
+// clearSleepTimer 定时器结束时清除，已被取消或替换时返回 false
+func (p *AudioPlayer) clearSleepTimer(t *sleepTimer) bool {
+	p.sleepMu.Lock()
+	defer p.sleepMu.Unlock()
+	if p.sleep != t {
+		return false
+	}
+	p.sleep = nil
+	return true
+}
+
+// sleepOnTrackEnd 歌曲结束时检查是否设置了按歌曲结束停止，是则不再播放下一首
+func (p *AudioPlayer) sleepOnTrackEnd() bool {
+	p.sleepMu.Lock()
+	t := p.sleep
+	p.sleepMu.Unlock()
+	if t == nil || !t.endOfTrack {
+		return false
+	}
+	t.endOnce.Do(func() { close(t.trackEnded) })
+	return true
+}
+
+// sleepStatus 返回睡眠定时器状态，没有设置时返回 nil
+func (p *AudioPlayer) sleepStatus(position, duration float64) *SleepStatus {
+	p.sleepMu.Lock()
+	defer p.sleepMu.Unlock()
+	t := p.sleep
+	if t == nil {
+		return nil
+	}
+
+	status := &SleepStatus{
+		EndOfTrack: t.endOfTrack,
+		Fade:       t.fade.Seconds(),
+		Fading:     t.fading,
+	}
+	if t.endOfTrack {
+		status.Remaining = duration - position
+	} else {
+		status.Remaining = time.Until(t.deadline).Seconds()
+	}
+	if status.Remaining < 0 {
+		status.Remaining = 0
+	}
+	return status
+}
+
+// orZero 定时器已经结束时返回零值
+func (s *SleepStatus) orZero() SleepStatus {
+	if s == nil {
+		return SleepStatus{}
+	}
+	return *s
+}
@@ -0,0 +1,165 @@
+package player
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"aku-web/internal/mixer"
+)
+
+// 声音来源：base 为播放器根据正在播放的内容自动设置的歌曲或电台，
+// overrides 为闹钟、小智语音等临时来源，后激活的优先
+var (
+	volumeProfiles  *mixer.Profiles
+	baseSource      = mixer.SourceMusic
+	overrideSources []mixer.Source
+	sourceMu        sync.Mutex
+)
+
+// SetVolumeProfiles 设置各来源的音量记录，为空时不记住音量
+func SetVolumeProfiles(profiles *mixer.Profiles) {
+	sourceMu.Lock()
+	volumeProfiles = profiles
+	sourceMu.Unlock()
+}
+
+// ActiveSource 返回当前的声音来源
+func ActiveSource() mixer.Source {
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	return activeSourceLocked()
+}
+
+func activeSourceLocked() mixer.Source {
+	if n := len(overrideSources); n > 0 {
+		return overrideSources[n-1]
+	}
+	return baseSource
+}
+
+// ActivateSource 激活临时来源（闹钟、小智语音），并切换到它记住的音量
+func ActivateSource(source mixer.Source) {
+	switchSource(func() {
+		removeOverrideLocked(source)
+		overrideSources = append(overrideSources, source)
+	})
+}
+
+// ReleaseSource 结束临时来源，恢复之前来源的音量
+func ReleaseSource(source mixer.Source) {
+	switchSource(func() {
+		removeOverrideLocked(source)
+	})
+}
+
+// setBaseSource 播放器开始播放歌曲或电台时设置基础来源
+func setBaseSource(source mixer.Source) {
+	switchSource(func() {
+		baseSource = source
+	})
+}
+
+func removeOverrideLocked(source mixer.Source) {
+	for i, s := range overrideSources {
+		if s == source {
+			overrideSources = append(overrideSources[:i:i], overrideSources[i+1:]...)
+			return
+		}
+	}
+}
+
+// switchSource 修改来源，当前来源变化时应用它记住的音量
+func switchSource(change func()) {
+	sourceMu.Lock()
+	before := activeSourceLocked()
+	change()
+	after := activeSourceLocked()
+	profiles := volumeProfiles
+	sourceMu.Unlock()
+
+	if before == after || profiles == nil {
+		return
+	}
+	log.Printf("[Volume] 声音来源切换: %s -> %s", before, after)
+	if volume, ok := profiles.Get(after); ok {
+		if err := ApplyVolume(volume); err != nil {
+			log.Printf("[Volume] 应用 %s 的音量失败: %v", after, err)
+		}
+	}
+	if defaultPlayer != nil {
+		defaultPlayer.events.publish(Event{Type: EventVolumeChange})
+		defaultPlayer.notify()
+	}
+}
+
+// rememberSourceVolume 用户调整音量后记为当前来源的音量
+func rememberSourceVolume() {
+	sourceMu.Lock()
+	source, profiles := activeSourceLocked(), volumeProfiles
+	sourceMu.Unlock()
+	if profiles == nil {
+		return
+	}
+
+	lastVolumeMu.Lock()
+	volume := lastVolume
+	lastVolumeMu.Unlock()
+	if volume < 0 {
+		return
+	}
+	if err := profiles.Set(source, volume); err != nil {
+		log.Printf("[Volume] %v", err)
+	}
+}
+
+// SourceVolume 返回来源记住的音量
+func SourceVolume(source mixer.Source) (int, bool) {
+	sourceMu.Lock()
+	profiles := volumeProfiles
+	sourceMu.Unlock()
+	if profiles == nil {
+		return 0, false
+	}
+	return profiles.Get(source)
+}
+
+// VolumeProfiles 返回所有来源记住的音量
+func VolumeProfiles() map[mixer.Source]int {
+	sourceMu.Lock()
+	profiles := volumeProfiles
+	sourceMu.Unlock()
+	if profiles == nil {
+		return map[mixer.Source]int{}
+	}
+	return profiles.All()
+}
+
+// SetSourceVolume 修改来源记住的音量，该来源正在使用时同时调整音量
+func SetSourceVolume(source mixer.Source, volume int) error {
+	m, control, err := getMixer()
+	if err != nil {
+		return err
+	}
+	c, _, err := m.Get(control)
+	if err != nil {
+		return err
+	}
+	if err := c.CheckRaw(volume); err != nil {
+		return err
+	}
+
+	sourceMu.Lock()
+	active, profiles := activeSourceLocked(), volumeProfiles
+	sourceMu.Unlock()
+	if profiles == nil {
+		return fmt.Errorf("没有启用各来源的音量记录")
+	}
+	if err := profiles.Set(source, volume); err != nil {
+		return err
+	}
+	if source == active {
+		return ApplyVolume(volume)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package player
+
+import (
+	"fmt"
+)
+
+// CacheProgress 当前歌曲的缓存下载进度
+type CacheProgress struct {
+	ReadySize int64  `json:"ready_size"`
+	Size      int64  `json:"size"`
+	Status    string `json:"status"` // downloading/completed/error
+}
+
+// Status 播放器对外展示的完整状态
+type Status struct {
+	State    string            `json:"state"`    // playing/paused/stopped/buffering
+	Position float64           `json:"position"` // 当前位置（秒）
+	Duration float64           `json:"duration"` // 总时长（秒）
+	URL      string            `json:"url,omitempty"`
+	Track    *QueueItem        `json:"track,omitempty"` // 通过播放队列播放时的队列项
+	Tags     map[string]string `json:"tags,omitempty"`  // mpg123 读到的标签
+	Cache    *CacheProgress    `json:"cache,omitempty"`
+	Volume   int               `json:"volume"`
+	Muted    bool              `json:"muted,omitempty"`
+
+	// 直播流信息
+	Live       bool   `json:"live,omitempty"`
+	Name       string `json:"name,omitempty"`        // 播放电台时传入的名称
+	Station    string `json:"station,omitempty"`     // 服务器返回的 icy-name
+	NowPlaying string `json:"now_playing,omitempty"` // 最近一次 StreamTitle
+
+	Sleep     *SleepStatus `json:"sleep,omitempty"`     // 睡眠定时器，未设置时为空
+	Crossfade int          `json:"crossfade,omitempty"` // 自动切换下一首时淡出淡入的秒数
+
+	ReplayGain *ReplayGainStatus `json:"replaygain,omitempty"` // 响度均衡
+}
+
+// GetStatus 获取默认播放器的状态
+func GetStatus() (Status, error) {
+	initDefaultPlayer()
+	if defaultPlayer == nil {
+		return Status{}, fmt.Errorf("播放器初始化失败")
+	}
+	return defaultPlayer.Status(), nil
+}
+
+// Status 获取播放器状态快照，不会等待正在进行的播放操作
+func (p *AudioPlayer) Status() Status {
+	p.stateMu.RLock()
+	status := Status{
+		State:    p.state.String(),
+		Position: p.backend.Position(),
+		URL:      p.track.url,
+		Track:    p.track.item,
+
+		Live:       p.track.live,
+		Name:       p.track.name,
+		Station:    p.track.station,
+		NowPlaying: p.track.nowPlaying,
+	}
+	if p.track.buffering {
+		status.State = "buffering"
+	} else if p.state == StateEnded {
+		status.State = StateStopped.String()
+	}
+	if p.track.duration != nil {
+		status.Duration = p.track.duration.TotalSeconds
+	}
+	if len(p.track.tags) > 0 {
+		status.Tags = make(map[string]string, len(p.track.tags))
+		for k, v := range p.track.tags {
+			status.Tags[k] = v
+		}
+	}
+	p.stateMu.RUnlock()
+
+	if status.URL != "" {
+		if cached := p.cache.peek(status.URL); cached != nil {
+			cached.mutex.RLock()
+			status.Cache = &CacheProgress{
+				ReadySize: cached.ReadySize,
+				Size:      cached.Size,
+				Status:    cached.Status.String(),
+			}
+			cached.mutex.RUnlock()
+		}
+	}
+
+	status.Sleep = p.sleepStatus(status.Position, status.Duration)
+	status.Crossfade = p.crossfadeSeconds()
+	status.ReplayGain = p.replayGainStatus()
+	status.Volume, status.Muted = currentVolume()
+	return status
+}
+
+// trackState 当前歌曲的信息，由 stateMu 保护
+type trackState struct {
+	url       string
+	item      *QueueItem
+	duration  *AudioDuration
+	tags      map[string]string
+	buffering bool
+
+	live       bool
+	name       string
+	station    string
+	nowPlaying string
+}
+
+// setTrack 记录即将播放的歌曲并通知订阅者
+func (p *AudioPlayer) setTrack(url string, item *QueueItem, duration *AudioDuration) {
+	p.stateMu.Lock()
+	p.track = trackState{
+		url:       url,
+		item:      item,
+		duration:  duration,
+		tags:      make(map[string]string),
+		buffering: true,
+	}
+	p.stateMu.Unlock()
+
+	p.notify()
+}
+
+// setLive 标记当前歌曲为直播流，name 为电台名称，station 为服务器返回的 icy-name
+func (p *AudioPlayer) setLive(name, station string) {
+	p.stateMu.Lock()
+	p.track.live = true
+	p.track.name = name
+	p.track.station = station
+	p.stateMu.Unlock()
+
+	p.notify()
+}
+
+// setBuffering 更新缓冲状态并通知订阅者
+func (p *AudioPlayer) setBuffering(buffering bool) {
+	p.stateMu.Lock()
+	p.track.buffering = buffering
+	p.stateMu.Unlock()
+
+	p.notify()
+}
+
+// setTag 记录 mpg123 读到的标签
+func (p *AudioPlayer) setTag(tag *TagInfo) {
+	if tag == nil || tag.Key == "" {
+		return
+	}
+	p.stateMu.Lock()
+	if p.track.tags != nil {
+		p.track.tags[tag.Key] = tag.Value
+	}
+	p.stateMu.Unlock()
+}
+
+// notify 发布状态变化事件，订阅者收到后重新获取状态
+func (p *AudioPlayer) notify() {
+	p.stateMu.RLock()
+	state := p.state
+	p.stateMu.RUnlock()
+	p.events.publish(Event{Type: EventStatus, State: &state})
+}
@@ -1,42 +1,236 @@
 package player
 
 import (
+	"errors"
 	"fmt"
-	"os/exec"
-	"strconv"
-	"strings"
+	"log"
+	"sync"
+	"time"
 
 	"aku-web/internal/config"
+	"aku-web/internal/mixer"
 )
 
-// GetVolume 获取当前音量
-func GetVolume() (int, error) {
-	cmd := exec.Command("sh", "-c", "amixer get 'Power Amplifier' | grep 'Mono:' | awk '{print $2}'")
-	output, err := cmd.Output()
+// 最近一次读取或设置的音量，-1 表示尚未读取
+var (
+	lastVolume   = -1
+	lastMuted    bool
+	lastVolumeMu sync.Mutex
+)
+
+// 音量使用的混音器和控制项
+var (
+	volumeMixer   mixer.Mixer
+	volumeControl = config.MixerControl
+	volumeMixerMu sync.Mutex
+)
+
+// VolumeInfo 音量控制项、当前音量和声音来源
+type VolumeInfo struct {
+	mixer.Volume
+	Control mixer.Control `json:"control"`
+	Source  mixer.Source  `json:"source"`
+}
+
+// SetMixer 替换音量使用的混音器和控制项，例如没有声卡时使用 mixer.Fake
+func SetMixer(m mixer.Mixer, control string) {
+	volumeMixerMu.Lock()
+	volumeMixer, volumeControl = m, control
+	volumeMixerMu.Unlock()
+
+	lastVolumeMu.Lock()
+	lastVolume = -1
+	lastVolumeMu.Unlock()
+}
+
+// getMixer 返回混音器和控制项，第一次使用时根据配置创建
+func getMixer() (mixer.Mixer, string, error) {
+	volumeMixerMu.Lock()
+	defer volumeMixerMu.Unlock()
+	if volumeMixer == nil {
+		m, err := mixer.New(config.Mixer, config.MixerCard)
+		if err != nil {
+			return nil, "", err
+		}
+		volumeMixer = m
+	}
+	return volumeMixer, volumeControl, nil
+}
+
+// GetVolumeInfo 获取音量控制项的范围和当前音量
+func GetVolumeInfo() (VolumeInfo, error) {
+	m, control, err := getMixer()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get volume: %v", err)
+		return VolumeInfo{}, err
 	}
-	volumeStr := strings.TrimSpace(string(output))
-	volume, err := strconv.Atoi(volumeStr)
+	c, volume, err := m.Get(control)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse volume: %v", err)
+		return VolumeInfo{}, fmt.Errorf("获取音量失败: %v", err)
 	}
+	rememberVolume(volume.Raw, volume.Muted)
+	return VolumeInfo{Volume: volume, Control: c, Source: ActiveSource()}, nil
+}
 
-	return volume, nil
+// GetVolume 获取当前音量（控制项的原始值）
+func GetVolume() (int, error) {
+	info, err := GetVolumeInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.Raw, nil
 }
 
-// SetVolume 设置音量
+// SetVolume 按控制项的原始值设置音量，并记为当前声音来源的音量
 func SetVolume(volume int) error {
-	// 验证音量范围
-	if volume < 0 || volume > config.MaxVolume {
-		return fmt.Errorf("volume must be between 0 and %d", config.MaxVolume)
+	if err := ApplyVolume(volume); err != nil {
+		return err
+	}
+	rememberSourceVolume()
+	return nil
+}
+
+// ApplyVolume 按原始值设置音量，但不记为来源的音量，用于渐变、恢复等自动调整
+func ApplyVolume(volume int) error {
+	m, control, err := getMixer()
+	if err != nil {
+		return err
+	}
+	if err := m.SetRaw(control, volume); err != nil {
+		return fmt.Errorf("设置音量失败: %v", err)
+	}
+	refreshVolume(m, control)
+	return nil
+}
+
+// SetVolumePercent 按百分比设置音量，并记为当前声音来源的音量
+func SetVolumePercent(percent int) error {
+	m, control, err := getMixer()
+	if err != nil {
+		return err
+	}
+	if err := mixer.SetPercent(m, control, percent); err != nil {
+		return fmt.Errorf("设置音量失败: %v", err)
+	}
+	refreshVolume(m, control)
+	rememberSourceVolume()
+	return nil
+}
+
+// SetVolumeDB 按分贝设置音量，并记为当前声音来源的音量
+func SetVolumeDB(db float64) error {
+	m, control, err := getMixer()
+	if err != nil {
+		return err
 	}
+	if err := m.SetDB(control, db); err != nil {
+		return fmt.Errorf("设置音量失败: %v", err)
+	}
+	refreshVolume(m, control)
+	rememberSourceVolume()
+	return nil
+}
+
+// SetMute 静音或取消静音
+func SetMute(muted bool) error {
+	m, control, err := getMixer()
+	if err != nil {
+		return err
+	}
+	if err := m.SetMute(control, muted); err != nil {
+		return fmt.Errorf("设置静音失败: %v", err)
+	}
+	refreshVolume(m, control)
+	return nil
+}
+
+// MixerControls 列出混音器的所有控制项
+func MixerControls() ([]mixer.Control, error) {
+	m, _, err := getMixer()
+	if err != nil {
+		return nil, err
+	}
+	return m.Controls()
+}
+
+// refreshVolume 设置后重新读取实际的音量（按分贝设置时硬件会取最接近的值）
+func refreshVolume(m mixer.Mixer, control string) {
+	_, volume, err := m.Get(control)
+	if err != nil {
+		log.Printf("读取音量失败: %v", err)
+		return
+	}
+	rememberVolume(volume.Raw, volume.Muted)
+}
 
-	// 设置音量
-	cmd := exec.Command("amixer", "set", "'Power Amplifier'", strconv.Itoa(volume))
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to set volume: %v", err)
+// ErrFadeCanceled 音量渐变被取消
+var ErrFadeCanceled = errors.New("音量渐变已取消")
+
+// 音量渐变时两次调整的最小间隔，避免频繁调用 amixer
+const minFadeStep = 100 * time.Millisecond
+
+// FadeVolume 在 duration 内把音量从 from 逐步调整到 to，cancel 关闭时停止并返回 ErrFadeCanceled
+func FadeVolume(from, to int, duration time.Duration, cancel <-chan struct{}) error {
+	if err := ApplyVolume(from); err != nil {
+		return err
+	}
+	steps := to - from
+	if steps < 0 {
+		steps = -steps
+	}
+	if steps == 0 || duration <= 0 {
+		return ApplyVolume(to)
+	}
+	if max := int(duration / minFadeStep); steps > max {
+		steps = max
+	}
+	if steps < 1 {
+		steps = 1
 	}
 
+	ticker := time.NewTicker(duration / time.Duration(steps))
+	defer ticker.Stop()
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-cancel:
+			return ErrFadeCanceled
+		case <-ticker.C:
+		}
+		if err := ApplyVolume(from + (to-from)*i/steps); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// rememberVolume 缓存音量，变化时通知播放器事件订阅者
+func rememberVolume(volume int, muted bool) {
+	lastVolumeMu.Lock()
+	changed := lastVolume != volume || lastMuted != muted
+	lastVolume, lastMuted = volume, muted
+	lastVolumeMu.Unlock()
+
+	if changed && defaultPlayer != nil {
+		defaultPlayer.events.publish(Event{Type: EventVolumeChange, Volume: float64(volume)})
+		defaultPlayer.notify()
+	}
+}
+
+// currentVolume 获取缓存的音量和静音状态，尚未读取过时读取一次
+func currentVolume() (int, bool) {
+	lastVolumeMu.Lock()
+	volume, muted := lastVolume, lastMuted
+	lastVolumeMu.Unlock()
+
+	if volume >= 0 {
+		return volume, muted
+	}
+	info, err := GetVolumeInfo()
+	if err != nil {
+		// 读取失败时记为 0，避免每次获取状态都调用 amixer
+		lastVolumeMu.Lock()
+		lastVolume = 0
+		lastVolumeMu.Unlock()
+		return 0, false
+	}
+	return info.Raw, info.Muted
+}
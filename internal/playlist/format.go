@@ -0,0 +1,329 @@
+package playlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format 歌单文件格式
+type Format string
+
+const (
+	FormatM3U  Format = "m3u"
+	FormatM3U8 Format = "m3u8"
+	FormatPLS  Format = "pls"
+	FormatXSPF Format = "xspf"
+)
+
+// ContentType 返回导出文件的 MIME 类型
+func (f Format) ContentType() string {
+	switch f {
+	case FormatPLS:
+		return "audio/x-scpls"
+	case FormatXSPF:
+		return "application/xspf+xml"
+	case FormatM3U8:
+		return "application/vnd.apple.mpegurl"
+	default:
+		return "audio/x-mpegurl"
+	}
+}
+
+// ParseFormat 解析格式名称
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimPrefix(name, "."))); f {
+	case FormatM3U, FormatM3U8, FormatPLS, FormatXSPF:
+		return f, nil
+	}
+	return "", fmt.Errorf("不支持的歌单格式: %s", name)
+}
+
+// DetectFormat 根据文件名和内容判断格式
+func DetectFormat(filename string, data []byte) (Format, error) {
+	if f, err := ParseFormat(path.Ext(filename)); err == nil {
+		return f, nil
+	}
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")))
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<playlist")):
+		return FormatXSPF, nil
+	case bytes.HasPrefix(bytes.ToLower(trimmed), []byte("[playlist]")):
+		return FormatPLS, nil
+	}
+	return FormatM3U8, nil
+}
+
+// Parse 解析歌单文件
+func Parse(format Format, r io.Reader) ([]Entry, error) {
+	switch format {
+	case FormatM3U, FormatM3U8:
+		return ParseM3U(r)
+	case FormatPLS:
+		return ParsePLS(r)
+	case FormatXSPF:
+		return ParseXSPF(r)
+	}
+	return nil, fmt.Errorf("不支持的歌单格式: %s", format)
+}
+
+// Write 导出歌单文件
+func Write(format Format, w io.Writer, p *Playlist) error {
+	switch format {
+	case FormatM3U, FormatM3U8:
+		return WriteM3U(w, p)
+	case FormatPLS:
+		return WritePLS(w, p)
+	case FormatXSPF:
+		return WriteXSPF(w, p)
+	}
+	return fmt.Errorf("不支持的歌单格式: %s", format)
+}
+
+// ParseM3U 解析 M3U/M3U8，支持 #EXTINF:时长,艺术家 - 标题
+func ParseM3U(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var pending *Entry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\xEF\xBB\xBF"))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			pending = &Entry{}
+			if comma := strings.Index(info, ","); comma >= 0 {
+				// 时长后面可能还有 key="value" 形式的属性
+				fields := strings.Fields(info[:comma])
+				if len(fields) > 0 {
+					if seconds, err := strconv.ParseFloat(fields[0], 64); err == nil && seconds > 0 {
+						pending.Duration = seconds
+					}
+				}
+				pending.Artists, pending.Title = splitArtistTitle(info[comma+1:])
+			}
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			entry := EntryFromLocation(line)
+			if pending != nil {
+				entry.Title, entry.Artists, entry.Duration = pending.Title, pending.Artists, pending.Duration
+				pending = nil
+			}
+			if entry.Title == "" {
+				entry.Title = titleFromLocation(line)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取歌单失败: %v", err)
+	}
+	return entries, nil
+}
+
+// WriteM3U 导出为扩展 M3U（UTF-8）
+func WriteM3U(w io.Writer, p *Playlist) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	fmt.Fprintf(bw, "#PLAYLIST:%s\n", p.Name)
+	for _, e := range p.Entries {
+		duration := -1
+		if e.Duration > 0 {
+			duration = int(e.Duration + 0.5)
+		}
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n", duration, e.displayName())
+		fmt.Fprintln(bw, e.Location())
+	}
+	return bw.Flush()
+}
+
+// ParsePLS 解析 PLS 格式
+func ParsePLS(r io.Reader) ([]Entry, error) {
+	type plsEntry struct {
+		file, title string
+		length      float64
+	}
+	items := make(map[int]*plsEntry)
+	get := func(n int) *plsEntry {
+		if items[n] == nil {
+			items[n] = &plsEntry{}
+		}
+		return items[n]
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		key, value := strings.ToLower(line[:eq]), strings.TrimSpace(line[eq+1:])
+		for _, prefix := range []string{"file", "title", "length"} {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			n, err := strconv.Atoi(key[len(prefix):])
+			if err != nil {
+				break
+			}
+			switch prefix {
+			case "file":
+				get(n).file = value
+			case "title":
+				get(n).title = value
+			case "length":
+				get(n).length, _ = strconv.ParseFloat(value, 64)
+			}
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取歌单失败: %v", err)
+	}
+
+	numbers := make([]int, 0, len(items))
+	for n := range items {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+
+	var entries []Entry
+	for _, n := range numbers {
+		item := items[n]
+		if item.file == "" {
+			continue
+		}
+		entry := EntryFromLocation(item.file)
+		entry.Artists, entry.Title = splitArtistTitle(item.title)
+		if entry.Title == "" {
+			entry.Title = titleFromLocation(item.file)
+		}
+		if item.length > 0 {
+			entry.Duration = item.length
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// WritePLS 导出为 PLS 格式
+func WritePLS(w io.Writer, p *Playlist) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "[playlist]")
+	for i, e := range p.Entries {
+		n := i + 1
+		length := -1
+		if e.Duration > 0 {
+			length = int(e.Duration + 0.5)
+		}
+		fmt.Fprintf(bw, "File%d=%s\n", n, e.Location())
+		fmt.Fprintf(bw, "Title%d=%s\n", n, e.displayName())
+		fmt.Fprintf(bw, "Length%d=%d\n", n, length)
+	}
+	fmt.Fprintf(bw, "NumberOfEntries=%d\n", len(p.Entries))
+	fmt.Fprintln(bw, "Version=2")
+	return bw.Flush()
+}
+
+// xspf 文件结构
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Version string      `xml:"version,attr"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Duration int64  `xml:"duration,omitempty"` // 毫秒
+}
+
+// ParseXSPF 解析 XSPF 格式
+func ParseXSPF(r io.Reader) ([]Entry, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("解析 XSPF 失败: %v", err)
+	}
+
+	var entries []Entry
+	for _, t := range doc.Tracks {
+		if t.Location == "" {
+			continue
+		}
+		entry := EntryFromLocation(t.Location)
+		entry.Title = t.Title
+		if entry.Title == "" && entry.Path != "" {
+			entry.Title = titleFromLocation(entry.Path)
+		} else if entry.Title == "" {
+			entry.Title = titleFromLocation(t.Location)
+		}
+		if t.Creator != "" {
+			entry.Artists = []string{t.Creator}
+		}
+		if t.Duration > 0 {
+			entry.Duration = float64(t.Duration) / 1000
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// WriteXSPF 导出为 XSPF 格式
+func WriteXSPF(w io.Writer, p *Playlist) error {
+	doc := xspfPlaylist{
+		Version: "1",
+		XMLNS:   "http://xspf.org/ns/0/",
+		Title:   p.Name,
+	}
+	for _, e := range p.Entries {
+		// XSPF 的 location 是 URI，本地文件写成 file: URI
+		location := e.Location()
+		if e.SongId == 0 && e.URL == "" {
+			location = fileURI(e.Path)
+		}
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Location: location,
+			Title:    e.Title,
+			Creator:  strings.Join(e.Artists, "/"),
+			Duration: int64(e.Duration * 1000),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// titleFromLocation 没有标题时使用文件名
+func titleFromLocation(location string) string {
+	if i := strings.IndexAny(location, "?#"); i >= 0 && strings.Contains(location, "://") {
+		location = location[:i]
+	}
+	location = strings.ReplaceAll(location, `\`, "/")
+	base := path.Base(location)
+	if unescaped, err := url.PathUnescape(base); err == nil && strings.Contains(location, "://") {
+		base = unescaped
+	}
+	return strings.TrimSuffix(base, path.Ext(base))
+}
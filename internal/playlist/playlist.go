@@ -0,0 +1,287 @@
+// Package playlist 保存命名歌单，支持本地文件、网络地址和网易云歌曲混合，并可导入导出 M3U/PLS/XSPF
+package playlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NeteaseScheme 导出为 M3U/PLS/XSPF 时网易云歌曲使用的地址前缀，其他播放器也能直接播放
+const NeteaseScheme = "https://music.163.com/song/media/outer/url?id="
+
+// 识别网易云歌曲地址：外链地址、网页地址和 netease:ID 写法
+var neteasePattern = regexp.MustCompile(`^(?:netease:(\d+)|https?://music\.163\.com/(?:#/)?song(?:/media/outer/url)?\?id=(\d+)(?:\.mp3)?)`)
+
+// Entry 歌单中的一项，Path、URL、SongId 三者只有一个有值
+type Entry struct {
+	Title    string   `json:"title"`
+	Artists  []string `json:"artists,omitempty"`
+	Path     string   `json:"path,omitempty"`    // 本地文件
+	URL      string   `json:"url,omitempty"`     // 网络地址
+	SongId   uint     `json:"song_id,omitempty"` // 网易云歌曲ID
+	Duration float64  `json:"duration,omitempty"`
+}
+
+// Location 返回写入歌单文件时使用的地址
+func (e Entry) Location() string {
+	switch {
+	case e.SongId != 0:
+		return NeteaseScheme + strconv.FormatUint(uint64(e.SongId), 10)
+	case e.URL != "":
+		return e.URL
+	default:
+		return e.Path
+	}
+}
+
+// Validate 检查歌单项是否有可播放的地址
+func (e Entry) Validate() error {
+	n := 0
+	if e.Path != "" {
+		n++
+	}
+	if e.URL != "" {
+		n++
+	}
+	if e.SongId != 0 {
+		n++
+	}
+	if n != 1 {
+		return fmt.Errorf("歌单项 %q 必须且只能指定 path、url、song_id 中的一个", e.Title)
+	}
+	return nil
+}
+
+// EntryFromLocation 根据歌单文件中的地址创建歌单项
+func EntryFromLocation(location string) Entry {
+	location = strings.TrimSpace(location)
+	if m := neteasePattern.FindStringSubmatch(location); m != nil {
+		idStr := m[1]
+		if idStr == "" {
+			idStr = m[2]
+		}
+		if id, err := strconv.ParseUint(idStr, 10, 32); err == nil {
+			return Entry{SongId: uint(id)}
+		}
+	}
+	lower := strings.ToLower(location)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		return Entry{URL: location}
+	}
+	// file: 地址是 URI，路径中的空格等字符经过百分号编码
+	if strings.HasPrefix(lower, "file:") {
+		if u, err := url.Parse(location); err == nil && u.Path != "" {
+			return Entry{Path: filepath.FromSlash(u.Path)}
+		}
+	}
+	return Entry{Path: location}
+}
+
+// fileURI 把本地路径转换为 file: URI，相对路径按工作目录转换为绝对路径
+func fileURI(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String()
+}
+
+// splitArtistTitle 把 "艺术家 - 标题" 拆开
+func splitArtistTitle(s string) ([]string, string) {
+	if i := strings.Index(s, " - "); i > 0 {
+		return []string{strings.TrimSpace(s[:i])}, strings.TrimSpace(s[i+3:])
+	}
+	return nil, strings.TrimSpace(s)
+}
+
+// displayName 返回 "艺术家 - 标题" 形式的名称
+func (e Entry) displayName() string {
+	if len(e.Artists) > 0 {
+		return strings.Join(e.Artists, "/") + " - " + e.Title
+	}
+	return e.Title
+}
+
+// Playlist 命名歌单
+type Playlist struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Entries []Entry   `json:"entries"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// Summary 歌单列表中的一项，不包含歌曲
+type Summary struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Count   int       `json:"count"`
+	Updated time.Time `json:"updated"`
+}
+
+// Store 歌单存储，保存为一个 JSON 文件
+type Store struct {
+	path      string
+	playlists map[string]*Playlist
+	mutex     sync.RWMutex
+}
+
+// NewStore 创建歌单存储并加载已有歌单
+func NewStore(path string) *Store {
+	s := &Store{
+		path:      path,
+		playlists: make(map[string]*Playlist),
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var playlists []*Playlist
+		if err := json.Unmarshal(data, &playlists); err != nil {
+			log.Printf("[Playlist] 歌单文件损坏: %v", err)
+		}
+		for _, p := range playlists {
+			s.playlists[p.ID] = p
+		}
+	}
+	return s
+}
+
+// save 写入磁盘，调用方需持有锁
+func (s *Store) save() error {
+	playlists := make([]*Playlist, 0, len(s.playlists))
+	for _, p := range s.playlists {
+		playlists = append(playlists, p)
+	}
+	sort.Slice(playlists, func(i, j int) bool { return playlists[i].Created.Before(playlists[j].Created) })
+
+	data, err := json.MarshalIndent(playlists, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("保存歌单失败: %v", err)
+	}
+	return os.Rename(s.path+".tmp", s.path)
+}
+
+// List 列出所有歌单，最近更新的在前
+func (s *Store) List() []Summary {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	list := make([]Summary, 0, len(s.playlists))
+	for _, p := range s.playlists {
+		list = append(list, Summary{ID: p.ID, Name: p.Name, Count: len(p.Entries), Updated: p.Updated})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Updated.After(list[j].Updated) })
+	return list
+}
+
+// Get 获取歌单的副本
+func (s *Store) Get(id string) (*Playlist, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	p, ok := s.playlists[id]
+	if !ok {
+		return nil, fmt.Errorf("歌单不存在: %s", id)
+	}
+	copied := *p
+	copied.Entries = append([]Entry{}, p.Entries...)
+	return &copied, nil
+}
+
+// Create 创建歌单
+func (s *Store) Create(name string, entries []Entry) (*Playlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("歌单名称不能为空")
+	}
+	if err := validate(entries); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p := &Playlist{
+		ID:      strconv.FormatInt(now.UnixNano(), 36),
+		Name:    name,
+		Entries: append([]Entry{}, entries...),
+		Created: now,
+		Updated: now,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.playlists[p.ID] = p
+	if err := s.save(); err != nil {
+		delete(s.playlists, p.ID)
+		return nil, err
+	}
+	copied := *p
+	return &copied, nil
+}
+
+// Update 修改歌单名称和歌曲，name 为空或 entries 为 nil 时保持不变
+func (s *Store) Update(id, name string, entries []Entry) (*Playlist, error) {
+	if err := validate(entries); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	p, ok := s.playlists[id]
+	if !ok {
+		return nil, fmt.Errorf("歌单不存在: %s", id)
+	}
+	old := *p
+	if name = strings.TrimSpace(name); name != "" {
+		p.Name = name
+	}
+	if entries != nil {
+		p.Entries = append([]Entry{}, entries...)
+	}
+	p.Updated = time.Now()
+
+	if err := s.save(); err != nil {
+		*p = old
+		return nil, err
+	}
+	copied := *p
+	return &copied, nil
+}
+
+// Delete 删除歌单
+func (s *Store) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	p, ok := s.playlists[id]
+	if !ok {
+		return fmt.Errorf("歌单不存在: %s", id)
+	}
+	delete(s.playlists, id)
+	if err := s.save(); err != nil {
+		s.playlists[id] = p
+		return err
+	}
+	return nil
+}
+
+func validate(entries []Entry) error {
+	for _, e := range entries {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Package radio 保存网络电台列表，并把 PLS/M3U 形式的电台地址解析为实际的流地址
+package radio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aku-web/internal/playlist"
+)
+
+// Station 网络电台
+type Station struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Genre string `json:"genre,omitempty"`
+}
+
+// Store 电台列表，保存为一个 JSON 文件
+type Store struct {
+	path     string
+	stations []Station
+	mutex    sync.RWMutex
+}
+
+// NewStore 创建电台列表并加载已保存的电台
+func NewStore(path string) *Store {
+	s := &Store{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &s.stations); err != nil {
+			log.Printf("[Radio] 电台列表文件损坏: %v", err)
+		}
+	}
+	return s
+}
+
+// save 写入磁盘，调用方需持有锁
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.stations, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("保存电台列表失败: %v", err)
+	}
+	return os.Rename(s.path+".tmp", s.path)
+}
+
+// List 返回所有电台
+func (s *Store) List() []Station {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]Station{}, s.stations...)
+}
+
+// Get 按ID查找电台
+func (s *Store) Get(id string) (Station, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, st := range s.stations {
+		if st.ID == id {
+			return st, nil
+		}
+	}
+	return Station{}, fmt.Errorf("电台不存在: %s", id)
+}
+
+// Add 添加电台
+func (s *Store) Add(station Station) (Station, error) {
+	if err := validate(station); err != nil {
+		return Station{}, err
+	}
+	station.ID = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stations = append(s.stations, station)
+	if err := s.save(); err != nil {
+		s.stations = s.stations[:len(s.stations)-1]
+		return Station{}, err
+	}
+	return station, nil
+}
+
+// Update 修改电台信息
+func (s *Store) Update(station Station) (Station, error) {
+	if err := validate(station); err != nil {
+		return Station{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.stations {
+		if s.stations[i].ID == station.ID {
+			old := s.stations[i]
+			s.stations[i] = station
+			if err := s.save(); err != nil {
+				s.stations[i] = old
+				return Station{}, err
+			}
+			return station, nil
+		}
+	}
+	return Station{}, fmt.Errorf("电台不存在: %s", station.ID)
+}
+
+// Delete 删除电台
+func (s *Store) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.stations {
+		if s.stations[i].ID == id {
+			old := s.stations
+			s.stations = append(append([]Station{}, s.stations[:i]...), s.stations[i+1:]...)
+			if err := s.save(); err != nil {
+				s.stations = old
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("电台不存在: %s", id)
+}
+
+func validate(station Station) error {
+	if strings.TrimSpace(station.Name) == "" {
+		return fmt.Errorf("电台名称不能为空")
+	}
+	lower := strings.ToLower(station.URL)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return fmt.Errorf("电台地址必须以 http:// 或 https:// 开头")
+	}
+	return nil
+}
+
+// 电台播放列表文件的类型
+var playlistTypes = map[string]playlist.Format{
+	"audio/x-scpls":        playlist.FormatPLS,
+	"audio/x-mpegurl":      playlist.FormatM3U,
+	"audio/mpegurl":        playlist.FormatM3U,
+	"application/xspf+xml": playlist.FormatXSPF,
+}
+
+var resolveClient = &http.Client{Timeout: 10 * time.Second}
+
+// ResolveStreamURL 电台地址是 PLS/M3U/XSPF 播放列表时返回其中第一个流地址，否则原样返回
+func ResolveStreamURL(url string) (string, error) {
+	ext := strings.ToLower(path.Ext(strings.SplitN(url, "?", 2)[0]))
+	format, byExt := playlist.ParseFormat(ext)
+	if byExt != nil {
+		// 扩展名看不出来时根据响应类型判断，不读取音频数据
+		resp, err := resolveClient.Head(url)
+		if err != nil {
+			return url, nil
+		}
+		resp.Body.Close()
+		contentType := strings.ToLower(strings.TrimSpace(strings.SplitN(resp.Header.Get("Content-Type"), ";", 2)[0]))
+		var ok bool
+		if format, ok = playlistTypes[contentType]; !ok {
+			return url, nil
+		}
+	}
+
+	resp, err := resolveClient.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("获取电台播放列表失败: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("获取电台播放列表失败: %v", err)
+	}
+	if strings.Contains(string(data), "#EXT-X-") {
+		return "", fmt.Errorf("暂不支持 HLS 电台")
+	}
+
+	entries, err := playlist.Parse(format, strings.NewReader(string(data)))
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.URL != "" {
+			return e.URL, nil
+		}
+	}
+	return "", fmt.Errorf("电台播放列表中没有可用的地址")
+}
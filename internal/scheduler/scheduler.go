@@ -0,0 +1,495 @@
+// Package scheduler 定时任务：闹钟、定时播放、定时显示和定时启停服务
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aku-web/internal/config"
+)
+
+// ActionType 任务动作类型
+type ActionType string
+
+const (
+	ActionPlay         ActionType = "play"          // 播放歌曲，Target 为本地路径、URL 或 netease:歌曲ID
+	ActionPlaylist     ActionType = "playlist"      // 播放保存的歌单，Target 为歌单ID
+	ActionRadio        ActionType = "radio"         // 播放网络电台，Target 为电台ID
+	ActionText         ActionType = "text"          // 屏幕显示文字，Target 为文字内容
+	ActionImage        ActionType = "image"         // 屏幕显示图片，Target 为图片路径
+	ActionServiceStart ActionType = "service_start" // 启动服务，Target 为服务名
+	ActionServiceStop  ActionType = "service_stop"  // 停止服务，Target 为服务名
+)
+
+// IsAlarm 播放类动作触发后作为闹钟，可以稍后提醒或关闭
+func (t ActionType) IsAlarm() bool {
+	return t == ActionPlay || t == ActionPlaylist || t == ActionRadio
+}
+
+// Action 任务触发时执行的动作
+type Action struct {
+	Type     ActionType `json:"type"`
+	Target   string     `json:"target"`
+	Volume   int        `json:"volume,omitempty"`    // 播放音量，0 表示使用当前音量
+	FadeIn   int        `json:"fade_in,omitempty"`   // 音量渐强的秒数，0 表示不渐强
+	FontSize int        `json:"font_size,omitempty"` // 显示文字的字号
+	Color    string     `json:"color,omitempty"`     // 显示文字的颜色
+}
+
+// Job 定时任务。Cron 和 At 二选一：Cron 为周期任务，At 为一次性任务
+type Job struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Enabled   bool       `json:"enabled"`
+	Cron      string     `json:"cron,omitempty"`
+	At        *time.Time `json:"at,omitempty"`
+	Action    Action     `json:"action"`
+	NextRun   *time.Time `json:"next_run,omitempty"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	Created   time.Time  `json:"created"`
+}
+
+// Alarm 正在响铃或稍后提醒中的闹钟
+type Alarm struct {
+	JobID        string     `json:"job_id"`
+	Name         string     `json:"name"`
+	Started      time.Time  `json:"started"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	Snoozes      int        `json:"snoozes"`
+}
+
+// Runner 执行任务动作，由调用方实现以避免 scheduler 依赖播放器和显示模块
+type Runner interface {
+	Run(job Job) error
+	Silence() // 停止闹钟的播放
+}
+
+const (
+	tickInterval = time.Second
+	missedGrace  = 5 * time.Minute // 超过这个时间未执行的任务视为错过，不再补执行
+	alarmExpiry  = time.Hour       // 闹钟响铃超过这个时间没有处理时自动结束
+)
+
+// Scheduler 定时任务调度器，任务保存为一个 JSON 文件
+type Scheduler struct {
+	path     string
+	runner   Runner
+	jobs     []Job
+	alarms   map[string]*Alarm
+	lastTick time.Time
+	stop     chan struct{}
+	mutex    sync.Mutex
+}
+
+// New 创建调度器并加载已保存的任务，调用 Start 后开始调度
+func New(path string, runner Runner) *Scheduler {
+	s := &Scheduler{
+		path:   path,
+		runner: runner,
+		alarms: make(map[string]*Alarm),
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &s.jobs); err != nil {
+			log.Printf("[Scheduler] 定时任务文件损坏: %v", err)
+		}
+	}
+
+	now := time.Now()
+	for i := range s.jobs {
+		s.jobs[i].NextRun = nextRun(&s.jobs[i], now)
+	}
+	return s
+}
+
+// Start 启动调度循环
+func (s *Scheduler) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.lastTick = time.Now()
+	go s.loop(s.stop)
+	log.Printf("[Scheduler] 调度已启动，共 %d 个任务", len(s.jobs))
+}
+
+// Stop 停止调度循环
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *Scheduler) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			s.tick(now)
+		}
+	}
+}
+
+// tick 执行到期的任务和稍后提醒的闹钟
+func (s *Scheduler) tick(now time.Time) {
+	s.mutex.Lock()
+
+	// 时钟被往回调（例如同步时间）时重新计算所有任务的下次执行时间
+	if now.Before(s.lastTick.Add(-time.Minute)) {
+		log.Printf("[Scheduler] 系统时间回退，重新计算任务时间")
+		for i := range s.jobs {
+			s.jobs[i].NextRun = nextRun(&s.jobs[i], now)
+		}
+	}
+	s.lastTick = now
+
+	var due []Job
+	changed := false
+	for i := range s.jobs {
+		job := &s.jobs[i]
+		if !job.Enabled || job.NextRun == nil || now.Before(*job.NextRun) {
+			continue
+		}
+		if late := now.Sub(*job.NextRun); late > missedGrace {
+			log.Printf("[Scheduler] 任务 %s 已错过执行时间 %s", job.Name, job.NextRun.Format(time.DateTime))
+		} else {
+			ran := now
+			job.LastRun = &ran
+			due = append(due, *job)
+		}
+		if job.At != nil {
+			job.Enabled = false // 一次性任务只执行一次
+		}
+		job.NextRun = nextRun(job, now)
+		changed = true
+	}
+
+	var snoozed []Job
+	for id, alarm := range s.alarms {
+		switch {
+		case alarm.SnoozedUntil != nil && !now.Before(*alarm.SnoozedUntil):
+			if job := s.findLocked(id); job != nil {
+				alarm.SnoozedUntil = nil
+				alarm.Started = now
+				snoozed = append(snoozed, *job)
+			} else {
+				delete(s.alarms, id)
+			}
+		case alarm.SnoozedUntil == nil && now.Sub(alarm.Started) > alarmExpiry:
+			delete(s.alarms, id)
+		}
+	}
+
+	if changed {
+		if err := s.save(); err != nil {
+			log.Printf("[Scheduler] %v", err)
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, job := range due {
+		go s.fire(job, true)
+	}
+	for _, job := range snoozed {
+		go s.fire(job, false)
+	}
+}
+
+// fire 执行任务，newAlarm 为 false 表示稍后提醒的闹钟再次响铃
+func (s *Scheduler) fire(job Job, newAlarm bool) {
+	log.Printf("[Scheduler] 执行任务 %s (%s %s)", job.Name, job.Action.Type, job.Action.Target)
+
+	if job.Action.Type.IsAlarm() && newAlarm {
+		s.mutex.Lock()
+		s.alarms[job.ID] = &Alarm{JobID: job.ID, Name: job.Name, Started: time.Now()}
+		s.mutex.Unlock()
+	}
+
+	err := s.runner.Run(job)
+	if err != nil {
+		log.Printf("[Scheduler] 任务 %s 执行失败: %v", job.Name, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err != nil && job.Action.Type.IsAlarm() {
+		delete(s.alarms, job.ID)
+	}
+	if j := s.findLocked(job.ID); j != nil {
+		lastError := ""
+		if err != nil {
+			lastError = err.Error()
+		}
+		if j.LastError != lastError {
+			j.LastError = lastError
+			if err := s.save(); err != nil {
+				log.Printf("[Scheduler] %v", err)
+			}
+		}
+	}
+}
+
+// save 写入磁盘，调用方需持有锁
+func (s *Scheduler) save() error {
+	data, err := json.MarshalIndent(s.jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("保存定时任务失败: %v", err)
+	}
+	return os.Rename(s.path+".tmp", s.path)
+}
+
+func (s *Scheduler) findLocked(id string) *Job {
+	for i := range s.jobs {
+		if s.jobs[i].ID == id {
+			return &s.jobs[i]
+		}
+	}
+	return nil
+}
+
+// nextRun 计算任务在 now 之后的下次执行时间，没有时返回 nil
+func nextRun(job *Job, now time.Time) *time.Time {
+	if !job.Enabled {
+		return nil
+	}
+	if job.At != nil {
+		if now.After(job.At.Add(missedGrace)) {
+			return nil
+		}
+		at := *job.At
+		return &at
+	}
+	schedule, err := ParseCron(job.Cron)
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// validate 检查任务的时间和动作
+func validate(job *Job) error {
+	if strings.TrimSpace(job.Name) == "" {
+		return fmt.Errorf("任务名称不能为空")
+	}
+	switch {
+	case job.Cron != "" && job.At != nil:
+		return fmt.Errorf("cron 和 at 只能设置一个")
+	case job.Cron != "":
+		if _, err := ParseCron(job.Cron); err != nil {
+			return err
+		}
+	case job.At == nil:
+		return fmt.Errorf("需要设置 cron 或 at")
+	}
+
+	a := job.Action
+	switch a.Type {
+	case ActionPlay, ActionPlaylist, ActionRadio, ActionText, ActionImage, ActionServiceStart, ActionServiceStop:
+	default:
+		return fmt.Errorf("不支持的动作类型: %q", a.Type)
+	}
+	if strings.TrimSpace(a.Target) == "" {
+		return fmt.Errorf("动作缺少目标")
+	}
+	if a.Volume < 0 {
+		return fmt.Errorf("音量不能为负数")
+	}
+	if a.FadeIn < 0 {
+		return fmt.Errorf("渐强时间不能为负数")
+	}
+	return nil
+}
+
+// List 返回所有任务，按下次执行时间排序，不会执行的任务排在最后
+func (s *Scheduler) List() []Job {
+	s.mutex.Lock()
+	jobs := append([]Job{}, s.jobs...)
+	s.mutex.Unlock()
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := jobs[i].NextRun, jobs[j].NextRun
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+	return jobs
+}
+
+// Get 按ID查找任务
+func (s *Scheduler) Get(id string) (Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if job := s.findLocked(id); job != nil {
+		return *job, nil
+	}
+	return Job{}, fmt.Errorf("定时任务不存在: %s", id)
+}
+
+// Create 添加任务
+func (s *Scheduler) Create(job Job) (Job, error) {
+	if err := validate(&job); err != nil {
+		return Job{}, err
+	}
+	now := time.Now()
+	if job.At != nil && job.At.Before(now) {
+		return Job{}, fmt.Errorf("执行时间已经过去: %s", job.At.Format(time.DateTime))
+	}
+	job.ID = strconv.FormatInt(now.UnixNano(), 36)
+	job.Created = now
+	job.LastRun, job.LastError = nil, ""
+	job.NextRun = nextRun(&job, now)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jobs = append(s.jobs, job)
+	if err := s.save(); err != nil {
+		s.jobs = s.jobs[:len(s.jobs)-1]
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Update 修改任务的名称、时间、动作和启用状态
+func (s *Scheduler) Update(job Job) (Job, error) {
+	if err := validate(&job); err != nil {
+		return Job{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	existing := s.findLocked(job.ID)
+	if existing == nil {
+		return Job{}, fmt.Errorf("定时任务不存在: %s", job.ID)
+	}
+
+	old := *existing
+	existing.Name = job.Name
+	existing.Enabled = job.Enabled
+	existing.Cron = job.Cron
+	existing.At = job.At
+	existing.Action = job.Action
+	existing.NextRun = nextRun(existing, time.Now())
+	if err := s.save(); err != nil {
+		*existing = old
+		return Job{}, err
+	}
+	return *existing, nil
+}
+
+// Delete 删除任务，正在响铃的闹钟会被关闭
+func (s *Scheduler) Delete(id string) error {
+	s.mutex.Lock()
+	for i := range s.jobs {
+		if s.jobs[i].ID != id {
+			continue
+		}
+		old := s.jobs
+		s.jobs = append(append([]Job{}, s.jobs[:i]...), s.jobs[i+1:]...)
+		if err := s.save(); err != nil {
+			s.jobs = old
+			s.mutex.Unlock()
+			return err
+		}
+		_, ringing := s.alarms[id]
+		delete(s.alarms, id)
+		s.mutex.Unlock()
+		if ringing {
+			s.runner.Silence()
+		}
+		return nil
+	}
+	s.mutex.Unlock()
+	return fmt.Errorf("定时任务不存在: %s", id)
+}
+
+// RunNow 立即执行一次任务，不影响下次执行时间
+func (s *Scheduler) RunNow(id string) error {
+	job, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	go s.fire(job, true)
+	return nil
+}
+
+// Active 返回正在响铃或稍后提醒中的闹钟
+func (s *Scheduler) Active() []Alarm {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	alarms := make([]Alarm, 0, len(s.alarms))
+	for _, alarm := range s.alarms {
+		alarms = append(alarms, *alarm)
+	}
+	sort.Slice(alarms, func(i, j int) bool { return alarms[i].Started.Before(alarms[j].Started) })
+	return alarms
+}
+
+// Snooze 停止响铃，minutes 分钟后再次响铃。id 为空时作用于所有正在响铃的闹钟
+func (s *Scheduler) Snooze(id string, minutes int) ([]Alarm, error) {
+	if minutes <= 0 {
+		minutes = config.DefaultSnoozeMinutes
+	}
+	until := time.Now().Add(time.Duration(minutes) * time.Minute)
+
+	s.mutex.Lock()
+	var result []Alarm
+	for key, alarm := range s.alarms {
+		if (id == "" && alarm.SnoozedUntil == nil) || key == id {
+			alarm.SnoozedUntil = &until
+			alarm.Snoozes++
+			result = append(result, *alarm)
+		}
+	}
+	s.mutex.Unlock()
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("没有正在响铃的闹钟")
+	}
+	s.runner.Silence()
+	return result, nil
+}
+
+// Dismiss 关闭闹钟。id 为空时关闭所有闹钟
+func (s *Scheduler) Dismiss(id string) error {
+	s.mutex.Lock()
+	found, ringing := false, false
+	for key, alarm := range s.alarms {
+		if id == "" || key == id {
+			found = true
+			ringing = ringing || alarm.SnoozedUntil == nil
+			delete(s.alarms, key)
+		}
+	}
+	s.mutex.Unlock()
+
+	if !found {
+		return fmt.Errorf("没有正在响铃的闹钟")
+	}
+	if ringing {
+		s.runner.Silence()
+	}
+	return nil
+}
@@ -25,13 +25,104 @@ func RegisterRoutes() {
 	http.HandleFunc("/api/music/resume", api.HandleResumeMusic)
 	http.HandleFunc("/api/music/seek", api.HandleSeekTo)
 
+	// 音乐文件管理路由
+	http.HandleFunc("/api/music/upload", api.HandleMusicUpload)
+	http.HandleFunc("/api/music/rename", api.HandleMusicRename)
+	http.HandleFunc("/api/music/delete", api.HandleMusicDelete)
+	http.HandleFunc("/api/music/mkdir", api.HandleMusicMkdir)
+
+	// 本地音乐库路由
+	http.HandleFunc("/api/library/tracks", api.HandleLibraryTracks)
+	http.HandleFunc("/api/library/albums", api.HandleLibraryAlbums)
+	http.HandleFunc("/api/library/artists", api.HandleLibraryArtists)
+	http.HandleFunc("/api/library/search", api.HandleLibrarySearch)
+	http.HandleFunc("/api/library/cover", api.HandleLibraryCover)
+	http.HandleFunc("/api/library/rescan", api.HandleLibraryRescan)
+
+	// 播放器状态路由
+	http.HandleFunc("/api/player/status", api.HandlePlayerStatus)
+	http.HandleFunc("/api/player/events", api.HandlePlayerEvents)
+	http.HandleFunc("/api/player/sleep", api.HandlePlayerSleep)
+	http.HandleFunc("/api/player/sleep/cancel", api.HandlePlayerSleepCancel)
+	http.HandleFunc("/api/player/crossfade", api.HandlePlayerCrossfade)
+	http.HandleFunc("/api/player/replaygain", api.HandlePlayerReplayGain)
+
+	// 播放历史路由
+	http.HandleFunc("/api/history", api.HandleHistory)
+	http.HandleFunc("/api/stats", api.HandleStats)
+
+	// 播放队列路由
+	http.HandleFunc("/api/queue/list", api.HandleQueueList)
+	http.HandleFunc("/api/queue/add", api.HandleQueueAdd)
+	http.HandleFunc("/api/queue/remove", api.HandleQueueRemove)
+	http.HandleFunc("/api/queue/move", api.HandleQueueMove)
+	http.HandleFunc("/api/queue/clear", api.HandleQueueClear)
+	http.HandleFunc("/api/queue/play", api.HandleQueuePlay)
+	http.HandleFunc("/api/queue/next", api.HandleQueueNext)
+	http.HandleFunc("/api/queue/previous", api.HandleQueuePrevious)
+	http.HandleFunc("/api/queue/shuffle", api.HandleQueueShuffle)
+	http.HandleFunc("/api/queue/repeat", api.HandleQueueRepeat)
+
+	// 音频缓存路由
+	http.HandleFunc("/api/cache/stats", api.HandleCacheStats)
+	http.HandleFunc("/api/cache/list", api.HandleCacheList)
+	http.HandleFunc("/api/cache/purge", api.HandleCachePurge)
+
 	// 音量控制路由
 	http.HandleFunc("/api/volume/get", api.HandleVolumeGet)
 	http.HandleFunc("/api/volume/set", api.HandleVolumeSet)
+	http.HandleFunc("/api/volume/mute", api.HandleVolumeMute)
+	http.HandleFunc("/api/volume/controls", api.HandleVolumeControls)
+	http.HandleFunc("/api/volume/profiles", api.HandleVolumeProfiles)
+	http.HandleFunc("/api/volume/profiles/set", api.HandleVolumeProfileSet)
+	http.HandleFunc("/api/volume/events", api.HandleVolumeEvents)
+
+	// 音频焦点路由
+	http.HandleFunc("/api/focus/status", api.HandleFocusStatus)
+	http.HandleFunc("/api/focus/rules", api.HandleFocusRules)
 
 	// 网易云歌单相关路由
 	http.HandleFunc("/api/playlist/detail", api.HandlePlaylistDetail)
 	http.HandleFunc("/api/playlist/play", api.HandlePlaylistPlay)
+	http.HandleFunc("/api/netease/search", api.HandleNeteaseSearch)
+	http.HandleFunc("/api/netease/album", api.HandleNeteaseAlbum)
+	http.HandleFunc("/api/netease/artist/songs", api.HandleNeteaseArtistSongs)
+	http.HandleFunc("/api/netease/lyric", api.HandleNeteaseLyric)
+	http.HandleFunc("/api/netease/cache", api.HandleNeteaseCacheStats)
+	http.HandleFunc("/api/netease/cache/clear", api.HandleNeteaseCacheClear)
+
+	// 歌词路由
+	http.HandleFunc("/api/lyrics/current", api.HandleLyricsCurrent)
+	http.HandleFunc("/api/lyrics/display", api.HandleLyricsDisplay)
+
+	// 本地歌单路由
+	http.HandleFunc("/api/playlists/list", api.HandlePlaylistList)
+	http.HandleFunc("/api/playlists/get", api.HandlePlaylistGet)
+	http.HandleFunc("/api/playlists/create", api.HandlePlaylistCreate)
+	http.HandleFunc("/api/playlists/update", api.HandlePlaylistUpdate)
+	http.HandleFunc("/api/playlists/delete", api.HandlePlaylistDelete)
+	http.HandleFunc("/api/playlists/import", api.HandlePlaylistImport)
+	http.HandleFunc("/api/playlists/export", api.HandlePlaylistExport)
+	http.HandleFunc("/api/playlists/load", api.HandlePlaylistLoad)
+
+	// 网络电台路由
+	http.HandleFunc("/api/radio/list", api.HandleRadioList)
+	http.HandleFunc("/api/radio/add", api.HandleRadioAdd)
+	http.HandleFunc("/api/radio/update", api.HandleRadioUpdate)
+	http.HandleFunc("/api/radio/delete", api.HandleRadioDelete)
+	http.HandleFunc("/api/radio/play", api.HandleRadioPlay)
+	http.HandleFunc("/api/radio/now", api.HandleRadioNow)
+
+	// 定时任务路由
+	http.HandleFunc("/api/schedule/list", api.HandleScheduleList)
+	http.HandleFunc("/api/schedule/get", api.HandleScheduleGet)
+	http.HandleFunc("/api/schedule/create", api.HandleScheduleCreate)
+	http.HandleFunc("/api/schedule/update", api.HandleScheduleUpdate)
+	http.HandleFunc("/api/schedule/delete", api.HandleScheduleDelete)
+	http.HandleFunc("/api/schedule/run", api.HandleScheduleRun)
+	http.HandleFunc("/api/schedule/active", api.HandleScheduleActive)
+	http.HandleFunc("/api/schedule/snooze", api.HandleScheduleSnooze)
+	http.HandleFunc("/api/schedule/dismiss", api.HandleScheduleDismiss)
 
 	// 第三方服务管理路由
 	http.HandleFunc("/api/service/start", api.HandleServiceStart)
@@ -12,6 +12,29 @@ var (
 	regMux   sync.RWMutex
 )
 
+// 服务输出显示开始活动（如小智开始说话）或回到空闲时的回调
+var (
+	activityHandler func(service string, active bool)
+	activityMu      sync.RWMutex
+)
+
+// SetActivityHandler 设置服务活动状态变化的回调
+func SetActivityHandler(handler func(service string, active bool)) {
+	activityMu.Lock()
+	activityHandler = handler
+	activityMu.Unlock()
+}
+
+// notifyActivity 通知服务的活动状态
+func notifyActivity(service string, active bool) {
+	activityMu.RLock()
+	handler := activityHandler
+	activityMu.RUnlock()
+	if handler != nil {
+		handler(service, active)
+	}
+}
+
 // GetService 获取服务实例，如果不存在则创建
 func GetService(name string) (Service, error) {
 	regMux.Lock()
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -87,6 +88,7 @@ func (s *XiaozhiService) Stop() error {
 	}
 
 	s.cleanup()
+	notifyActivity(s.name, false)
 	return nil
 }
 
@@ -155,6 +157,7 @@ func (s *XiaozhiService) startCommand(cmd *exec.Cmd) error {
 					msg := string(buf[:n])
 					log.Printf("Read %d bytes from command output", n)
 					s.SendOutput(msg)
+					s.detectActivity(msg)
 				}
 				if err != nil {
 					if err != io.EOF {
@@ -171,3 +174,23 @@ func (s *XiaozhiService) startCommand(cmd *exec.Cmd) error {
 	log.Printf("Command started successfully")
 	return nil
 }
+
+// detectActivity 根据主服务的输出判断小智是否在说话或聆听，同一段输出中以最后出现的关键字为准
+func (s *XiaozhiService) detectActivity(output string) {
+	text := strings.ToLower(output)
+	lastIndex := func(patterns []string) int {
+		last := -1
+		for _, p := range patterns {
+			if i := strings.LastIndex(text, strings.ToLower(p)); i > last {
+				last = i
+			}
+		}
+		return last
+	}
+
+	active, idle := lastIndex(config.XiaozhiActivePatterns), lastIndex(config.XiaozhiIdlePatterns)
+	if active < 0 && idle < 0 {
+		return
+	}
+	notifyActivity(s.name, active > idle)
+}
@@ -85,6 +85,20 @@ func main() {
 		log.Fatalf("初始化显示管理器失败: %v", err)
 	}
 
+	api.InitNetease()
+
+	// 加载音乐库索引并在后台扫描
+	api.InitLibrary(config.LibraryIndexPath, config.LibraryDirs)
+	api.InitPlaylists(config.PlaylistStorePath)
+	api.InitRadio(config.RadioStorePath)
+	api.InitVolumeProfiles(config.VolumeProfilePath)
+	api.InitFocus()
+	api.InitHistory(config.HistoryPath, config.HistoryLimit)
+	api.InitLyrics()
+
+	// 启动定时任务调度
+	api.InitSchedule(config.ScheduleStorePath)
+
 	// 启动HTTP服务器
 	if err := server.Start(); err != nil {
 		printColorized(colorRed, "✗ 服务器错误: %v", err)